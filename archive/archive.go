@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ulikunitz/xz"
+)
+
+// Format is the format of an archive.
+type Format string
+
+const (
+	// FormatNone selects the format based on the
+	// archive's file extension or, when extracting,
+	// its contents.
+	FormatNone Format = ""
+	// FormatTar is an uncompressed tarball.
+	FormatTar Format = "tar"
+	// FormatTarGzip is a gzip compressed tarball.
+	FormatTarGzip Format = "tar.gz"
+	// FormatTarXz is an xz compressed tarball.
+	FormatTarXz Format = "tar.xz"
+	// FormatZip is a zip archive.
+	FormatZip Format = "zip"
+)
+
+// ErrUnsupportedFormat is returned when an archive's
+// format is unknown or cannot be read or written.
+var ErrUnsupportedFormat = errors.New("unsupported archive format")
+
+// FormatOf returns the format of the archive
+// at the given path based on its extension.
+func FormatOf(name string) (Format, error) {
+	name = strings.ToLower(name)
+
+	for _, f := range []struct {
+		Suffix string
+		Format Format
+	}{
+		{Suffix: ".tar", Format: FormatTar},
+		{Suffix: ".tar.gz", Format: FormatTarGzip},
+		{Suffix: ".tgz", Format: FormatTarGzip},
+		{Suffix: ".tar.xz", Format: FormatTarXz},
+		{Suffix: ".txz", Format: FormatTarXz},
+		{Suffix: ".zip", Format: FormatZip},
+	} {
+		if strings.HasSuffix(name, f.Suffix) {
+			return f.Format, nil
+		}
+	}
+
+	return FormatNone, fmt.Errorf("detecting format of %q: %w", name, ErrUnsupportedFormat)
+}
+
+// detectFormat returns the format of the archive file f based
+// on its contents falling back to the extension of its name.
+func detectFormat(f *os.File) (Format, error) {
+	header := make([]byte, 512)
+
+	n, err := f.ReadAt(header, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return FormatNone, fmt.Errorf("reading %q: %w", f.Name(), err)
+	}
+
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return FormatTarGzip, nil
+	case bytes.HasPrefix(header, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		return FormatTarXz, nil
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")), bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		return FormatZip, nil
+	case len(header) > 262 && bytes.Equal(header[257:262], []byte("ustar")):
+		return FormatTar, nil
+	default:
+		return FormatOf(f.Name())
+	}
+}
+
+// xzReader returns a reader decompressing r.
+func xzReader(r io.Reader) (io.Reader, error) {
+	xr, err := xz.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing: %w", err)
+	}
+
+	return xr, nil
+}
+
+// xzWriter returns a writer compressing to w
+// which must be closed to flush its output.
+func xzWriter(w io.Writer) (io.WriteCloser, error) {
+	xw, err := xz.NewWriter(w)
+	if err != nil {
+		return nil, fmt.Errorf("compressing: %w", err)
+	}
+
+	return xw, nil
+}
+
+// WithFormat sets the format of the archive
+// rather than detecting it.
+type WithFormat Format
+
+func (f WithFormat) ConfigureCreate(c *createConfig) {
+	c.Format = Format(f)
+}
+
+func (f WithFormat) ConfigureExtract(c *extractConfig) {
+	c.Format = Format(f)
+}
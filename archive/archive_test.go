@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/go-ci/archive"
+	"github.com/mt-sre/go-ci/file"
+	"github.com/mt-sre/go-ci/internal/filetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatOf(t *testing.T) {
+	t.Parallel()
+
+	for name, expected := range map[string]archive.Format{
+		"release.tar":    archive.FormatTar,
+		"release.tar.gz": archive.FormatTarGzip,
+		"release.TGZ":    archive.FormatTarGzip,
+		"release.tar.xz": archive.FormatTarXz,
+		"release.txz":    archive.FormatTarXz,
+		"release.zip":    archive.FormatZip,
+	} {
+		format, err := archive.FormatOf(name)
+		require.NoError(t, err)
+
+		assert.Equal(t, expected, format, name)
+	}
+
+	_, err := archive.FormatOf("release.rar")
+	assert.ErrorIs(t, err, archive.ErrUnsupportedFormat)
+}
+
+func TestCreateExtract(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+
+	filetest.WriteTree(t, src, map[string]string{
+		"bin/tool":     "#!/bin/sh",
+		"docs/a.md":    "a",
+		"docs/b.md":    "b",
+		"build/output": "excluded",
+	})
+	require.NoError(t, os.Chmod(filepath.Join(src, "bin", "tool"), 0o750))
+	require.NoError(t, os.Symlink("../bin/tool", filepath.Join(src, "docs", "tool")))
+
+	for _, ext := range []string{".tar", ".tar.gz", ".tar.xz", ".zip"} {
+		ext := ext
+
+		t.Run(ext, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			out := filepath.Join(dir, "release"+ext)
+
+			require.NoError(t, archive.Create(out, src, archive.WithExcludes{"build/"}))
+
+			dst := filepath.Join(dir, "dst")
+			require.NoError(t, archive.Extract(out, dst))
+
+			assert.Equal(t, map[string]string{
+				"bin/": "", "bin/tool": "#!/bin/sh",
+				"docs/": "", "docs/a.md": "a", "docs/b.md": "b", "docs/tool": "-> ../bin/tool",
+			}, filetest.ReadTree(t, dst))
+
+			info, err := os.Stat(filepath.Join(dst, "bin", "tool"))
+			require.NoError(t, err)
+
+			assert.Equal(t, fs.FileMode(0o755), info.Mode().Perm())
+			assert.True(t, info.ModTime().Equal(time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)))
+
+			selected := filepath.Join(dir, "selected")
+			require.NoError(t, archive.Extract(out, selected,
+				archive.WithPaths{"docs/*.md"},
+				archive.WithStripComponents(1),
+			))
+
+			assert.Equal(t, map[string]string{"a.md": "a", "b.md": "b"}, filetest.ReadTree(t, selected))
+		})
+	}
+}
+
+func TestCreateReproducible(t *testing.T) {
+	t.Parallel()
+
+	trees := make([]string, 2)
+
+	for i := range trees {
+		trees[i] = t.TempDir()
+
+		filetest.WriteTree(t, trees[i], map[string]string{
+			"b":       "b",
+			"a/c":     "c",
+			"a/d/e":   "e",
+			"a/d/f.x": "f",
+		})
+
+		// permissions and times differ between trees
+		mtime := time.Now().Add(time.Duration(i) * time.Hour)
+
+		require.NoError(t, os.Chmod(filepath.Join(trees[i], "b"), fs.FileMode(0o600+i*0o44)))
+		require.NoError(t, os.Chtimes(filepath.Join(trees[i], "a", "c"), mtime, mtime))
+	}
+
+	for _, ext := range []string{".tar", ".tar.gz", ".zip"} {
+		var digests []string
+
+		for _, tree := range trees {
+			out := filepath.Join(t.TempDir(), "release"+ext)
+
+			require.NoError(t, archive.Create(out, tree, archive.WithModTime(time.Unix(1700000000, 0))))
+
+			digest, err := file.Checksum(out)
+			require.NoError(t, err)
+
+			digests = append(digests, digest)
+		}
+
+		assert.Equal(t, digests[0], digests[1], "%s archives differ", ext)
+	}
+}
+
+func TestCreateWithinRoot(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	filetest.WriteTree(t, root, map[string]string{"a": "a"})
+
+	out := filepath.Join(root, "release.zip")
+
+	// the second archive must not include the first
+	for range 2 {
+		require.NoError(t, archive.Create(out, root))
+	}
+
+	dst := t.TempDir()
+	require.NoError(t, archive.Extract(out, dst))
+
+	assert.Equal(t, map[string]string{"a": "a"}, filetest.ReadTree(t, dst))
+}
+
+func TestCreateUnsupportedFormat(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	err := archive.Create(filepath.Join(root, "release.rar"), root)
+	assert.ErrorIs(t, err, archive.ErrUnsupportedFormat)
+
+	err = archive.Create(filepath.Join(root, "release"), root, archive.WithFormat("rar"))
+	assert.ErrorIs(t, err, archive.ErrUnsupportedFormat)
+}
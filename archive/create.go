@@ -0,0 +1,281 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mt-sre/go-ci/file"
+)
+
+// Create writes an archive of the contents of the directory root
+// to dst atomically. Archives are reproducible: entries are sorted
+// by path, owners are normalized to root, permissions to 0o755 for
+// directories and executable files and 0o644 otherwise, and every
+// entry has the same modification time. Symbolic links are stored
+// as links and other entities, other than regular files and
+// directories, are omitted. The format is based on the extension
+// of dst unless configured with "WithFormat".
+func Create(dst, root string, opts ...CreateOption) error {
+	var cfg createConfig
+
+	cfg.Option(opts...)
+
+	if err := cfg.Default(); err != nil {
+		return err
+	}
+
+	if cfg.Format == FormatNone {
+		var err error
+
+		if cfg.Format, err = FormatOf(dst); err != nil {
+			return err
+		}
+	}
+
+	excludes := cfg.Excludes
+
+	// an archive within root must not include itself
+	if rel, err := filepath.Rel(root, dst); err == nil && filepath.IsLocal(rel) {
+		excludes = append(excludes[:len(excludes):len(excludes)], "/"+filepath.ToSlash(rel))
+	}
+
+	ents, err := file.FindEntries(root,
+		file.WithMinDepth(1),
+		file.WithExcludes(excludes),
+		file.WithSortBy(file.SortByPath),
+	)
+	if err != nil {
+		return fmt.Errorf("creating archive of %q: %w", root, err)
+	}
+
+	write := map[Format]func(w io.Writer, ents []file.Entry) error{
+		FormatTar:     cfg.writeTar,
+		FormatTarGzip: cfg.writeTarGzip,
+		FormatTarXz:   cfg.writeTarXz,
+		FormatZip:     cfg.writeZip,
+	}[cfg.Format]
+
+	if write == nil {
+		return fmt.Errorf("creating %q as %q: %w", dst, cfg.Format, ErrUnsupportedFormat)
+	}
+
+	if err := file.WriteAtomic(dst, func(w io.Writer) error {
+		return write(w, ents)
+	}); err != nil {
+		return fmt.Errorf("creating archive %q: %w", dst, err)
+	}
+
+	return nil
+}
+
+// normalizedMode returns the archived permissions of ent.
+func normalizedMode(ent file.Entry) fs.FileMode {
+	switch {
+	case ent.Type == file.EntTypeSymlink:
+		return 0o777
+	case ent.Type == file.EntTypeDir, ent.Info.Mode().Perm()&0o111 != 0:
+		return 0o755
+	default:
+		return 0o644
+	}
+}
+
+// archived reports whether entities of the given type are archived.
+func archived(t file.EntType) bool {
+	return t == file.EntTypeDir || t == file.EntTypeFile || t == file.EntTypeSymlink
+}
+
+func (c *createConfig) writeTarGzip(w io.Writer, ents []file.Entry) error {
+	// the header's name and modification time are left unset
+	gw := gzip.NewWriter(w)
+
+	if err := c.writeTar(gw, ents); err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
+
+func (c *createConfig) writeTarXz(w io.Writer, ents []file.Entry) error {
+	xw, err := xzWriter(w)
+	if err != nil {
+		return err
+	}
+
+	if err := c.writeTar(xw, ents); err != nil {
+		xw.Close()
+
+		return err
+	}
+
+	return xw.Close()
+}
+
+func (c *createConfig) writeTar(w io.Writer, ents []file.Entry) error {
+	tw := tar.NewWriter(w)
+
+	for _, ent := range ents {
+		if !archived(ent.Type) {
+			continue
+		}
+
+		hdr := &tar.Header{
+			Name:    ent.Rel,
+			Mode:    int64(normalizedMode(ent)),
+			ModTime: c.ModTime,
+		}
+
+		switch ent.Type {
+		case file.EntTypeDir:
+			hdr.Typeflag, hdr.Name = tar.TypeDir, ent.Rel+"/"
+		case file.EntTypeSymlink:
+			hdr.Typeflag, hdr.Linkname = tar.TypeSymlink, ent.Target
+		default:
+			hdr.Typeflag, hdr.Size = tar.TypeReg, ent.Info.Size()
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("writing header of %q: %w", ent.Rel, err)
+		}
+
+		if ent.Type == file.EntTypeFile {
+			if err := copyFile(tw, ent); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tw.Close()
+}
+
+func (c *createConfig) writeZip(w io.Writer, ents []file.Entry) error {
+	zw := zip.NewWriter(w)
+
+	for _, ent := range ents {
+		if !archived(ent.Type) {
+			continue
+		}
+
+		hdr := &zip.FileHeader{
+			Name:     ent.Rel,
+			Method:   zip.Deflate,
+			Modified: c.ModTime,
+		}
+
+		switch ent.Type {
+		case file.EntTypeDir:
+			hdr.Name, hdr.Method = ent.Rel+"/", zip.Store
+			hdr.SetMode(fs.ModeDir | normalizedMode(ent))
+		case file.EntTypeSymlink:
+			hdr.SetMode(fs.ModeSymlink | normalizedMode(ent))
+		default:
+			hdr.SetMode(normalizedMode(ent))
+		}
+
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return fmt.Errorf("writing header of %q: %w", ent.Rel, err)
+		}
+
+		switch ent.Type {
+		case file.EntTypeSymlink:
+			// zip stores the destination of links as their contents
+			if _, err := io.WriteString(fw, ent.Target); err != nil {
+				return fmt.Errorf("writing %q: %w", ent.Rel, err)
+			}
+		case file.EntTypeFile:
+			if err := copyFile(fw, ent); err != nil {
+				return err
+			}
+		}
+	}
+
+	return zw.Close()
+}
+
+// copyFile writes the contents of the regular file ent to w.
+func copyFile(w io.Writer, ent file.Entry) error {
+	f, err := os.Open(ent.Path)
+	if err != nil {
+		return fmt.Errorf("opening %q: %w", ent.Path, err)
+	}
+
+	defer f.Close()
+
+	// files changing size while archived would corrupt tarballs
+	if _, err := io.CopyN(w, f, ent.Info.Size()); err != nil {
+		return fmt.Errorf("writing %q: %w", ent.Path, err)
+	}
+
+	return nil
+}
+
+// defaultModTime is the modification time of archived entities
+// when SOURCE_DATE_EPOCH is not set. It is the earliest time which
+// can be represented by zip archives.
+var defaultModTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+type createConfig struct {
+	Excludes []string
+	Format   Format
+	ModTime  time.Time
+}
+
+func (c *createConfig) Option(opts ...CreateOption) {
+	for _, opt := range opts {
+		opt.ConfigureCreate(c)
+	}
+}
+
+func (c *createConfig) Default() error {
+	if c.ModTime.IsZero() {
+		c.ModTime = defaultModTime
+
+		if epoch := strings.TrimSpace(os.Getenv("SOURCE_DATE_EPOCH")); epoch != "" {
+			secs, err := strconv.ParseInt(epoch, 10, 64)
+			if err != nil {
+				return fmt.Errorf("parsing SOURCE_DATE_EPOCH %q: %w", epoch, err)
+			}
+
+			c.ModTime = time.Unix(secs, 0)
+		}
+	}
+
+	c.ModTime = c.ModTime.UTC().Truncate(time.Second)
+
+	return nil
+}
+
+type CreateOption interface {
+	ConfigureCreate(*createConfig)
+}
+
+// WithExcludes excludes entities matching any of the given
+// patterns, as understood by file.WithExcludes, from archives.
+type WithExcludes []string
+
+func (e WithExcludes) ConfigureCreate(c *createConfig) {
+	c.Excludes = append(c.Excludes, e...)
+}
+
+// WithModTime sets the modification time of archived entities.
+// By default the time given by the SOURCE_DATE_EPOCH environment
+// variable is used and otherwise 1980-01-01T00:00:00Z.
+type WithModTime time.Time
+
+func (m WithModTime) ConfigureCreate(c *createConfig) {
+	c.ModTime = time.Time(m)
+}
@@ -0,0 +1,676 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mt-sre/go-ci/file"
+)
+
+// ErrUnsafePath is returned when an archive contains entries
+// which would be extracted outside the destination directory.
+var ErrUnsafePath = errors.New("unsafe path")
+
+// ErrTooLarge is returned when extracting an archive
+// would exceed the configured limits.
+var ErrTooLarge = errors.New("archive too large")
+
+// Extract extracts the archive at src into the directory dst which
+// is created if needed. Entries with absolute paths, paths leading
+// outside dst or symbolic links resolving outside dst are rejected
+// with ErrUnsafePath and existing symbolic links within dst are
+// never written through. Extraction stops with ErrTooLarge once
+// the configured limits are exceeded. Permissions are restored
+// without setuid, setgid and sticky bits and entities other than
+// regular files, directories and links are skipped. The format is
+// detected from the archive's contents unless configured with
+// "WithFormat".
+func Extract(src, dst string, opts ...ExtractOption) (err error) {
+	var cfg extractConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("opening %q: %w", src, err)
+	}
+
+	defer f.Close()
+
+	if cfg.Format == FormatNone {
+		if cfg.Format, err = detectFormat(f); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return fmt.Errorf("creating %q: %w", dst, err)
+	}
+
+	x := &extractor{cfg: cfg, root: dst}
+
+	// links are validated against the extracted tree
+	// even when extraction fails to not leave escaping
+	// links behind
+	defer func() {
+		err = errors.Join(err, x.checkLinks())
+	}()
+
+	switch cfg.Format {
+	case FormatTar:
+		err = x.extractTar(f)
+	case FormatTarGzip:
+		err = x.extractTarGzip(f)
+	case FormatTarXz:
+		err = x.extractTarXz(f)
+	case FormatZip:
+		err = x.extractZip(f)
+	default:
+		err = ErrUnsupportedFormat
+	}
+
+	if err != nil {
+		return fmt.Errorf("extracting %q: %w", src, err)
+	}
+
+	return x.setDirModes()
+}
+
+// member is an archived entity.
+type member struct {
+	name string
+	// mode holds the type and permission bits
+	mode     fs.FileMode
+	size     int64
+	linkname string
+	hardlink bool
+	modTime  time.Time
+	open     func() (io.ReadCloser, error)
+}
+
+// extractor extracts members below root.
+type extractor struct {
+	cfg  extractConfig
+	root string
+	// written is the number of bytes extracted so far
+	written int64
+	entries int
+	// links are the slash separated paths of extracted symbolic links
+	links []string
+	// dirs are the extracted directories whose modes
+	// and times are set once their contents exist
+	dirs []member
+}
+
+func (x *extractor) extractTarGzip(r io.Reader) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("decompressing: %w", err)
+	}
+
+	defer gr.Close()
+
+	return x.extractTar(gr)
+}
+
+func (x *extractor) extractTarXz(r io.Reader) error {
+	xr, err := xzReader(r)
+	if err != nil {
+		return err
+	}
+
+	return x.extractTar(xr)
+}
+
+func (x *extractor) extractTar(r io.Reader) error {
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("reading tarball: %w", err)
+		}
+
+		m := member{
+			name:     hdr.Name,
+			mode:     hdr.FileInfo().Mode(),
+			size:     hdr.Size,
+			linkname: hdr.Linkname,
+			modTime:  hdr.ModTime,
+			open: func() (io.ReadCloser, error) {
+				return io.NopCloser(tr), nil
+			},
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeDir, tar.TypeSymlink:
+		case tar.TypeLink:
+			m.hardlink = true
+		default:
+			// devices, FIFOs and extended headers are skipped
+			continue
+		}
+
+		if err := x.extract(m); err != nil {
+			return err
+		}
+	}
+}
+
+func (x *extractor) extractZip(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("describing %q: %w", f.Name(), err)
+	}
+
+	zr, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		return fmt.Errorf("reading zip archive: %w", err)
+	}
+
+	for _, zf := range zr.File {
+		m := member{
+			name:    zf.Name,
+			mode:    zf.Mode(),
+			size:    int64(zf.UncompressedSize64),
+			modTime: zf.Modified,
+			open:    zf.Open,
+		}
+
+		if m.mode&fs.ModeSymlink != 0 {
+			if m.linkname, err = readLinkname(zf); err != nil {
+				return err
+			}
+		}
+
+		if !m.mode.IsRegular() && !m.mode.IsDir() && m.mode&fs.ModeSymlink == 0 {
+			continue
+		}
+
+		if err := x.extract(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readLinkname reads the destination of a symbolic
+// link stored as the contents of the zip entry zf.
+func readLinkname(zf *zip.File) (string, error) {
+	r, err := zf.Open()
+	if err != nil {
+		return "", fmt.Errorf("opening %q: %w", zf.Name, err)
+	}
+
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, 4096))
+	if err != nil {
+		return "", fmt.Errorf("reading %q: %w", zf.Name, err)
+	}
+
+	return string(data), nil
+}
+
+// extract extracts m if selected.
+func (x *extractor) extract(m member) error {
+	name, err := x.clean(m.name)
+	if err != nil || name == "." || !x.cfg.selected(name) {
+		return err
+	}
+
+	rel := x.strip(name)
+	if rel == "" {
+		return nil
+	}
+
+	if x.entries++; x.cfg.MaxEntries >= 0 && x.entries > x.cfg.MaxEntries {
+		return fmt.Errorf("%w: more than %d entries", ErrTooLarge, x.cfg.MaxEntries)
+	}
+
+	if err := x.mkdirParents(rel); err != nil {
+		return err
+	}
+
+	switch dst := x.path(rel); {
+	case m.mode.IsDir():
+		return x.extractDir(m, rel, dst)
+	case m.hardlink:
+		return x.extractHardlink(m, dst)
+	case m.mode&fs.ModeSymlink != 0:
+		return x.extractSymlink(m, rel, dst)
+	default:
+		return x.extractFile(m, dst)
+	}
+}
+
+// clean returns the cleaned slash separated form of the
+// member name rejecting absolute paths and paths leading
+// outside root.
+func (x *extractor) clean(name string) (string, error) {
+	name = strings.ReplaceAll(name, `\`, "/")
+
+	if strings.HasPrefix(name, "/") || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("%w: absolute path %q", ErrUnsafePath, name)
+	}
+
+	res := path.Clean(name)
+	if res == ".." || strings.HasPrefix(res, "../") {
+		return "", fmt.Errorf("%w: %q is outside the destination", ErrUnsafePath, name)
+	}
+
+	return res, nil
+}
+
+// strip returns the cleaned name without the configured number
+// of leading elements, or the empty string when none remain.
+func (x *extractor) strip(name string) string {
+	elems := strings.Split(name, "/")
+	if len(elems) <= x.cfg.StripComponents {
+		return ""
+	}
+
+	return path.Join(elems[x.cfg.StripComponents:]...)
+}
+
+// path returns the OS path of the slash separated path rel.
+func (x *extractor) path(rel string) string {
+	return filepath.Join(x.root, filepath.FromSlash(rel))
+}
+
+// mkdirParents creates the directories containing rel refusing
+// to create entities through existing symbolic links.
+func (x *extractor) mkdirParents(rel string) error {
+	dir := x.root
+
+	for _, elem := range strings.Split(path.Dir(rel), "/") {
+		if elem == "." {
+			break
+		}
+
+		dir = filepath.Join(dir, elem)
+
+		info, err := os.Lstat(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				return fmt.Errorf("creating %q: %w", dir, err)
+			}
+
+			continue
+		} else if err != nil {
+			return fmt.Errorf("describing %q: %w", dir, err)
+		}
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("%w: %q is below the symbolic link %q", ErrUnsafePath, rel, dir)
+		}
+
+		if !info.IsDir() {
+			return fmt.Errorf("creating %q: %w", dir, fs.ErrExist)
+		}
+	}
+
+	return nil
+}
+
+// removeExisting removes the existing entity, other
+// than a directory, at name so it may be replaced.
+func removeExisting(name string) error {
+	info, err := os.Lstat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("describing %q: %w", name, err)
+	}
+
+	if info.IsDir() {
+		return fmt.Errorf("replacing %q: %w", name, fs.ErrExist)
+	}
+
+	if err := os.Remove(name); err != nil {
+		return fmt.Errorf("removing %q: %w", name, err)
+	}
+
+	return nil
+}
+
+func (x *extractor) extractDir(m member, rel, name string) error {
+	info, err := os.Lstat(name)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if err := os.Mkdir(name, 0o700); err != nil {
+			return fmt.Errorf("creating %q: %w", name, err)
+		}
+	case err != nil:
+		return fmt.Errorf("describing %q: %w", name, err)
+	case info.Mode()&fs.ModeSymlink != 0:
+		return fmt.Errorf("%w: %q is a symbolic link", ErrUnsafePath, rel)
+	case !info.IsDir():
+		return fmt.Errorf("creating %q: %w", name, fs.ErrExist)
+	}
+
+	// directories must remain writable to extract their
+	// contents until their modes are set once done
+	if err := os.Chmod(name, m.mode.Perm()|0o700); err != nil {
+		return fmt.Errorf("setting mode of %q: %w", name, err)
+	}
+
+	m.name = rel
+	x.dirs = append(x.dirs, m)
+
+	return nil
+}
+
+func (x *extractor) extractFile(m member, name string) error {
+	if m.size > x.remaining() {
+		return fmt.Errorf("%w: more than %d bytes", ErrTooLarge, x.cfg.MaxSize)
+	}
+
+	if err := removeExisting(name); err != nil {
+		return err
+	}
+
+	r, err := m.open()
+	if err != nil {
+		return fmt.Errorf("opening %q: %w", m.name, err)
+	}
+
+	defer r.Close()
+
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, m.mode.Perm())
+	if err != nil {
+		return fmt.Errorf("creating %q: %w", name, err)
+	}
+
+	// declared sizes are not trusted so the data
+	// read is limited to the remaining budget
+	limit := x.remaining()
+
+	n, err := io.Copy(f, io.LimitReader(r, limit+1))
+	if err != nil {
+		f.Close()
+
+		return fmt.Errorf("writing %q: %w", name, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing %q: %w", name, err)
+	}
+
+	if n > limit {
+		return fmt.Errorf("%w: more than %d bytes", ErrTooLarge, x.cfg.MaxSize)
+	}
+
+	x.written += n
+
+	if err := os.Chtimes(name, m.modTime, m.modTime); err != nil {
+		return fmt.Errorf("setting times of %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// remaining returns the number of bytes which may still be extracted.
+func (x *extractor) remaining() int64 {
+	if x.cfg.MaxSize < 0 {
+		return 1<<63 - 2
+	}
+
+	return x.cfg.MaxSize - x.written
+}
+
+func (x *extractor) extractSymlink(m member, rel, name string) error {
+	if filepath.IsAbs(m.linkname) || strings.HasPrefix(m.linkname, "/") {
+		return fmt.Errorf("%w: %q links to the absolute path %q", ErrUnsafePath, rel, m.linkname)
+	}
+
+	if target := path.Join(path.Dir(rel), m.linkname); target == ".." || strings.HasPrefix(target, "../") {
+		return fmt.Errorf("%w: %q links to %q outside the destination", ErrUnsafePath, rel, m.linkname)
+	}
+
+	if err := removeExisting(name); err != nil {
+		return err
+	}
+
+	if err := os.Symlink(m.linkname, name); err != nil {
+		return fmt.Errorf("creating link %q: %w", name, err)
+	}
+
+	x.links = append(x.links, rel)
+
+	return nil
+}
+
+func (x *extractor) extractHardlink(m member, name string) error {
+	target, err := x.clean(m.linkname)
+	if err != nil {
+		return err
+	}
+
+	if target = x.strip(target); target == "" || target == "." {
+		return fmt.Errorf("%w: %q links to %q", ErrUnsafePath, m.name, m.linkname)
+	}
+
+	if err := x.mkdirParents(target); err != nil {
+		return err
+	}
+
+	// the target must already have been extracted as a regular file
+	info, err := os.Lstat(x.path(target))
+	if err != nil || !info.Mode().IsRegular() {
+		return fmt.Errorf("%w: %q links to %q which is not an extracted file", ErrUnsafePath, m.name, m.linkname)
+	}
+
+	if err := removeExisting(name); err != nil {
+		return err
+	}
+
+	if err := os.Link(x.path(target), name); err != nil {
+		return fmt.Errorf("creating link %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// checkLinks removes the extracted symbolic links which resolve
+// outside root through other links returning ErrUnsafePath.
+func (x *extractor) checkLinks() error {
+	var errs []error
+
+	for _, rel := range x.links {
+		if within, err := x.resolvesWithin(rel); err == nil && within {
+			continue
+		}
+
+		os.Remove(x.path(rel))
+
+		errs = append(errs, fmt.Errorf("%w: %q resolves outside the destination", ErrUnsafePath, rel))
+	}
+
+	return errors.Join(errs...)
+}
+
+// resolvesWithin reports whether the slash separated path rel
+// resolves within root following any symbolic links within root.
+// Missing entities are resolved lexically.
+func (x *extractor) resolvesWithin(rel string) (bool, error) {
+	var (
+		resolved []string
+		pending  = strings.Split(rel, "/")
+		// limits the links followed like the kernel's ELOOP
+		follows int
+	)
+
+	for len(pending) > 0 {
+		elem := pending[0]
+		pending = pending[1:]
+
+		switch elem {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return false, nil
+			}
+
+			resolved = resolved[:len(resolved)-1]
+
+			continue
+		}
+
+		resolved = append(resolved, elem)
+
+		target, err := os.Readlink(x.path(path.Join(resolved...)))
+		if err != nil {
+			// not a link or missing
+			continue
+		}
+
+		if follows++; follows > 40 {
+			return false, fmt.Errorf("resolving %q: too many links", rel)
+		}
+
+		if filepath.IsAbs(target) {
+			return false, nil
+		}
+
+		resolved = resolved[:len(resolved)-1]
+		pending = append(strings.Split(filepath.ToSlash(target), "/"), pending...)
+	}
+
+	return true, nil
+}
+
+// setDirModes sets the modes and modification times of
+// extracted directories deepest first once their contents
+// exist as restoring a mode may deny writing to them.
+func (x *extractor) setDirModes() error {
+	dirs := slices.Clone(x.dirs)
+
+	slices.SortStableFunc(dirs, func(a, b member) int {
+		return strings.Count(b.name, "/") - strings.Count(a.name, "/")
+	})
+
+	for _, m := range dirs {
+		name := x.path(m.name)
+
+		if err := os.Chmod(name, m.mode.Perm()); err != nil {
+			return fmt.Errorf("setting mode of %q: %w", name, err)
+		}
+
+		if err := os.Chtimes(name, m.modTime, m.modTime); err != nil {
+			return fmt.Errorf("setting times of %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+type extractConfig struct {
+	Format          Format
+	MaxEntries      int
+	MaxSize         int64
+	Paths           []string
+	StripComponents int
+}
+
+func (c *extractConfig) Option(opts ...ExtractOption) {
+	for _, opt := range opts {
+		opt.ConfigureExtract(c)
+	}
+}
+
+func (c *extractConfig) Default() {
+	if c.MaxEntries == 0 {
+		c.MaxEntries = DefaultMaxEntries
+	}
+
+	if c.MaxSize == 0 {
+		c.MaxSize = DefaultMaxSize
+	}
+}
+
+// selected reports whether the slash separated path rel, or any
+// directory containing it, matches the configured patterns.
+func (c *extractConfig) selected(rel string) bool {
+	if len(c.Paths) == 0 {
+		return true
+	}
+
+	for p := rel; p != "."; p = path.Dir(p) {
+		for _, pattern := range c.Paths {
+			if matches, _ := file.MatchGlob(pattern, p); matches {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+const (
+	// DefaultMaxEntries is the default limit of extracted entries.
+	DefaultMaxEntries = 100_000
+	// DefaultMaxSize is the default limit of extracted bytes.
+	DefaultMaxSize = 4 << 30
+)
+
+type ExtractOption interface {
+	ConfigureExtract(*extractConfig)
+}
+
+// WithMaxEntries limits the number of entries extracted.
+// A negative value disables the limit which is
+// DefaultMaxEntries by default.
+type WithMaxEntries int
+
+func (m WithMaxEntries) ConfigureExtract(c *extractConfig) {
+	c.MaxEntries = int(m)
+}
+
+// WithMaxSize limits the total size in bytes of extracted
+// files. A negative value disables the limit which is
+// DefaultMaxSize by default.
+type WithMaxSize int64
+
+func (m WithMaxSize) ConfigureExtract(c *extractConfig) {
+	c.MaxSize = int64(m)
+}
+
+// WithPaths extracts only entries matching, or within directories
+// matching, any of the given glob patterns as understood by
+// file.MatchGlob. Patterns are matched against the names of
+// entries before stripping components.
+type WithPaths []string
+
+func (p WithPaths) ConfigureExtract(c *extractConfig) {
+	c.Paths = append(c.Paths, p...)
+}
+
+// WithStripComponents strips the given number of
+// leading path elements from extracted entries.
+type WithStripComponents int
+
+func (s WithStripComponents) ConfigureExtract(c *extractConfig) {
+	c.StripComponents = int(s)
+}
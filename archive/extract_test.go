@@ -0,0 +1,267 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mt-sre/go-ci/archive"
+	"github.com/mt-sre/go-ci/internal/filetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// member describes a crafted archive entry.
+type member struct {
+	Name     string
+	Data     string
+	Linkname string
+	Type     byte
+	// Mode overrides the permissions of 0o644
+	Mode int64
+	// Size overrides the size declared by tar headers
+	Size int64
+}
+
+func writeTar(t *testing.T, members ...member) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	tw := tar.NewWriter(&buf)
+
+	for _, m := range members {
+		hdr := &tar.Header{
+			Name:     m.Name,
+			Typeflag: m.Type,
+			Linkname: m.Linkname,
+			Mode:     0o644,
+			Size:     int64(len(m.Data)),
+		}
+
+		if m.Type == 0 {
+			hdr.Typeflag = tar.TypeReg
+		}
+
+		if m.Mode != 0 {
+			hdr.Mode = m.Mode
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			hdr.Size = 0
+		}
+
+		require.NoError(t, tw.WriteHeader(hdr))
+
+		_, err := tw.Write([]byte(m.Data))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+
+	name := filepath.Join(t.TempDir(), "crafted.tar")
+	require.NoError(t, os.WriteFile(name, buf.Bytes(), 0o644))
+
+	return name
+}
+
+func writeZip(t *testing.T, members ...member) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+
+	for _, m := range members {
+		w, err := zw.Create(m.Name)
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte(m.Data))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, zw.Close())
+
+	name := filepath.Join(t.TempDir(), "crafted.zip")
+	require.NoError(t, os.WriteFile(name, buf.Bytes(), 0o644))
+
+	return name
+}
+
+func TestExtractUnsafe(t *testing.T) {
+	t.Parallel()
+
+	for name, members := range map[string][]member{
+		"traversal": {
+			{Name: "ok"},
+			{Name: "dir/../../evil", Data: "evil"},
+		},
+		"absolute path": {
+			{Name: "/tmp/evil", Data: "evil"},
+		},
+		"absolute symlink": {
+			{Name: "link", Type: tar.TypeSymlink, Linkname: "/etc/passwd"},
+		},
+		"escaping symlink": {
+			{Name: "dir/link", Type: tar.TypeSymlink, Linkname: "../../outside"},
+		},
+		"symlink chain": {
+			{Name: "link", Type: tar.TypeSymlink, Linkname: "self/../outside"},
+			{Name: "self", Type: tar.TypeSymlink, Linkname: "."},
+		},
+		"write through symlink": {
+			{Name: "link", Type: tar.TypeSymlink, Linkname: "dir"},
+			{Name: "dir/", Type: tar.TypeDir},
+			{Name: "link/file", Data: "evil"},
+		},
+		"hard link outside": {
+			{Name: "link", Type: tar.TypeLink, Linkname: "../outside"},
+		},
+	} {
+		members := members
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			parent := t.TempDir()
+			dst := filepath.Join(parent, "dst")
+
+			filetest.WriteTree(t, parent, map[string]string{"outside": "secret"})
+
+			err := archive.Extract(writeTar(t, members...), dst)
+			require.ErrorIs(t, err, archive.ErrUnsafePath)
+
+			for _, m := range members {
+				if m.Type != tar.TypeSymlink {
+					continue
+				}
+
+				_, err := os.Lstat(filepath.Join(dst, m.Name))
+				if strings.HasPrefix(m.Linkname, "/") || strings.HasPrefix(m.Linkname, "..") || strings.Contains(m.Linkname, "/../") {
+					assert.True(t, os.IsNotExist(err), "escaping link %q extracted", m.Name)
+				}
+			}
+
+			data, err := os.ReadFile(filepath.Join(parent, "outside"))
+			require.NoError(t, err)
+
+			assert.Equal(t, "secret", string(data))
+		})
+	}
+}
+
+func TestExtractZipUnsafe(t *testing.T) {
+	t.Parallel()
+
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	err := archive.Extract(writeZip(t, member{Name: "../evil", Data: "evil"}), dst)
+	require.ErrorIs(t, err, archive.ErrUnsafePath)
+
+	_, err = os.Stat(filepath.Join(dst, "..", "evil"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExtractLimits(t *testing.T) {
+	t.Parallel()
+
+	big := strings.Repeat("0", 1024)
+
+	for name, tc := range map[string]struct {
+		Archive string
+		Options []archive.ExtractOption
+	}{
+		"tar size": {
+			Archive: writeTar(t, member{Name: "a", Data: big}, member{Name: "b", Data: big}),
+			Options: []archive.ExtractOption{archive.WithMaxSize(1500)},
+		},
+		"zip size": {
+			Archive: writeZip(t, member{Name: "a", Data: big}, member{Name: "b", Data: big}),
+			Options: []archive.ExtractOption{archive.WithMaxSize(1500)},
+		},
+		"entries": {
+			Archive: writeZip(t, member{Name: "a"}, member{Name: "b"}, member{Name: "c"}),
+			Options: []archive.ExtractOption{archive.WithMaxEntries(2)},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := archive.Extract(tc.Archive, t.TempDir(), tc.Options...)
+			assert.ErrorIs(t, err, archive.ErrTooLarge)
+		})
+	}
+
+	// limits may be disabled
+	require.NoError(t, archive.Extract(
+		writeTar(t, member{Name: "a", Data: big}, member{Name: "b", Data: big}),
+		t.TempDir(),
+		archive.WithMaxSize(-1),
+	))
+}
+
+func TestExtractHardlink(t *testing.T) {
+	t.Parallel()
+
+	dst := t.TempDir()
+
+	require.NoError(t, archive.Extract(writeTar(t,
+		member{Name: "pkg/a", Data: "a"},
+		member{Name: "pkg/b", Type: tar.TypeLink, Linkname: "pkg/a"},
+	), dst))
+
+	assert.Equal(t, map[string]string{"pkg/": "", "pkg/a": "a", "pkg/b": "a"}, filetest.ReadTree(t, dst))
+}
+
+func TestExtractDirModes(t *testing.T) {
+	t.Parallel()
+
+	dst := t.TempDir()
+
+	// read-only directories cannot be removed by TempDir
+	t.Cleanup(func() {
+		_ = filepath.WalkDir(dst, func(p string, d fs.DirEntry, err error) error {
+			if err == nil && d.IsDir() {
+				_ = os.Chmod(p, 0o755)
+			}
+
+			return nil
+		})
+	})
+
+	require.NoError(t, archive.Extract(writeTar(t,
+		member{Name: "ro/sub/", Type: tar.TypeDir, Mode: 0o2750},
+		member{Name: "ro/", Type: tar.TypeDir, Mode: 0o555},
+		member{Name: "ro/sub/f", Data: "f"},
+	), dst))
+
+	assert.Equal(t, map[string]string{"ro/": "", "ro/sub/": "", "ro/sub/f": "f"}, filetest.ReadTree(t, dst))
+
+	for dir, expected := range map[string]fs.FileMode{"ro": 0o555, "ro/sub": 0o750} {
+		info, err := os.Stat(filepath.Join(dst, dir))
+		require.NoError(t, err)
+
+		assert.Equal(t, expected, info.Mode()&(fs.ModePerm|fs.ModeSetgid), dir)
+	}
+}
+
+func TestExtractUnknownFormat(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "archive")
+	require.NoError(t, os.WriteFile(name, []byte("not an archive"), 0o644))
+
+	err := archive.Extract(name, t.TempDir())
+	assert.ErrorIs(t, err, archive.ErrUnsupportedFormat)
+}
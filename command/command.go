@@ -35,6 +35,11 @@ func NewCommand(name string, opts ...CommandOption) Command {
 	cfg.Option(opts...)
 	cfg.Default()
 
+	if wrapper := cfg.Wrapper; wrapper != nil {
+		cfg.Wrapper = nil
+		name, cfg = wrapper.Wrap(name, cfg)
+	}
+
 	cmd := exec.CommandContext(cfg.Ctx, name)
 
 	var (
@@ -50,6 +55,14 @@ func NewCommand(name string, opts ...CommandOption) Command {
 		cmd.Stderr = io.MultiWriter(cmd.Stderr, os.Stdout)
 	}
 
+	if cfg.Stdout != nil {
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, cfg.Stdout)
+	}
+
+	if cfg.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, cfg.Stderr)
+	}
+
 	if len(cfg.Args) > 0 {
 		cmd.Args = append(cmd.Args, cfg.Args...)
 	}
@@ -114,10 +127,13 @@ type CommandConfig struct {
 	Args           []string
 	Ctx            context.Context
 	Env            []string
+	Stderr         io.Writer
 	Stdin          io.Reader
+	Stdout         io.Writer
 	Verbose        bool
 	WithCurrentEnv bool
 	WorkDir        string
+	Wrapper        Wrapper
 }
 
 func (c *CommandConfig) Option(opts ...CommandOption) {
@@ -136,6 +152,12 @@ type CommandOption interface {
 	ConfigureCommand(*CommandConfig)
 }
 
+// Wrapper rewrites a command's name and configuration before
+// it is executed, e.g. to run the command within a container.
+type Wrapper interface {
+	Wrap(name string, cfg CommandConfig) (string, CommandConfig)
+}
+
 // WithArgs supplies the given args to the Command executable.
 type WithArgs []string
 
@@ -177,6 +199,22 @@ func (ws WithStdin) ConfigureCommand(c *CommandConfig) {
 	c.Stdin = ws.Reader
 }
 
+// WithStdout additionally writes the Command's 'out'
+// to the supplied writer as it is produced.
+type WithStdout struct{ io.Writer }
+
+func (ws WithStdout) ConfigureCommand(c *CommandConfig) {
+	c.Stdout = ws.Writer
+}
+
+// WithStderr additionally writes the Command's 'err'
+// to the supplied writer as it is produced.
+type WithStderr struct{ io.Writer }
+
+func (ws WithStderr) ConfigureCommand(c *CommandConfig) {
+	c.Stderr = ws.Writer
+}
+
 // WithConsoleOut writes the Command's 'out' and 'err' to
 // 'os.Stdout' when set to true.
 type WithConsoleOut bool
@@ -192,3 +230,10 @@ type WithWorkingDirectory string
 func (wd WithWorkingDirectory) ConfigureCommand(c *CommandConfig) {
 	c.WorkDir = string(wd)
 }
+
+// WithWrapper runs the Command as rewritten by the given Wrapper.
+type WithWrapper struct{ Wrapper }
+
+func (ww WithWrapper) ConfigureCommand(c *CommandConfig) {
+	c.Wrapper = ww.Wrapper
+}
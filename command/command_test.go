@@ -90,3 +90,45 @@ func TestNewCommandAlias(t *testing.T) {
 
 	assert.Equal(t, []string{"ls", "-la"}, cmd.cmd.Args, "expected command arguments [ls -la]")
 }
+
+type echoWrapper struct{}
+
+func (echoWrapper) Wrap(name string, cfg CommandConfig) (string, CommandConfig) {
+	cfg.Args = append([]string{name}, cfg.Args...)
+
+	return "echo", cfg
+}
+
+func TestWithWrapper(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping command tests on Windows")
+	}
+
+	cmd := NewCommand("ls", WithArgs{"-la"}, WithWrapper{echoWrapper{}})
+
+	require.NoError(t, cmd.Run())
+	assert.Equal(t, "ls -la\n", cmd.Stdout())
+}
+
+func TestWithStdoutStderr(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping command tests on Windows")
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := NewCommand("sh",
+		WithArgs{"-c", "echo out; echo err >&2"},
+		WithStdout{&stdout},
+		WithStderr{&stderr},
+	)
+
+	require.NoError(t, cmd.Run())
+	assert.Equal(t, "out\n", stdout.String())
+	assert.Equal(t, "err\n", stderr.String())
+	assert.Equal(t, "out\n", cmd.Stdout(), "output is still captured")
+}
@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ArchiveFormat is the on-disk format of saved images.
+type ArchiveFormat string
+
+const (
+	// ArchiveFormatNone uses the runtime's default format.
+	ArchiveFormatNone ArchiveFormat = ""
+	// ArchiveFormatDocker is a "docker save" compatible tarball.
+	ArchiveFormatDocker ArchiveFormat = "docker-archive"
+	// ArchiveFormatOCI is a tarball of an OCI image layout.
+	ArchiveFormatOCI ArchiveFormat = "oci-archive"
+	// ArchiveFormatOCIDir is an OCI image layout directory.
+	ArchiveFormatOCIDir ArchiveFormat = "oci-dir"
+)
+
+// ErrUnsupportedFormat is returned when the runtime
+// cannot save images in the requested format.
+var ErrUnsupportedFormat = errors.New("unsupported archive format")
+
+// Save writes the given images to 'out'. An error is returned
+// if the images cannot be saved.
+func (c *Client) Save(ctx context.Context, out string, refs []string, opts ...SaveOption) error {
+	var cfg SaveConfig
+
+	cfg.Option(opts...)
+
+	args := []string{"save", "--output", out}
+
+	switch {
+	case cfg.Format == ArchiveFormatNone:
+	case c.cfg.Flavor == FlavorDocker && cfg.Format != ArchiveFormatDocker:
+		return fmt.Errorf("saving images as %q with docker: %w", cfg.Format, ErrUnsupportedFormat)
+	case c.cfg.Flavor == FlavorPodman:
+		args = append(args, "--format", string(cfg.Format))
+	}
+
+	// podman only saves several images to docker archives, its
+	// default format, when asked to and rejects the flag otherwise
+	multi := cfg.Format == ArchiveFormatNone || cfg.Format == ArchiveFormatDocker
+
+	if c.cfg.Flavor == FlavorPodman && len(refs) > 1 && multi {
+		args = append(args, "--multi-image-archive")
+	}
+
+	args = append(args, refs...)
+
+	if _, err := c.run(ctx, args...); err != nil {
+		return fmt.Errorf("saving %s: %w", strings.Join(refs, ", "), err)
+	}
+
+	return nil
+}
+
+type SaveConfig struct {
+	Format ArchiveFormat
+}
+
+func (c *SaveConfig) Option(opts ...SaveOption) {
+	for _, opt := range opts {
+		opt.ConfigureSave(c)
+	}
+}
+
+type SaveOption interface {
+	ConfigureSave(*SaveConfig)
+}
+
+// Load loads the images contained in the archive at 'path'
+// returning the names, or IDs of untagged images, loaded.
+func (c *Client) Load(ctx context.Context, path string) ([]string, error) {
+	out, err := c.run(ctx, "load", "--input", path)
+	if err != nil {
+		return nil, fmt.Errorf("loading %q: %w", path, err)
+	}
+
+	var res []string
+
+	for _, line := range strings.Split(out, "\n") {
+		// podman: "Loaded image: a" or "Loaded image(s): a,b"
+		// docker: "Loaded image: a" or "Loaded image ID: sha256:..."
+		_, loaded, ok := strings.Cut(line, ": ")
+		if !ok || !strings.HasPrefix(line, "Loaded image") {
+			continue
+		}
+
+		for _, img := range strings.Split(loaded, ",") {
+			if img = strings.TrimSpace(img); img != "" {
+				res = append(res, img)
+			}
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,508 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mt-sre/go-ci/command"
+)
+
+// ErrCredentialsNotFound is returned when no credentials
+// are configured for a registry.
+var ErrCredentialsNotFound = errors.New("credentials not found")
+
+// Credentials authenticate against a registry.
+type Credentials struct {
+	Username string
+	Password string
+	// IdentityToken is an OAuth2 refresh token which
+	// is exchanged for registry access tokens.
+	IdentityToken string
+}
+
+// AuthFile is the registry credential file format shared by
+// podman's "auth.json" and docker's "config.json". Fields not
+// related to credentials are preserved when the file is written.
+type AuthFile struct {
+	Auths       map[string]AuthEntry
+	CredHelpers map[string]string
+	CredsStore  string
+
+	other map[string]json.RawMessage
+}
+
+// AuthEntry holds inline credentials for a registry.
+type AuthEntry struct {
+	// Auth is the base64 encoding of "username:password".
+	Auth          string `json:"auth,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+}
+
+// DefaultAuthFilePaths returns the auth files consulted by
+// podman and docker ordered from highest to lowest precedence.
+func DefaultAuthFilePaths() []string {
+	var paths []string
+
+	if path := os.Getenv("REGISTRY_AUTH_FILE"); path != "" {
+		paths = append(paths, path)
+	}
+
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "containers", "auth.json"))
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err == nil {
+		paths = append(paths, filepath.Join(configDir, "containers", "auth.json"))
+	}
+
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "config.json"))
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".docker", "config.json"))
+	}
+
+	return paths
+}
+
+// LoadAuthFile reads the auth file at the given path.
+func LoadAuthFile(path string) (*AuthFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading auth file %q: %w", path, err)
+	}
+
+	var res AuthFile
+
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("parsing auth file %q: %w", path, err)
+	}
+
+	return &res, nil
+}
+
+// LoadDefaultAuthFiles merges all existing default auth files
+// giving precedence to the files returned first by
+// "DefaultAuthFilePaths".
+func LoadDefaultAuthFiles() (*AuthFile, error) {
+	res := &AuthFile{}
+
+	for _, path := range DefaultAuthFilePaths() {
+		file, err := LoadAuthFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		res.Merge(file)
+	}
+
+	return res, nil
+}
+
+func (a *AuthFile) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.other); err != nil {
+		return err
+	}
+
+	for key, dst := range map[string]any{
+		"auths":       &a.Auths,
+		"credHelpers": &a.CredHelpers,
+		"credsStore":  &a.CredsStore,
+	} {
+		raw, ok := a.other[key]
+		if !ok {
+			continue
+		}
+
+		if err := json.Unmarshal(raw, dst); err != nil {
+			return fmt.Errorf("parsing %q: %w", key, err)
+		}
+
+		delete(a.other, key)
+	}
+
+	return nil
+}
+
+func (a AuthFile) MarshalJSON() ([]byte, error) {
+	res := make(map[string]any, len(a.other)+3)
+
+	for k, v := range a.other {
+		res[k] = v
+	}
+
+	res["auths"] = a.Auths
+	if res["auths"] == nil {
+		res["auths"] = map[string]AuthEntry{}
+	}
+
+	if len(a.CredHelpers) > 0 {
+		res["credHelpers"] = a.CredHelpers
+	}
+
+	if a.CredsStore != "" {
+		res["credsStore"] = a.CredsStore
+	}
+
+	return json.Marshal(res)
+}
+
+// Merge adds the entries of 'other' which are not
+// already present in the receiver.
+func (a *AuthFile) Merge(other *AuthFile) {
+	for k, v := range other.Auths {
+		if _, ok := a.Auths[k]; !ok {
+			if a.Auths == nil {
+				a.Auths = make(map[string]AuthEntry)
+			}
+
+			a.Auths[k] = v
+		}
+	}
+
+	for k, v := range other.CredHelpers {
+		if _, ok := a.CredHelpers[k]; !ok {
+			if a.CredHelpers == nil {
+				a.CredHelpers = make(map[string]string)
+			}
+
+			a.CredHelpers[k] = v
+		}
+	}
+
+	if a.CredsStore == "" {
+		a.CredsStore = other.CredsStore
+	}
+}
+
+// Set stores inline credentials for the given registry, or
+// registry and repository namespace, e.g. "quay.io/org".
+func (a *AuthFile) Set(key string, creds Credentials) {
+	if a.Auths == nil {
+		a.Auths = make(map[string]AuthEntry)
+	}
+
+	entry := AuthEntry{IdentityToken: creds.IdentityToken}
+	if creds.Username != "" || creds.Password != "" {
+		entry.Auth = base64.StdEncoding.EncodeToString([]byte(creds.Username + ":" + creds.Password))
+	}
+
+	a.Auths[key] = entry
+}
+
+// Write writes the auth file to the given path readable only by
+// the current user. The file is replaced atomically.
+func (a *AuthFile) Write(path string) error {
+	data, err := json.MarshalIndent(a, "", "\t")
+	if err != nil {
+		return fmt.Errorf("encoding auth file: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("creating directory %q: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".auth-*.json")
+	if err != nil {
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("writing auth file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing auth file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replacing auth file %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// Resolve returns the credentials for the image reference 'ref'.
+// Credential helpers configured for the registry take precedence
+// followed by the most specific inline entry and finally the
+// default credential store. ErrCredentialsNotFound is returned
+// if no credentials are configured.
+func (a *AuthFile) Resolve(ctx context.Context, ref string) (Credentials, error) {
+	parsed, err := ParseReference(ref)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	if helper, ok := a.credHelper(parsed.Registry); ok {
+		return helperCredentials(ctx, helper, parsed.Registry)
+	}
+
+	if entry, ok := a.lookupEntry(parsed); ok {
+		return entry.credentials()
+	}
+
+	if a.CredsStore != "" {
+		return helperCredentials(ctx, a.CredsStore, parsed.Registry)
+	}
+
+	return Credentials{}, fmt.Errorf("resolving %q: %w", ref, ErrCredentialsNotFound)
+}
+
+func (a *AuthFile) credHelper(registry string) (string, bool) {
+	for key, helper := range a.CredHelpers {
+		if normalizeAuthKey(key) == registry {
+			return helper, true
+		}
+	}
+
+	return "", false
+}
+
+// lookupEntry finds the inline entry for the most specific
+// namespace of the reference's repository.
+func (a *AuthFile) lookupEntry(ref Reference) (AuthEntry, bool) {
+	entries := make(map[string]AuthEntry, len(a.Auths))
+	for key, entry := range a.Auths {
+		if entry.Auth == "" && entry.IdentityToken == "" {
+			// placeholders written alongside credential stores
+			continue
+		}
+
+		entries[normalizeAuthKey(key)] = entry
+	}
+
+	name := ref.Name()
+
+	for {
+		if entry, ok := entries[name]; ok {
+			return entry, true
+		}
+
+		i := strings.LastIndex(name, "/")
+		if i < 0 {
+			return AuthEntry{}, false
+		}
+
+		name = name[:i]
+	}
+}
+
+// normalizeAuthKey converts legacy docker keys such as
+// "https://index.docker.io/v1/" to registry hostnames.
+func normalizeAuthKey(key string) string {
+	key = strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+	key = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(key, "/"), "/v1"), "/v2")
+
+	switch key {
+	case "index.docker.io", "registry-1.docker.io":
+		return DockerHub
+	}
+
+	return key
+}
+
+func (e AuthEntry) credentials() (Credentials, error) {
+	res := Credentials{IdentityToken: e.IdentityToken}
+
+	if e.Auth == "" {
+		return res, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(e.Auth)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("decoding auth entry: %w", err)
+	}
+
+	user, pass, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return Credentials{}, errors.New("decoding auth entry: missing ':' separator")
+	}
+
+	res.Username, res.Password = user, pass
+
+	return res, nil
+}
+
+// helperCredentials queries a docker credential helper.
+// See https://github.com/docker/docker-credential-helpers.
+func helperCredentials(ctx context.Context, helper, registry string) (Credentials, error) {
+	serverURL := registry
+	if registry == DockerHub {
+		serverURL = "https://index.docker.io/v1/"
+	}
+
+	get := command.NewCommand("docker-credential-"+helper,
+		command.WithContext{Context: ctx},
+		command.WithArgs{"get"},
+		command.WithCurrentEnv(true),
+		command.WithStdin{Reader: strings.NewReader(serverURL)},
+	)
+	if err := get.Run(); err != nil {
+		return Credentials{}, fmt.Errorf("starting credential helper %q: %w", helper, err)
+	}
+
+	if !get.Success() {
+		if strings.Contains(get.CombinedOutput(), "credentials not found") {
+			return Credentials{}, fmt.Errorf("credential helper %q for %q: %w", helper, registry, ErrCredentialsNotFound)
+		}
+
+		return Credentials{}, fmt.Errorf("running credential helper %q: %w", helper, get.Error())
+	}
+
+	var out struct {
+		Username string
+		Secret   string
+	}
+
+	if err := json.NewDecoder(bytes.NewBufferString(get.Stdout())).Decode(&out); err != nil {
+		return Credentials{}, fmt.Errorf("parsing output of credential helper %q: %w", helper, err)
+	}
+
+	if out.Username == "<token>" {
+		return Credentials{IdentityToken: out.Secret}, nil
+	}
+
+	return Credentials{Username: out.Username, Password: out.Secret}, nil
+}
+
+// TempAuthFile resolves the credentials for each of the given
+// image references and writes them inline to a new temporary
+// auth file. The file is named "config.json" so that it may be
+// used by both podman and docker, with credentials stored under
+// both repository and registry keys since docker does not support
+// repository scoped credentials. Credentials are resolved
+// eagerly so that no helpers are required when the file is used.
+// References without credentials are skipped. The user's docker CLI
+// plugins, e.g. buildx, are linked alongside the file so that they
+// remain available when its directory is used as DOCKER_CONFIG.
+func (a *AuthFile) TempAuthFile(ctx context.Context, refs ...string) (*TempAuthFile, error) {
+	var scoped AuthFile
+
+	for _, ref := range refs {
+		creds, err := a.Resolve(ctx, ref)
+		if errors.Is(err, ErrCredentialsNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		parsed, _ := ParseReference(ref)
+
+		scoped.Set(parsed.Name(), creds)
+
+		// docker only looks up credentials by registry host so the
+		// credentials of the first reference to each host are kept
+		if _, ok := scoped.Auths[parsed.Registry]; !ok {
+			scoped.Set(parsed.Registry, creds)
+		}
+
+		if parsed.Registry == DockerHub {
+			// docker only consults the legacy key for Docker Hub
+			scoped.Set("https://index.docker.io/v1/", creds)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "go-ci-auth-")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary directory: %w", err)
+	}
+
+	res := &TempAuthFile{Path: filepath.Join(dir, "config.json")}
+
+	if err := scoped.Write(res.Path); err != nil {
+		_ = res.Remove()
+
+		return nil, err
+	}
+
+	if err := linkCLIPlugins(dir); err != nil {
+		_ = res.Remove()
+
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// linkCLIPlugins links the "cli-plugins" directory of the user's
+// docker config directory into dir, if it exists, as docker only
+// finds user plugins within the directory given by DOCKER_CONFIG.
+func linkCLIPlugins(dir string) error {
+	configDir := os.Getenv("DOCKER_CONFIG")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+
+		configDir = filepath.Join(home, ".docker")
+	}
+
+	plugins, err := filepath.Abs(filepath.Join(configDir, "cli-plugins"))
+	if err != nil {
+		return fmt.Errorf("resolving docker CLI plugins: %w", err)
+	}
+
+	if _, err := os.Stat(plugins); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("describing docker CLI plugins: %w", err)
+	}
+
+	if err := os.Symlink(plugins, filepath.Join(dir, "cli-plugins")); err != nil {
+		return fmt.Errorf("linking docker CLI plugins: %w", err)
+	}
+
+	return nil
+}
+
+// TempAuthFile is a temporary auth file which
+// must be removed once no longer needed.
+type TempAuthFile struct {
+	Path string
+}
+
+// Remove deletes the auth file and its directory.
+func (f *TempAuthFile) Remove() error {
+	if err := os.RemoveAll(filepath.Dir(f.Path)); err != nil {
+		return fmt.Errorf("removing temporary auth file: %w", err)
+	}
+
+	return nil
+}
+
+// authEnv returns the environment directing a runtime
+// of the given flavor to use the auth file at 'path'.
+func authEnv(flavor Flavor, path string) map[string]string {
+	if path == "" {
+		return nil
+	}
+
+	if flavor == FlavorDocker {
+		return map[string]string{"DOCKER_CONFIG": filepath.Dir(path)}
+	}
+
+	return map[string]string{"REGISTRY_AUTH_FILE": path}
+}
@@ -0,0 +1,201 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mt-sre/go-ci/container"
+	"github.com/mt-sre/go-ci/container/containertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAuthFile = `{
+	"auths": {
+		"quay.io": {"auth": "cXVheTpxdWF5LXBhc3M="},
+		"quay.io/team": {"auth": "dGVhbTp0ZWFtLXBhc3M="},
+		"https://index.docker.io/v1/": {"auth": "aHViOmh1Yi1wYXNz"},
+		"ghcr.io": {}
+	},
+	"credHelpers": {
+		"registry.example.com": "fake"
+	},
+	"psFormat": "table {{.ID}}"
+}`
+
+func writeAuthFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "auth.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestAuthFileResolve(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping credential helper tests on Windows")
+	}
+
+	// fake credential helper echoing the requested server
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "docker-credential-fake"), []byte(`#!/bin/sh
+read server
+if [ "$server" = "registry.example.com" ]; then
+	echo '{"ServerURL":"registry.example.com","Username":"helper","Secret":"helper-pass"}'
+else
+	echo "credentials not found in native keychain"
+	exit 1
+fi
+`), 0o755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	auth, err := container.LoadAuthFile(writeAuthFile(t, testAuthFile))
+	require.NoError(t, err)
+
+	for ref, expected := range map[string]container.Credentials{
+		"quay.io/org/app:v1":         {Username: "quay", Password: "quay-pass"},
+		"quay.io/team/app":           {Username: "team", Password: "team-pass"},
+		"busybox":                    {Username: "hub", Password: "hub-pass"},
+		"registry.example.com/a/b:1": {Username: "helper", Password: "helper-pass"},
+	} {
+		creds, err := auth.Resolve(context.Background(), ref)
+		require.NoError(t, err, ref)
+
+		assert.Equal(t, expected, creds, ref)
+	}
+
+	for _, ref := range []string{"ghcr.io/org/app", "example.org/app"} {
+		_, err := auth.Resolve(context.Background(), ref)
+		assert.ErrorIs(t, err, container.ErrCredentialsNotFound, ref)
+	}
+
+	auth.CredsStore = "fake"
+
+	_, err = auth.Resolve(context.Background(), "example.org/app")
+	assert.ErrorIs(t, err, container.ErrCredentialsNotFound)
+}
+
+func TestAuthFileMergeAndWrite(t *testing.T) {
+	t.Parallel()
+
+	auth, err := container.LoadAuthFile(writeAuthFile(t, testAuthFile))
+	require.NoError(t, err)
+
+	var other container.AuthFile
+
+	other.Set("quay.io", container.Credentials{Username: "other", Password: "other"})
+	other.Set("registry.io", container.Credentials{IdentityToken: "token"})
+	other.CredsStore = "secretservice"
+
+	auth.Merge(&other)
+
+	path := filepath.Join(t.TempDir(), "nested", "auth.json")
+	require.NoError(t, auth.Write(path))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	written, err := container.LoadAuthFile(path)
+	require.NoError(t, err)
+
+	creds, err := written.Resolve(context.Background(), "quay.io/org/app")
+	require.NoError(t, err)
+	assert.Equal(t, "quay", creds.Username, "existing entries take precedence")
+
+	creds, err = written.Resolve(context.Background(), "registry.io/app")
+	require.NoError(t, err)
+	assert.Equal(t, "token", creds.IdentityToken)
+
+	assert.Equal(t, "secretservice", written.CredsStore)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var raw map[string]any
+
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.Equal(t, "table {{.ID}}", raw["psFormat"], "unrelated fields are preserved")
+}
+
+func TestTempAuthFile(t *testing.T) {
+	t.Parallel()
+
+	auth, err := container.LoadAuthFile(writeAuthFile(t, testAuthFile))
+	require.NoError(t, err)
+
+	tmp, err := auth.TempAuthFile(context.Background(), "quay.io/team/app", "busybox", "ghcr.io/none")
+	require.NoError(t, err)
+
+	assert.Equal(t, "config.json", filepath.Base(tmp.Path))
+
+	scoped, err := container.LoadAuthFile(tmp.Path)
+	require.NoError(t, err)
+
+	assert.Len(t, scoped.Auths, 5)
+	assert.Contains(t, scoped.Auths, "quay.io/team/app")
+	assert.Contains(t, scoped.Auths, "quay.io")
+	assert.Equal(t, scoped.Auths["quay.io/team/app"], scoped.Auths["quay.io"])
+	assert.Contains(t, scoped.Auths, "docker.io/library/busybox")
+	assert.Contains(t, scoped.Auths, "docker.io")
+	assert.Contains(t, scoped.Auths, "https://index.docker.io/v1/")
+	assert.Empty(t, scoped.CredHelpers)
+
+	t.Run("passed through environment", func(t *testing.T) {
+		for flavor, expected := range map[container.Flavor]map[string]string{
+			container.FlavorPodman: {"REGISTRY_AUTH_FILE": tmp.Path},
+			container.FlavorDocker: {"DOCKER_CONFIG": filepath.Dir(tmp.Path)},
+		} {
+			var exec containertest.Executor
+
+			client := containertest.NewClient(&exec, container.WithFlavor(flavor))
+
+			require.NoError(t, client.Push(context.Background(), "quay.io/team/app", container.WithAuthFile(tmp.Path)))
+
+			push := exec.Calls("push")
+			require.Len(t, push, 1)
+			assert.Equal(t, expected, push[0].Env)
+
+			for _, arg := range push[0].Args {
+				assert.False(t, strings.Contains(arg, "team-pass"))
+			}
+		}
+	})
+
+	require.NoError(t, tmp.Remove())
+	assert.NoDirExists(t, filepath.Dir(tmp.Path))
+}
+
+func TestTempAuthFileCLIPlugins(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", configDir)
+
+	plugin := filepath.Join(configDir, "cli-plugins", "docker-buildx")
+	require.NoError(t, os.MkdirAll(filepath.Dir(plugin), 0o755))
+	require.NoError(t, os.WriteFile(plugin, []byte("#!/bin/sh\n"), 0o755))
+
+	var auth container.AuthFile
+
+	tmp, err := auth.TempAuthFile(context.Background())
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(tmp.Path), "cli-plugins", "docker-buildx"))
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\n", string(data))
+
+	require.NoError(t, tmp.Remove())
+	assert.FileExists(t, plugin, "plugins must not be removed with the auth file")
+}
@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"context"
+	"fmt"
+)
+
+// Build builds an image from the given context directory.
+// An error is returned if the build fails.
+func (c *Client) Build(ctx context.Context, contextDir string, opts ...BuildOption) error {
+	var cfg BuildConfig
+
+	cfg.Option(opts...)
+
+	return c.build(ctx, contextDir, cfg)
+}
+
+func (c *Client) build(ctx context.Context, contextDir string, cfg BuildConfig) error {
+	if _, err := c.execute(ctx, Invocation{
+		Args: cfg.args(contextDir),
+		Env:  mergeEnv(cfg.BuildArgs, authEnv(c.cfg.Flavor, cfg.AuthFile)),
+	}); err != nil {
+		return fmt.Errorf("building %q: %w", contextDir, err)
+	}
+
+	return nil
+}
+
+type BuildConfig struct {
+	AuthFile string
+	// BuildArgs are passed through the runtime's environment
+	// so that their values never appear in argv.
+	BuildArgs     map[string]string
+	Containerfile string
+	ExtraArgs     []string
+	// ImageChecker is consulted by "BuildCached" for
+	// images missing from local storage.
+	ImageChecker ImageChecker
+	Labels       Labels
+	Platform     string
+	Tags         []string
+}
+
+func (c *BuildConfig) Option(opts ...BuildOption) {
+	for _, opt := range opts {
+		opt.ConfigureBuild(c)
+	}
+}
+
+func (c *BuildConfig) args(contextDir string) []string {
+	args := []string{"build"}
+
+	if c.Containerfile != "" {
+		args = append(args, "--file", c.Containerfile)
+	}
+
+	if c.Platform != "" {
+		args = append(args, "--platform", c.Platform)
+	}
+
+	for _, tag := range c.Tags {
+		args = append(args, "--tag", tag)
+	}
+
+	for _, k := range sortedKeys(c.BuildArgs) {
+		args = append(args, "--build-arg", k)
+	}
+
+	args = append(args, c.Labels.Args()...)
+	args = append(args, c.ExtraArgs...)
+
+	return append(args, contextDir)
+}
+
+type BuildOption interface {
+	ConfigureBuild(*BuildConfig)
+}
+
+// Push pushes the given image reference to its registry.
+func (c *Client) Push(ctx context.Context, ref string, opts ...PushOption) error {
+	var cfg PushConfig
+
+	cfg.Option(opts...)
+
+	args := []string{"push"}
+
+	// docker trusts plain HTTP registries on loopback addresses
+	// and otherwise requires daemon configuration
+	if cfg.Insecure && c.cfg.Flavor == FlavorPodman {
+		args = append(args, "--tls-verify=false")
+	}
+
+	args = append(args, cfg.ExtraArgs...)
+	args = append(args, ref)
+
+	if _, err := c.runWithEnv(ctx, authEnv(c.cfg.Flavor, cfg.AuthFile), args...); err != nil {
+		return fmt.Errorf("pushing %q: %w", ref, err)
+	}
+
+	return nil
+}
+
+type PushConfig struct {
+	AuthFile  string
+	ExtraArgs []string
+	Insecure  bool
+}
+
+func (c *PushConfig) Option(opts ...PushOption) {
+	for _, opt := range opts {
+		opt.ConfigurePush(c)
+	}
+}
+
+type PushOption interface {
+	ConfigurePush(*PushConfig)
+}
+
+func mergeEnv(envs ...map[string]string) map[string]string {
+	var res map[string]string
+
+	for _, env := range envs {
+		for k, v := range env {
+			if res == nil {
+				res = make(map[string]string)
+			}
+
+			res[k] = v
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,252 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ContextHash returns a hex encoded SHA-256 digest of everything
+// which determines the result of a build: the files of contextDir
+// not excluded by "LoadIgnorePatterns", the Containerfile and the
+// build args, platform and extra args supplied by opts. File names,
+// contents, modes and symlink targets are hashed while modification
+// times and ownership are not. Labels are not hashed so that metadata
+// such as the git revision does not invalidate cached images.
+func ContextHash(contextDir string, opts ...BuildOption) (string, error) {
+	var cfg BuildConfig
+
+	cfg.Option(opts...)
+
+	return contextHash(contextDir, cfg)
+}
+
+func contextHash(contextDir string, cfg BuildConfig) (string, error) {
+	ignore, err := LoadIgnorePatterns(contextDir)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+
+	walk := func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(contextDir, path)
+		if err != nil {
+			return err
+		}
+
+		if rel == "." {
+			return nil
+		}
+
+		// directories are not pruned since exceptions may
+		// re-include paths beneath excluded directories
+		if ignore.Ignored(rel) {
+			return nil
+		}
+
+		return hashEntry(h, path, filepath.ToSlash(rel), d)
+	}
+
+	if err := filepath.WalkDir(contextDir, walk); err != nil {
+		return "", fmt.Errorf("hashing build context: %w", err)
+	}
+
+	containerfile, err := resolveContainerfile(contextDir, cfg.Containerfile)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(h, "containerfile\x00")
+
+	if err := hashFile(h, containerfile); err != nil {
+		return "", fmt.Errorf("hashing containerfile: %w", err)
+	}
+
+	for _, k := range sortedKeys(cfg.BuildArgs) {
+		fmt.Fprintf(h, "arg\x00%s=%s\x00", k, cfg.BuildArgs[k])
+	}
+
+	fmt.Fprintf(h, "platform\x00%s\x00", cfg.Platform)
+
+	// flags such as "--target" change the result of builds
+	// and their order may be significant
+	for _, arg := range cfg.ExtraArgs {
+		fmt.Fprintf(h, "extra\x00%s\x00", arg)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashEntry(h hash.Hash, path, rel string, d fs.DirEntry) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(h, "%s\x00%s\x00", rel, info.Mode())
+
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(h, "%s\x00", target)
+	case info.Mode().IsRegular():
+		if err := hashFile(h, path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hashFile writes the length prefixed digest of the file at path.
+func hashFile(h hash.Hash, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	sum := sha256.New()
+	if _, err := io.Copy(sum, f); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(h, "%x\x00", sum.Sum(nil))
+
+	return nil
+}
+
+// resolveContainerfile returns the Containerfile used by the runtime
+// which defaults to "Containerfile", or "Dockerfile", in contextDir.
+func resolveContainerfile(contextDir, containerfile string) (string, error) {
+	if containerfile != "" {
+		return containerfile, nil
+	}
+
+	for _, name := range []string{"Containerfile", "Dockerfile"} {
+		path := filepath.Join(contextDir, name)
+
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("no Containerfile or Dockerfile found in %q: %w", contextDir, fs.ErrNotExist)
+}
+
+// ImageChecker reports whether an image exists.
+// "*Client" checks the runtime's local storage.
+type ImageChecker interface {
+	ImageExists(ctx context.Context, ref string) (bool, error)
+}
+
+// ImageExists reports whether the image exists in local storage.
+func (c *Client) ImageExists(ctx context.Context, ref string) (bool, error) {
+	args := []string{"image", "exists", ref}
+	if c.cfg.Flavor == FlavorDocker {
+		args = []string{"image", "inspect", "--format", "{{.Id}}", ref}
+	}
+
+	_, err := c.run(ctx, args...)
+
+	var execErr *ExecError
+
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &execErr) && execErr.ExitCode == 1:
+		return false, nil
+	default:
+		return false, fmt.Errorf("checking for image %q: %w", ref, err)
+	}
+}
+
+// CachedBuild describes the result of "BuildCached".
+type CachedBuild struct {
+	// Ref is the repository tagged with the context hash.
+	Ref  string
+	Hash string
+	// Skipped is true when an existing image was found.
+	Skipped bool
+}
+
+// BuildCached builds contextDir as "repository:<hash>" where hash
+// is the "ContextHash" of the build. The build is skipped when the
+// image exists in local storage or according to the checker given
+// by "WithImageChecker", e.g. one querying the target registry.
+// Additional tags given by "WithTags" are applied to built images
+// and to images found in local storage.
+func (c *Client) BuildCached(ctx context.Context, contextDir, repository string, opts ...BuildOption) (CachedBuild, error) {
+	var cfg BuildConfig
+
+	cfg.Option(opts...)
+
+	sum, err := contextHash(contextDir, cfg)
+	if err != nil {
+		return CachedBuild{}, err
+	}
+
+	res := CachedBuild{
+		Ref:  repository + ":" + sum,
+		Hash: sum,
+	}
+
+	local, err := c.ImageExists(ctx, res.Ref)
+	if err != nil {
+		return CachedBuild{}, err
+	}
+
+	if local {
+		for _, tag := range cfg.Tags {
+			if _, err := c.run(ctx, "tag", res.Ref, tag); err != nil {
+				return CachedBuild{}, fmt.Errorf("tagging %q as %q: %w", res.Ref, tag, err)
+			}
+		}
+
+		res.Skipped = true
+
+		return res, nil
+	}
+
+	if cfg.ImageChecker != nil {
+		remote, err := cfg.ImageChecker.ImageExists(ctx, res.Ref)
+		if err != nil {
+			return CachedBuild{}, err
+		}
+
+		if remote {
+			res.Skipped = true
+
+			return res, nil
+		}
+	}
+
+	cfg.Tags = append([]string{res.Ref}, cfg.Tags...)
+
+	if err := c.build(ctx, contextDir, cfg); err != nil {
+		return CachedBuild{}, err
+	}
+
+	return res, nil
+}
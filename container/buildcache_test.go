@@ -0,0 +1,203 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mt-sre/go-ci/container"
+	"github.com/mt-sre/go-ci/container/containertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnorePatterns(t *testing.T) {
+	t.Parallel()
+
+	patterns, err := container.ParseIgnorePatterns(strings.NewReader(`
+# comment
+*.log
+/build
+**/testdata
+docs
+!docs/README.md
+tmp?
+`))
+	require.NoError(t, err)
+
+	for rel, expected := range map[string]bool{
+		"app.log":                true,
+		"sub/app.log":            false,
+		"build":                  true,
+		"build/out/bin":          true,
+		"testdata/x":             true,
+		"pkg/a/testdata/fixture": true,
+		"docs/guide.md":          true,
+		"docs/README.md":         false,
+		"tmp1/file":              true,
+		"tmp12":                  false,
+		"main.go":                false,
+	} {
+		assert.Equal(t, expected, patterns.Ignored(rel), rel)
+	}
+}
+
+func writeContext(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+	}
+
+	return dir
+}
+
+func TestContextHash(t *testing.T) {
+	t.Parallel()
+
+	dir := writeContext(t, map[string]string{
+		"Containerfile":    "FROM scratch\nCOPY main.go /\n",
+		".containerignore": "*.log\n",
+		"main.go":          "package main\n",
+	})
+
+	base, err := container.ContextHash(dir)
+	require.NoError(t, err)
+	assert.Len(t, base, 64)
+
+	same, err := container.ContextHash(dir)
+	require.NoError(t, err)
+	assert.Equal(t, base, same)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "debug.log"), []byte("ignored"), 0o644))
+
+	ignored, err := container.ContextHash(dir)
+	require.NoError(t, err)
+	assert.Equal(t, base, ignored, "ignored files do not change the hash")
+
+	withArgs, err := container.ContextHash(dir, container.WithBuildArgs{"VERSION": "1"})
+	require.NoError(t, err)
+	assert.NotEqual(t, base, withArgs)
+
+	withPlatform, err := container.ContextHash(dir, container.WithPlatform("linux/arm64"))
+	require.NoError(t, err)
+	assert.NotEqual(t, base, withPlatform)
+
+	build, err := container.ContextHash(dir, container.WithExtraArgs{"--target", "build"})
+	require.NoError(t, err)
+	assert.NotEqual(t, base, build)
+
+	final, err := container.ContextHash(dir, container.WithExtraArgs{"--target", "final"})
+	require.NoError(t, err)
+	assert.NotEqual(t, build, final, "extra args are hashed")
+
+	require.NoError(t, os.Chmod(filepath.Join(dir, "main.go"), 0o755))
+
+	chmod, err := container.ContextHash(dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, base, chmod, "modes are hashed")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package app\n"), 0o755))
+
+	changed, err := container.ContextHash(dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, chmod, changed, "contents are hashed")
+
+	_, err = container.ContextHash(t.TempDir())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+type imageCheckerFunc func(ctx context.Context, ref string) (bool, error)
+
+func (f imageCheckerFunc) ImageExists(ctx context.Context, ref string) (bool, error) {
+	return f(ctx, ref)
+}
+
+func TestBuildCached(t *testing.T) {
+	t.Parallel()
+
+	dir := writeContext(t, map[string]string{
+		"Containerfile": "FROM scratch\n",
+	})
+
+	sum, err := container.ContextHash(dir)
+	require.NoError(t, err)
+
+	ref := "quay.io/org/app:" + sum
+
+	t.Run("builds missing images", func(t *testing.T) {
+		t.Parallel()
+
+		var exec containertest.Executor
+
+		exec.Handle([]string{"image", "exists"}, containertest.Fail(1, ""))
+
+		client := containertest.NewClient(&exec)
+
+		res, err := client.BuildCached(context.Background(), dir, "quay.io/org/app",
+			container.WithTags{"quay.io/org/app:latest"},
+			container.WithImageChecker{ImageChecker: imageCheckerFunc(func(_ context.Context, r string) (bool, error) {
+				assert.Equal(t, ref, r)
+
+				return false, nil
+			})},
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, container.CachedBuild{Ref: ref, Hash: sum}, res)
+
+		build := exec.Calls("build")
+		require.Len(t, build, 1)
+		assert.Equal(t, []string{"build", "--tag", ref, "--tag", "quay.io/org/app:latest", dir}, build[0].Args)
+	})
+
+	t.Run("skips local images", func(t *testing.T) {
+		t.Parallel()
+
+		var exec containertest.Executor
+
+		client := containertest.NewClient(&exec)
+
+		res, err := client.BuildCached(context.Background(), dir, "quay.io/org/app",
+			container.WithTags{"quay.io/org/app:latest"})
+		require.NoError(t, err)
+
+		assert.True(t, res.Skipped)
+		assert.Empty(t, exec.Calls("build"))
+
+		tag := exec.Calls("tag")
+		require.Len(t, tag, 1)
+		assert.Equal(t, []string{"tag", ref, "quay.io/org/app:latest"}, tag[0].Args)
+	})
+
+	t.Run("skips remote images", func(t *testing.T) {
+		t.Parallel()
+
+		var exec containertest.Executor
+
+		exec.Handle([]string{"image", "inspect"}, containertest.Fail(1, "No such image"))
+
+		client := containertest.NewClient(&exec, container.WithFlavor(container.FlavorDocker))
+
+		res, err := client.BuildCached(context.Background(), dir, "quay.io/org/app",
+			container.WithImageChecker{ImageChecker: imageCheckerFunc(func(context.Context, string) (bool, error) {
+				return true, nil
+			})},
+		)
+		require.NoError(t, err)
+
+		assert.True(t, res.Skipped)
+		assert.Empty(t, exec.Calls("build"))
+	})
+}
@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/mt-sre/go-ci/command"
+)
+
+// ErrNoRuntimeFound is returned when no container
+// runtime is available in the PATH.
+var ErrNoRuntimeFound = errors.New("no container runtime found")
+
+// NewClient returns a Client for the container runtime
+// configured by the given options. By default the first
+// runtime found by "Runtime" is used and an error is
+// returned if none are available.
+func NewClient(opts ...ClientOption) (*Client, error) {
+	var cfg ClientConfig
+
+	cfg.Option(opts...)
+	if err := cfg.Default(); err != nil {
+		return nil, fmt.Errorf("applying defaults: %w", err)
+	}
+
+	return &Client{
+		cfg: cfg,
+	}, nil
+}
+
+// Client invokes a container runtime CLI.
+type Client struct {
+	cfg ClientConfig
+}
+
+// BinPath returns the path of the runtime executable.
+func (c *Client) BinPath() string { return c.cfg.BinPath }
+
+// Flavor returns the flavor of the runtime.
+func (c *Client) Flavor() Flavor { return c.cfg.Flavor }
+
+func (c *Client) execute(ctx context.Context, inv Invocation) (Output, error) {
+	return c.cfg.Executor.Execute(ctx, inv)
+}
+
+func (c *Client) run(ctx context.Context, args ...string) (string, error) {
+	return c.runWithEnv(ctx, nil, args...)
+}
+
+func (c *Client) runWithEnv(ctx context.Context, env map[string]string, args ...string) (string, error) {
+	out, err := c.execute(ctx, Invocation{Args: args, Env: env})
+	if err != nil {
+		return "", err
+	}
+
+	return out.Stdout, nil
+}
+
+type ClientConfig struct {
+	BinPath  string
+	Executor Executor
+	Flavor   Flavor
+}
+
+func (c *ClientConfig) Option(opts ...ClientOption) {
+	for _, opt := range opts {
+		opt.ConfigureClient(c)
+	}
+}
+
+func (c *ClientConfig) Default() error {
+	if c.BinPath == "" && c.Executor == nil {
+		path, ok := Runtime()
+		if !ok {
+			return ErrNoRuntimeFound
+		}
+
+		c.BinPath = path
+	}
+
+	if c.Flavor == FlavorNone {
+		c.Flavor = flavorFromPath(c.BinPath)
+	}
+
+	if c.Executor == nil {
+		c.Executor = &CommandExecutor{BinPath: c.BinPath}
+	}
+
+	return nil
+}
+
+type ClientOption interface {
+	ConfigureClient(*ClientConfig)
+}
+
+// Flavor identifies the family of a container runtime
+// where the CLIs differ in behavior.
+type Flavor string
+
+const (
+	// FlavorNone is an unknown runtime flavor.
+	FlavorNone Flavor = ""
+	// FlavorDocker is the docker CLI.
+	FlavorDocker Flavor = "docker"
+	// FlavorPodman is the podman CLI.
+	FlavorPodman Flavor = "podman"
+)
+
+func flavorFromPath(path string) Flavor {
+	if strings.Contains(filepath.Base(path), "docker") {
+		return FlavorDocker
+	}
+
+	return FlavorPodman
+}
+
+// Invocation describes a single call to a container runtime CLI.
+type Invocation struct {
+	// Args are passed to the runtime executable.
+	Args []string
+	// Env is added to the environment of the runtime process.
+	Env map[string]string
+	// Stdin, when not nil, is supplied as the runtime's input.
+	Stdin io.Reader
+	// Stdout and Stderr, when not nil, additionally
+	// receive the runtime's output as it is produced.
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Output holds the captured output of an Invocation.
+type Output struct {
+	Stdout string
+	Stderr string
+}
+
+// Executor runs Invocations against a container runtime.
+// Implementations must return an *ExecError when the
+// runtime exits unsuccessfully.
+type Executor interface {
+	Execute(ctx context.Context, inv Invocation) (Output, error)
+}
+
+// CommandExecutor is the default Executor which runs the
+// runtime found at BinPath as a child process.
+type CommandExecutor struct {
+	BinPath string
+}
+
+func (e *CommandExecutor) Execute(ctx context.Context, inv Invocation) (Output, error) {
+	opts := []command.CommandOption{
+		command.WithContext{Context: ctx},
+		command.WithArgs(inv.Args),
+		// runtimes depend on HOME, XDG_RUNTIME_DIR and similar
+		command.WithCurrentEnv(true),
+	}
+
+	if len(inv.Env) > 0 {
+		opts = append(opts, command.WithEnv(inv.Env))
+	}
+
+	if inv.Stdin != nil {
+		opts = append(opts, command.WithStdin{Reader: inv.Stdin})
+	}
+
+	if inv.Stdout != nil {
+		opts = append(opts, command.WithStdout{Writer: inv.Stdout})
+	}
+
+	if inv.Stderr != nil {
+		opts = append(opts, command.WithStderr{Writer: inv.Stderr})
+	}
+
+	cmd := command.NewCommand(e.BinPath, opts...)
+	if err := cmd.Run(); err != nil {
+		return Output{}, fmt.Errorf("starting container runtime: %w", err)
+	}
+
+	out := Output{
+		Stdout: cmd.Stdout(),
+		Stderr: cmd.Stderr(),
+	}
+
+	if !cmd.Success() {
+		return out, &ExecError{
+			Args:     inv.Args,
+			ExitCode: cmd.ExitCode(),
+			Stderr:   strings.TrimSpace(out.Stderr),
+		}
+	}
+
+	return out, nil
+}
+
+// ExecError is returned when a container runtime
+// exits with a non-zero exit code.
+type ExecError struct {
+	Args     []string
+	ExitCode int
+	Stderr   string
+}
+
+func (e *ExecError) Error() string {
+	return fmt.Sprintf("running %q exited with code %d: %s", strings.Join(e.Args, " "), e.ExitCode, e.Stderr)
+}
@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlavorFromPath(t *testing.T) {
+	t.Parallel()
+
+	for path, expected := range map[string]Flavor{
+		"/usr/bin/podman":     FlavorPodman,
+		"/usr/bin/docker":     FlavorDocker,
+		"/opt/bin/docker.exe": FlavorDocker,
+		"nerdctl":             FlavorPodman,
+	} {
+		assert.Equal(t, expected, flavorFromPath(path), path)
+	}
+}
+
+func TestCommandExecutor(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping command tests on Windows")
+	}
+
+	exec := CommandExecutor{BinPath: "sh"}
+
+	out, err := exec.Execute(context.Background(), Invocation{
+		Args: []string{"-c", "echo $VALUE && echo err >&2"},
+		Env:  map[string]string{"VALUE": "hello"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Output{Stdout: "hello\n", Stderr: "err\n"}, out)
+
+	_, err = exec.Execute(context.Background(), Invocation{Args: []string{"-c", "echo failed >&2; exit 3"}})
+
+	var execErr *ExecError
+
+	require.True(t, errors.As(err, &execErr))
+	assert.Equal(t, 3, execErr.ExitCode)
+	assert.Equal(t, "failed", execErr.Stderr)
+}
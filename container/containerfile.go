@@ -0,0 +1,585 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/mt-sre/go-ci/file"
+)
+
+// ErrInvalidContainerfile is returned for files
+// which cannot be parsed as Containerfiles.
+var ErrInvalidContainerfile = errors.New("invalid containerfile")
+
+// Containerfile is a parsed Containerfile or Dockerfile.
+type Containerfile struct {
+	// Path is set when parsed by "ParseContainerfileFile".
+	Path string
+	// Args are the ARG instructions preceding the first FROM
+	// which may be referenced by FROM instructions.
+	Args []Instruction
+	// Stages are the build stages in order of declaration.
+	Stages []Stage
+	// Instructions holds every instruction in file order.
+	Instructions []Instruction
+}
+
+// Stage is a single build stage beginning with FROM.
+type Stage struct {
+	Index int
+	// Name is the lowercased name given by "AS", if any.
+	Name string
+	// BaseImage is the FROM argument with ARGs substituted.
+	BaseImage string
+	// Platform is the value of FROM's "--platform" flag.
+	Platform string
+	From     Instruction
+	// Instructions holds the stage's instructions after FROM.
+	Instructions []Instruction
+}
+
+// Instruction is a single Containerfile instruction.
+type Instruction struct {
+	// Command is the upper-cased instruction keyword, e.g. "RUN".
+	Command string
+	// Flags are the leading "--name=value" arguments.
+	Flags []string
+	// Args are the remaining arguments split on whitespace or
+	// decoded from the JSON exec form.
+	Args []string
+	// JSON is true when Args were given in the JSON exec form.
+	JSON bool
+	// Heredocs are the here-documents attached to the instruction.
+	Heredocs []Heredoc
+	// Original is the instruction text with continuations joined.
+	Original string
+	// Line and EndLine are the 1-based lines which the instruction
+	// spans excluding any here-documents.
+	Line    int
+	EndLine int
+}
+
+// Flag returns the value of the named flag, e.g. "platform".
+func (i Instruction) Flag(name string) (string, bool) {
+	for _, f := range i.Flags {
+		k, v, _ := strings.Cut(strings.TrimPrefix(f, "--"), "=")
+		if k == name {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// Heredoc is a here-document, e.g. "RUN <<EOF".
+type Heredoc struct {
+	Name    string
+	Content string
+	// Expand is false when the delimiter was quoted.
+	Expand bool
+	// Chomp is true for "<<-" which strips leading tabs.
+	Chomp bool
+}
+
+// ParseContainerfileFile parses the Containerfile at the given path.
+func ParseContainerfileFile(path string, opts ...ParseOption) (*Containerfile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening %q: %w", path, err)
+	}
+
+	defer f.Close()
+
+	res, err := ParseContainerfile(f, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %q: %w", path, err)
+	}
+
+	res.Path = path
+
+	return res, nil
+}
+
+// ParseContainerfile parses Dockerfile syntax including parser
+// directives, line continuations and here-documents. ARGs
+// declared before the first FROM are substituted in FROM
+// instructions using build args to override their defaults.
+func ParseContainerfile(r io.Reader, opts ...ParseOption) (*Containerfile, error) {
+	var cfg ParseConfig
+
+	cfg.Option(opts...)
+
+	p := parser{
+		scanner: bufio.NewScanner(r),
+		escape:  '\\',
+	}
+
+	p.scanner.Buffer(nil, 1<<20)
+
+	instructions, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Containerfile{Instructions: instructions}
+
+	globals := map[string]string{}
+
+	for _, inst := range instructions {
+		switch {
+		case inst.Command == "FROM":
+			stage, err := newStage(len(res.Stages), inst, globals)
+			if err != nil {
+				return nil, err
+			}
+
+			res.Stages = append(res.Stages, stage)
+		case len(res.Stages) > 0:
+			last := &res.Stages[len(res.Stages)-1]
+			last.Instructions = append(last.Instructions, inst)
+		case inst.Command == "ARG":
+			res.Args = append(res.Args, inst)
+
+			for _, arg := range inst.Args {
+				name, def, _ := strings.Cut(arg, "=")
+				if val, ok := cfg.BuildArgs[name]; ok {
+					def = val
+				}
+
+				globals[name] = unquote(def)
+			}
+		default:
+			return nil, fmt.Errorf("%w: line %d: %s before FROM", ErrInvalidContainerfile, inst.Line, inst.Command)
+		}
+	}
+
+	return res, nil
+}
+
+type ParseConfig struct {
+	BuildArgs map[string]string
+}
+
+func (c *ParseConfig) Option(opts ...ParseOption) {
+	for _, opt := range opts {
+		opt.ConfigureParse(c)
+	}
+}
+
+type ParseOption interface {
+	ConfigureParse(*ParseConfig)
+}
+
+func newStage(index int, from Instruction, globals map[string]string) (Stage, error) {
+	stage := Stage{Index: index, From: from}
+
+	args := from.Args
+
+	switch {
+	case len(args) == 1:
+	case len(args) == 3 && strings.EqualFold(args[1], "as"):
+		stage.Name = strings.ToLower(args[2])
+	default:
+		return Stage{}, fmt.Errorf("%w: line %d: FROM requires an image and optional stage name", ErrInvalidContainerfile, from.Line)
+	}
+
+	stage.BaseImage = Expand(args[0], globals)
+
+	if platform, ok := from.Flag("platform"); ok {
+		stage.Platform = Expand(platform, globals)
+	}
+
+	return stage, nil
+}
+
+type parser struct {
+	scanner *bufio.Scanner
+	line    int
+	escape  rune
+}
+
+func (p *parser) next() (string, bool) {
+	if !p.scanner.Scan() {
+		return "", false
+	}
+
+	p.line++
+
+	return strings.TrimRight(p.scanner.Text(), "\r"), true
+}
+
+func (p *parser) parse() ([]Instruction, error) {
+	var (
+		res        []Instruction
+		directives = true
+	)
+
+	for {
+		line, ok := p.next()
+		if !ok {
+			break
+		}
+
+		trimmed := strings.TrimSpace(line)
+
+		if directives && p.directive(trimmed) {
+			continue
+		}
+
+		directives = false
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		inst, err := p.instruction(line)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, inst)
+	}
+
+	if err := p.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading containerfile: %w", err)
+	}
+
+	return res, nil
+}
+
+// directive handles parser directives such as "# escape=`"
+// returning false once the line is not a directive.
+func (p *parser) directive(line string) bool {
+	if !strings.HasPrefix(line, "#") {
+		return false
+	}
+
+	key, val, ok := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, "#")), "=")
+	if !ok || strings.ContainsAny(strings.TrimSpace(key), " \t") {
+		return false
+	}
+
+	if strings.EqualFold(strings.TrimSpace(key), "escape") {
+		if val = strings.TrimSpace(val); val == "`" {
+			p.escape = '`'
+		}
+	}
+
+	return true
+}
+
+func (p *parser) instruction(first string) (Instruction, error) {
+	inst := Instruction{Line: p.line}
+
+	text, cont := p.trimContinuation(first)
+
+	for cont {
+		line, ok := p.next()
+		if !ok {
+			break
+		}
+
+		// comments and blank lines are ignored within continuations
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		var part string
+
+		part, cont = p.trimContinuation(line)
+		text += " " + strings.TrimSpace(part)
+	}
+
+	inst.EndLine = p.line
+	inst.Original = strings.TrimSpace(text)
+
+	cmd, rest, _ := strings.Cut(inst.Original, " ")
+	inst.Command = strings.ToUpper(strings.TrimSpace(cmd))
+	rest = strings.TrimSpace(rest)
+
+	for strings.HasPrefix(rest, "--") {
+		var flag string
+
+		flag, rest, _ = strings.Cut(rest, " ")
+		inst.Flags = append(inst.Flags, flag)
+		rest = strings.TrimSpace(rest)
+	}
+
+	if strings.HasPrefix(rest, "[") {
+		var args []string
+		if err := json.Unmarshal([]byte(rest), &args); err == nil {
+			inst.Args, inst.JSON = args, true
+		}
+	}
+
+	if !inst.JSON {
+		inst.Args = fields(rest)
+	}
+
+	if inst.Command == "RUN" || inst.Command == "COPY" || inst.Command == "ADD" {
+		if err := p.heredocs(&inst); err != nil {
+			return Instruction{}, err
+		}
+	}
+
+	return inst, nil
+}
+
+func (p *parser) trimContinuation(line string) (string, bool) {
+	trimmed := strings.TrimRightFunc(line, unicode.IsSpace)
+	if strings.HasSuffix(trimmed, string(p.escape)) && !strings.HasSuffix(trimmed, string(p.escape)+string(p.escape)) {
+		return strings.TrimRightFunc(strings.TrimSuffix(trimmed, string(p.escape)), unicode.IsSpace), true
+	}
+
+	return trimmed, false
+}
+
+func (p *parser) heredocs(inst *Instruction) error {
+	if inst.JSON {
+		return nil
+	}
+
+	for _, arg := range inst.Args {
+		if !strings.HasPrefix(arg, "<<") {
+			continue
+		}
+
+		doc := Heredoc{Expand: true}
+
+		name := strings.TrimPrefix(arg, "<<")
+		if strings.HasPrefix(name, "-") {
+			doc.Chomp = true
+			name = name[1:]
+		}
+
+		// only the delimiter portion, e.g. "<<EOF>file", is used
+		if i := strings.IndexAny(name, "<>|&;"); i > 0 {
+			name = name[:i]
+		}
+
+		if unquoted := unquote(name); unquoted != name {
+			doc.Expand = false
+			name = unquoted
+		}
+
+		if name == "" {
+			continue
+		}
+
+		doc.Name = name
+
+		var content strings.Builder
+
+		for {
+			line, ok := p.next()
+			if !ok {
+				return fmt.Errorf("%w: line %d: unterminated heredoc %q", ErrInvalidContainerfile, inst.Line, name)
+			}
+
+			if doc.Chomp {
+				line = strings.TrimLeft(line, "\t")
+			}
+
+			if line == name {
+				break
+			}
+
+			content.WriteString(line)
+			content.WriteByte('\n')
+		}
+
+		doc.Content = content.String()
+		inst.Heredocs = append(inst.Heredocs, doc)
+	}
+
+	return nil
+}
+
+// fields splits s on whitespace keeping quoted sections intact.
+func fields(s string) []string {
+	var (
+		res   []string
+		cur   strings.Builder
+		quote rune
+		inArg bool
+	)
+
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+
+			cur.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+
+			cur.WriteRune(r)
+		case unicode.IsSpace(r):
+			if inArg {
+				res = append(res, cur.String())
+				cur.Reset()
+
+				inArg = false
+			}
+		default:
+			inArg = true
+
+			cur.WriteRune(r)
+		}
+	}
+
+	if inArg {
+		res = append(res, cur.String())
+	}
+
+	return res
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+
+	return s
+}
+
+// Expand substitutes "$name" and "${name}" variables in s using
+// vars. The "${name:-default}", "${name-default}", "${name:+alt}"
+// and "${name+alt}" forms are supported. Undefined variables are
+// replaced with an empty string.
+func Expand(s string, vars map[string]string) string {
+	var res strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && s[i+1] == '$' {
+			res.WriteByte('$')
+			i++
+
+			continue
+		}
+
+		if s[i] != '$' || i+1 == len(s) {
+			res.WriteByte(s[i])
+
+			continue
+		}
+
+		if s[i+1] == '{' {
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				res.WriteString(s[i:])
+
+				break
+			}
+
+			res.WriteString(expandBraced(s[i+2:i+end], vars))
+			i += end
+
+			continue
+		}
+
+		j := i + 1
+		for j < len(s) && (s[j] == '_' || unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j]))) {
+			j++
+		}
+
+		if j == i+1 {
+			res.WriteByte('$')
+
+			continue
+		}
+
+		res.WriteString(vars[s[i+1:j]])
+		i = j - 1
+	}
+
+	return res.String()
+}
+
+func expandBraced(expr string, vars map[string]string) string {
+	i := strings.IndexAny(expr, ":-+")
+	if i < 0 {
+		return vars[expr]
+	}
+
+	name, op := expr[:i], expr[i:]
+	val, set := vars[name]
+
+	colon := strings.HasPrefix(op, ":")
+	op = strings.TrimPrefix(op, ":")
+
+	if op == "" {
+		return val
+	}
+
+	word := op[1:]
+	// with a colon empty values are treated as unset
+	present := set && (!colon || val != "")
+
+	switch op[0] {
+	case '-':
+		if present {
+			return val
+		}
+
+		return word
+	case '+':
+		if present {
+			return word
+		}
+
+		return ""
+	default:
+		return val
+	}
+}
+
+// IsContainerfile reports whether the base name of path is
+// conventionally used for Containerfiles or Dockerfiles, e.g.
+// "Containerfile", "Dockerfile.dev" or "app.Dockerfile".
+func IsContainerfile(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+
+	for _, base := range []string{"containerfile", "dockerfile"} {
+		if name == base || strings.HasPrefix(name, base+".") || strings.HasSuffix(name, "."+base) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FindContainerfiles searches recursively from root for files
+// satisfying "IsContainerfile" and any additional FindOptions.
+func FindContainerfiles(root string, opts ...file.FindOption) ([]string, error) {
+	opts = append([]file.FindOption{file.WithEntType(file.EntTypeFile)}, opts...)
+
+	paths, err := file.Find(root, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("finding containerfiles: %w", err)
+	}
+
+	var res []string
+
+	for _, p := range paths {
+		if IsContainerfile(p) {
+			res = append(res, p)
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,264 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mt-sre/go-ci/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testContainerfile = `# syntax=docker/dockerfile:1
+ARG GO_VERSION=1.23
+ARG BASE
+
+FROM --platform=$BUILDPLATFORM golang:${GO_VERSION} AS build
+# comments are ignored
+RUN go build \
+    # including within continuations
+    -o /app ./cmd/app
+RUN <<EOF
+set -e
+echo "$HOME"
+EOF
+COPY <<-'CONF' /etc/app.conf
+	key=value
+	CONF
+
+FROM ${BASE:-registry.access.redhat.com/ubi9/ubi-minimal}
+COPY --from=build /app /app
+USER 1001
+ENTRYPOINT ["/app", "--serve"]
+`
+
+func TestParseContainerfile(t *testing.T) {
+	t.Parallel()
+
+	cf, err := container.ParseContainerfile(strings.NewReader(testContainerfile))
+	require.NoError(t, err)
+
+	require.Len(t, cf.Args, 2)
+	require.Len(t, cf.Stages, 2)
+
+	build := cf.Stages[0]
+
+	assert.Equal(t, "build", build.Name)
+	assert.Equal(t, "golang:1.23", build.BaseImage)
+	assert.Equal(t, 5, build.From.Line)
+	require.Len(t, build.Instructions, 3)
+
+	run := build.Instructions[0]
+
+	assert.Equal(t, "RUN go build -o /app ./cmd/app", run.Original)
+	assert.Equal(t, 7, run.Line)
+	assert.Equal(t, 9, run.EndLine)
+
+	heredoc := build.Instructions[1]
+
+	require.Len(t, heredoc.Heredocs, 1)
+	assert.Equal(t, container.Heredoc{
+		Name:    "EOF",
+		Content: "set -e\necho \"$HOME\"\n",
+		Expand:  true,
+	}, heredoc.Heredocs[0])
+
+	copyConf := build.Instructions[2]
+
+	require.Len(t, copyConf.Heredocs, 1)
+	assert.Equal(t, container.Heredoc{
+		Name:    "CONF",
+		Content: "key=value\n",
+		Chomp:   true,
+	}, copyConf.Heredocs[0])
+
+	final := cf.Stages[1]
+
+	assert.Equal(t, "registry.access.redhat.com/ubi9/ubi-minimal", final.BaseImage)
+	assert.Equal(t, 18, final.From.Line)
+	require.Len(t, final.Instructions, 3)
+
+	from, ok := final.Instructions[0].Flag("from")
+	assert.True(t, ok)
+	assert.Equal(t, "build", from)
+
+	entrypoint := final.Instructions[2]
+
+	assert.True(t, entrypoint.JSON)
+	assert.Equal(t, []string{"/app", "--serve"}, entrypoint.Args)
+
+	cf, err = container.ParseContainerfile(strings.NewReader(testContainerfile),
+		container.WithBuildArgs{"GO_VERSION": "1.24", "BASE": "quay.io/org/base:v1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "golang:1.24", cf.Stages[0].BaseImage)
+	assert.Equal(t, "quay.io/org/base:v1", cf.Stages[1].BaseImage)
+}
+
+func TestParseContainerfileInvalid(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]string{
+		"instruction before FROM": "RUN true\nFROM scratch\n",
+		"unterminated heredoc":    "FROM scratch\nRUN <<EOF\ntrue\n",
+		"malformed FROM":          "FROM a b\n",
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := container.ParseContainerfile(strings.NewReader(tc))
+			assert.ErrorIs(t, err, container.ErrInvalidContainerfile)
+		})
+	}
+}
+
+func TestParseContainerfileEscapeDirective(t *testing.T) {
+	t.Parallel()
+
+	cf, err := container.ParseContainerfile(strings.NewReader("# escape=`\nFROM scratch\nCOPY a `\n  C:\\b\n"))
+	require.NoError(t, err)
+
+	require.Len(t, cf.Stages, 1)
+	require.Len(t, cf.Stages[0].Instructions, 1)
+	assert.Equal(t, []string{"a", `C:\b`}, cf.Stages[0].Instructions[0].Args)
+}
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	vars := map[string]string{"SET": "value", "EMPTY": ""}
+
+	for in, expected := range map[string]string{
+		"$SET/${SET}":       "value/value",
+		"${UNSET:-default}": "default",
+		"${EMPTY:-default}": "default",
+		"${EMPTY-default}":  "",
+		"${SET:+alt}":       "alt",
+		"${UNSET:+alt}":     "",
+		`\$SET`:             "$SET",
+		"$":                 "$",
+	} {
+		assert.Equal(t, expected, container.Expand(in, vars), in)
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	t.Parallel()
+
+	const digest = "@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	policy := container.Policy{
+		AllowedRegistries: []string{"quay.io/org", "registry.access.redhat.com"},
+		RequireDigest:     true,
+		ForbidLatest:      true,
+		RequireUser:       true,
+	}
+
+	for name, tc := range map[string]struct {
+		Containerfile string
+		Expected      []container.Rule
+	}{
+		"compliant": {
+			Containerfile: "FROM quay.io/org/builder:v1" + digest + " AS build\n" +
+				"FROM build AS test\n" +
+				"FROM scratch\nUSER 1001\n",
+		},
+		"unpinned": {
+			Containerfile: "FROM quay.io/org/app:v1\nUSER app\n",
+			Expected:      []container.Rule{container.RuleUnpinned},
+		},
+		"implicit latest from docker hub": {
+			Containerfile: "FROM busybox\nUSER app\n",
+			Expected:      []container.Rule{container.RuleUnpinned, container.RuleLatest, container.RuleRegistry},
+		},
+		"explicit latest": {
+			Containerfile: "FROM quay.io/org/app:latest" + digest + "\nUSER app\n",
+			Expected:      []container.Rule{container.RuleLatest},
+		},
+		"registry prefix is not a repository prefix": {
+			Containerfile: "FROM quay.io/organisation/app:v1" + digest + "\nUSER app\n",
+			Expected:      []container.Rule{container.RuleRegistry},
+		},
+		"missing user": {
+			Containerfile: "FROM scratch\n",
+			Expected:      []container.Rule{container.RuleUser},
+		},
+		"root user": {
+			Containerfile: "FROM scratch\nUSER 1001\nUSER root:root\n",
+			Expected:      []container.Rule{container.RuleUser},
+		},
+		"unresolved arg": {
+			Containerfile: "ARG BASE\nFROM $BASE\nUSER app\n",
+			Expected:      []container.Rule{container.RuleInvalid},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cf, err := container.ParseContainerfile(strings.NewReader(tc.Containerfile))
+			require.NoError(t, err)
+
+			var rules []container.Rule
+
+			for _, v := range policy.Check(cf) {
+				rules = append(rules, v.Rule)
+			}
+
+			assert.Equal(t, tc.Expected, rules)
+		})
+	}
+}
+
+func TestPolicyCheckFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	files := map[string]string{
+		"Containerfile":            "FROM quay.io/org/app:v1\nUSER app\n",
+		"build/app.Dockerfile":     "FROM scratch\n",
+		"build/Dockerfile.dev":     "FROM scratch\nUSER app\n",
+		"docs/containerfiles.md":   "FROM busybox\n",
+		"build/Containerfile.d/ok": "FROM busybox\n",
+	}
+
+	for name, data := range files {
+		path := filepath.Join(root, name)
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+	}
+
+	paths, err := container.FindContainerfiles(root)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		filepath.Join(root, "Containerfile"),
+		filepath.Join(root, "build", "app.Dockerfile"),
+		filepath.Join(root, "build", "Dockerfile.dev"),
+	}, paths)
+
+	violations, err := container.Policy{RequireDigest: true, RequireUser: true}.CheckFiles(paths)
+	require.NoError(t, err)
+
+	var found []string
+
+	for _, v := range violations {
+		found = append(found, v.String())
+	}
+
+	assert.ElementsMatch(t, []string{
+		filepath.Join(root, "Containerfile") + `:1: unpinned-base: base image "quay.io/org/app:v1" is not pinned by digest`,
+		filepath.Join(root, "build", "app.Dockerfile") + ":1: missing-user: final stage does not set USER",
+	}, found)
+}
@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package containertest provides a fake container runtime
+// for testing code built on the container package.
+package containertest
+
+import (
+	"context"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/mt-sre/go-ci/container"
+)
+
+// NewClient returns a container.Client backed by the given
+// fake Executor using the podman flavor unless overridden.
+func NewClient(exec *Executor, opts ...container.ClientOption) *container.Client {
+	opts = append([]container.ClientOption{
+		container.WithBinPath("podman"),
+		container.WithExecutor{Executor: exec},
+	}, opts...)
+
+	client, err := container.NewClient(opts...)
+	if err != nil {
+		// unreachable since an Executor is always provided
+		panic(err)
+	}
+
+	return client
+}
+
+// HandlerFunc answers a single fake runtime invocation.
+type HandlerFunc func(inv container.Invocation) (container.Output, error)
+
+// Stdout returns a HandlerFunc which succeeds with the given output.
+func Stdout(out string) HandlerFunc {
+	return func(container.Invocation) (container.Output, error) {
+		return container.Output{Stdout: out}, nil
+	}
+}
+
+// Fail returns a HandlerFunc which exits with the given code and stderr.
+func Fail(code int, stderr string) HandlerFunc {
+	return func(inv container.Invocation) (container.Output, error) {
+		return container.Output{Stderr: stderr}, &container.ExecError{
+			Args:     inv.Args,
+			ExitCode: code,
+			Stderr:   stderr,
+		}
+	}
+}
+
+// Executor is a fake container.Executor which records
+// invocations and answers them with registered handlers.
+// Invocations without a matching handler succeed with
+// no output. Handler output is also written to the
+// Invocation's Stdout and Stderr writers when set.
+type Executor struct {
+	mu       sync.Mutex
+	calls    []container.Invocation
+	handlers []handler
+}
+
+type handler struct {
+	prefix []string
+	fn     HandlerFunc
+}
+
+// Handle registers fn to answer invocations whose leading
+// arguments equal prefix. Handlers registered later take
+// precedence over earlier ones.
+func (e *Executor) Handle(prefix []string, fn HandlerFunc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.handlers = append(e.handlers, handler{prefix: prefix, fn: fn})
+}
+
+func (e *Executor) Execute(_ context.Context, inv container.Invocation) (container.Output, error) {
+	e.mu.Lock()
+
+	e.calls = append(e.calls, inv)
+
+	var fn HandlerFunc
+
+	for i := len(e.handlers) - 1; i >= 0; i-- {
+		if hasPrefix(inv.Args, e.handlers[i].prefix) {
+			fn = e.handlers[i].fn
+
+			break
+		}
+	}
+
+	e.mu.Unlock()
+
+	if fn == nil {
+		return container.Output{}, nil
+	}
+
+	out, err := fn(inv)
+
+	if inv.Stdout != nil {
+		_, _ = io.WriteString(inv.Stdout, out.Stdout)
+	}
+
+	if inv.Stderr != nil {
+		_, _ = io.WriteString(inv.Stderr, out.Stderr)
+	}
+
+	return out, err
+}
+
+// Calls returns all recorded invocations whose leading
+// arguments equal prefix in the order they were made.
+func (e *Executor) Calls(prefix ...string) []container.Invocation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var res []container.Invocation
+
+	for _, inv := range e.calls {
+		if hasPrefix(inv.Args, prefix) {
+			res = append(res, inv)
+		}
+	}
+
+	return res
+}
+
+func hasPrefix(args, prefix []string) bool {
+	return len(args) >= len(prefix) && slices.Equal(args[:len(prefix)], prefix)
+}
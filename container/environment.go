@@ -0,0 +1,520 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvironmentLabel is applied to every container and network
+// of an Environment with the environment's name as value.
+const EnvironmentLabel = "io.github.mt-sre.go-ci.environment"
+
+// ErrInvalidEnvironment is returned for environment
+// specs which cannot be brought up.
+var ErrInvalidEnvironment = errors.New("invalid environment")
+
+// EnvironmentSpec declares a set of services and the networks
+// which connect them in the spirit of a compose file, e.g.
+//
+//	name: itest
+//	services:
+//	  db:
+//	    image: docker.io/library/postgres:16
+//	    environment:
+//	      POSTGRES_PASSWORD: secret
+//	    healthcheck:
+//	      exec: [pg_isready, -U, postgres]
+//	  api:
+//	    image: quay.io/org/api:v1
+//	    depends_on: [db]
+//	    ports: ["8080"]
+//	    healthcheck:
+//	      http: {port: "8080", path: /healthz}
+type EnvironmentSpec struct {
+	// Name prefixes all container and network names. A random
+	// name is generated when empty.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Networks are created before any services are started.
+	// Services not attached to any network are attached to
+	// an implicit network named "default".
+	Networks []string               `json:"networks,omitempty" yaml:"networks,omitempty"`
+	Services map[string]ServiceSpec `json:"services" yaml:"services"`
+}
+
+// ServiceSpec declares a single service of an EnvironmentSpec.
+// Services are reachable from one another by their name.
+type ServiceSpec struct {
+	Image       string            `json:"image" yaml:"image"`
+	Command     []string          `json:"command,omitempty" yaml:"command,omitempty"`
+	Environment map[string]string `json:"environment,omitempty" yaml:"environment,omitempty"`
+	// Ports are published on random loopback ports.
+	// See "Service.Endpoint".
+	Ports    []string `json:"ports,omitempty" yaml:"ports,omitempty"`
+	Volumes  []Volume `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+	Networks []string `json:"networks,omitempty" yaml:"networks,omitempty"`
+	// DependsOn names services which must be ready
+	// before this service is started.
+	DependsOn   []string         `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
+	HealthCheck *HealthCheckSpec `json:"healthcheck,omitempty" yaml:"healthcheck,omitempty"`
+	RunArgs     []string         `json:"run_args,omitempty" yaml:"run_args,omitempty"`
+}
+
+// HealthCheckSpec selects the readiness probe of a service.
+// At most one of Exec, TCP, HTTP or Log may be set.
+type HealthCheckSpec struct {
+	// Exec is a command which must exit successfully.
+	Exec []string `json:"exec,omitempty" yaml:"exec,omitempty"`
+	// TCP is a container port which must accept connections.
+	TCP  string     `json:"tcp,omitempty" yaml:"tcp,omitempty"`
+	HTTP *HTTPProbe `json:"http,omitempty" yaml:"http,omitempty"`
+	// Log is a pattern which must appear in the service's logs
+	// at least Occurrences times.
+	Log         string        `json:"log,omitempty" yaml:"log,omitempty"`
+	Occurrences int           `json:"occurrences,omitempty" yaml:"occurrences,omitempty"`
+	Interval    time.Duration `json:"interval,omitempty" yaml:"interval,omitempty"`
+	Timeout     time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// Probe returns the Probe described by the health check.
+func (h HealthCheckSpec) Probe() (Probe, error) {
+	var probes []Probe
+
+	if len(h.Exec) > 0 {
+		probes = append(probes, ExecProbe{Command: h.Exec})
+	}
+
+	if h.TCP != "" {
+		probes = append(probes, TCPProbe{Port: h.TCP})
+	}
+
+	if h.HTTP != nil {
+		probes = append(probes, *h.HTTP)
+	}
+
+	if h.Log != "" {
+		pattern, err := regexp.Compile(h.Log)
+		if err != nil {
+			return nil, fmt.Errorf("%w: compiling log pattern: %w", ErrInvalidEnvironment, err)
+		}
+
+		probes = append(probes, LogProbe{Pattern: pattern, Occurrences: h.Occurrences})
+	}
+
+	switch len(probes) {
+	case 0:
+		return nil, nil
+	case 1:
+		return probes[0], nil
+	default:
+		return nil, fmt.Errorf("%w: health checks must use a single probe", ErrInvalidEnvironment)
+	}
+}
+
+func (h HealthCheckSpec) port() string {
+	if h.HTTP != nil {
+		return h.HTTP.Port
+	}
+
+	return h.TCP
+}
+
+// LoadEnvironmentSpec reads the YAML, or JSON, spec at path.
+func LoadEnvironmentSpec(path string) (EnvironmentSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return EnvironmentSpec{}, fmt.Errorf("reading environment spec: %w", err)
+	}
+
+	spec, err := ParseEnvironmentSpec(bytes.NewReader(data))
+	if err != nil {
+		return EnvironmentSpec{}, fmt.Errorf("parsing %q: %w", path, err)
+	}
+
+	return spec, nil
+}
+
+// ParseEnvironmentSpec decodes a YAML, or JSON, spec rejecting
+// unknown fields.
+func ParseEnvironmentSpec(r io.Reader) (EnvironmentSpec, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	var spec EnvironmentSpec
+
+	if err := dec.Decode(&spec); err != nil {
+		return EnvironmentSpec{}, fmt.Errorf("%w: %w", ErrInvalidEnvironment, err)
+	}
+
+	return spec, nil
+}
+
+// Order returns the service names in the order they are started
+// such that every service follows its dependencies. An error is
+// returned for unknown dependencies or dependency cycles.
+func (s EnvironmentSpec) Order() ([]string, error) {
+	remaining := make(map[string]int, len(s.Services))
+	dependents := make(map[string][]string, len(s.Services))
+
+	for name, svc := range s.Services {
+		if svc.Image == "" {
+			return nil, fmt.Errorf("%w: service %q has no image", ErrInvalidEnvironment, name)
+		}
+
+		remaining[name] = len(svc.DependsOn)
+
+		for _, dep := range svc.DependsOn {
+			if _, ok := s.Services[dep]; !ok {
+				return nil, fmt.Errorf("%w: service %q depends on unknown service %q", ErrInvalidEnvironment, name, dep)
+			}
+
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+
+	var ready []string
+
+	for name, n := range remaining {
+		if n == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	res := make([]string, 0, len(s.Services))
+
+	for len(ready) > 0 {
+		// sorting keeps the start order stable between runs
+		sort.Strings(ready)
+
+		name := ready[0]
+		ready = ready[1:]
+		res = append(res, name)
+
+		for _, dependent := range dependents[name] {
+			if remaining[dependent]--; remaining[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(res) < len(s.Services) {
+		var cyclic []string
+
+		for name, n := range remaining {
+			if n > 0 {
+				cyclic = append(cyclic, name)
+			}
+		}
+
+		sort.Strings(cyclic)
+
+		return nil, fmt.Errorf("%w: dependency cycle between %s", ErrInvalidEnvironment, strings.Join(cyclic, ", "))
+	}
+
+	return res, nil
+}
+
+// Up creates the networks of spec and starts its services in
+// dependency order waiting for each service to become ready
+// before starting its dependents. If any step fails everything
+// created so far is removed. The environment is otherwise torn
+// down when ctx is cancelled, a registered Cleaner runs its
+// cleanups or "Environment.Down" is called.
+func (c *Client) Up(ctx context.Context, spec EnvironmentSpec, opts ...EnvironmentOption) (*Environment, error) {
+	var cfg EnvironmentConfig
+
+	cfg.Option(opts...)
+
+	order, err := spec.Order()
+	if err != nil {
+		return nil, err
+	}
+
+	name := spec.Name
+	if name == "" {
+		name = randomEnvironmentName()
+	}
+
+	env := &Environment{
+		Name:     name,
+		client:   c,
+		services: make(map[string]*Service, len(order)),
+	}
+
+	env.logCtx, env.stopLogs = context.WithCancel(context.Background())
+
+	if cfg.Cleaner != nil {
+		cfg.Cleaner.Cleanup(func() { _ = env.Down(context.Background()) })
+	}
+
+	if err := env.up(ctx, spec, order, cfg); err != nil {
+		return nil, errors.Join(err, env.Down(context.Background()))
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = env.Down(context.Background())
+		case <-env.logCtx.Done():
+		}
+	}()
+
+	return env, nil
+}
+
+type EnvironmentConfig struct {
+	AuthFile  string
+	Cleaner   Cleaner
+	LogOutput io.Writer
+}
+
+func (c *EnvironmentConfig) Option(opts ...EnvironmentOption) {
+	for _, opt := range opts {
+		opt.ConfigureEnvironment(c)
+	}
+}
+
+type EnvironmentOption interface {
+	ConfigureEnvironment(*EnvironmentConfig)
+}
+
+func randomEnvironmentName() string {
+	buf := make([]byte, 4)
+	_, _ = rand.Read(buf)
+
+	return "go-ci-" + hex.EncodeToString(buf)
+}
+
+// Environment is a set of running services started by "Up".
+type Environment struct {
+	Name string
+
+	client   *Client
+	services map[string]*Service
+	// started records service names in start order
+	started  []string
+	networks []string
+
+	logCtx   context.Context
+	stopLogs context.CancelFunc
+	logs     sync.WaitGroup
+	logMu    sync.Mutex
+
+	downOnce sync.Once
+	downErr  error
+}
+
+// Service returns the running service with the given spec name.
+func (e *Environment) Service(name string) (*Service, bool) {
+	svc, ok := e.services[name]
+
+	return svc, ok
+}
+
+// Down removes all services in reverse start order followed by
+// the environment's networks. Every removal is attempted and the
+// combined errors are returned. Subsequent calls return the result
+// of the first.
+func (e *Environment) Down(ctx context.Context) error {
+	e.downOnce.Do(func() {
+		var errs []error
+
+		for i := len(e.started) - 1; i >= 0; i-- {
+			if err := e.services[e.started[i]].Remove(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("removing service %q: %w", e.started[i], err))
+			}
+		}
+
+		e.stopLogs()
+		e.logs.Wait()
+
+		for _, network := range e.networks {
+			if err := e.client.RemoveNetwork(ctx, network); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		e.downErr = errors.Join(errs...)
+	})
+
+	return e.downErr
+}
+
+func (e *Environment) up(ctx context.Context, spec EnvironmentSpec, order []string, cfg EnvironmentConfig) error {
+	networks := append([]string(nil), spec.Networks...)
+
+	for _, name := range order {
+		if len(spec.Services[name].Networks) == 0 {
+			networks = append(networks, "default")
+
+			break
+		}
+	}
+
+	declared := make(map[string]struct{}, len(networks))
+	labels := WithLabels{EnvironmentLabel: e.Name}
+
+	for _, network := range networks {
+		// networks, including "default", may be declared twice
+		if _, ok := declared[network]; ok {
+			continue
+		}
+
+		declared[network] = struct{}{}
+
+		if err := e.client.CreateNetwork(ctx, e.qualify(network), labels); err != nil {
+			return err
+		}
+
+		e.networks = append(e.networks, e.qualify(network))
+	}
+
+	width := 0
+	for _, name := range order {
+		width = max(width, len(name))
+	}
+
+	for _, name := range order {
+		svc := spec.Services[name]
+
+		opts := []ServiceOption{
+			WithAuthFile(cfg.AuthFile),
+			WithCmd(svc.Command),
+			WithEnv(svc.Environment),
+			labels,
+			WithName(e.qualify(name)),
+			WithNetworkAliases{name},
+			WithPorts(svc.Ports),
+			WithRunArgs(svc.RunArgs),
+			WithVolumes(svc.Volumes),
+		}
+
+		attached := svc.Networks
+		if len(attached) == 0 {
+			attached = []string{"default"}
+		}
+
+		for _, network := range attached {
+			if _, ok := declared[network]; !ok {
+				return fmt.Errorf("%w: service %q uses undeclared network %q", ErrInvalidEnvironment, name, network)
+			}
+
+			opts = append(opts, WithNetworks{e.qualify(network)})
+		}
+
+		if check := svc.HealthCheck; check != nil {
+			probe, err := check.Probe()
+			if err != nil {
+				return fmt.Errorf("service %q: %w", name, err)
+			}
+
+			// network probes connect through a published port
+			if port := check.port(); port != "" && !slices.Contains(svc.Ports, port) {
+				opts = append(opts, WithPorts{port})
+			}
+
+			opts = append(opts,
+				WithReadiness{Probe: probe},
+				WithReadinessInterval(check.Interval),
+				WithReadinessTimeout(check.Timeout),
+			)
+		}
+
+		started, err := e.client.StartService(ctx, svc.Image, opts...)
+		if err != nil {
+			return fmt.Errorf("starting service %q: %w", name, err)
+		}
+
+		e.services[name] = started
+		e.started = append(e.started, name)
+
+		if cfg.LogOutput != nil {
+			e.streamLogs(started, fmt.Sprintf("%-*s | ", width, name), cfg.LogOutput)
+		}
+	}
+
+	return nil
+}
+
+func (e *Environment) qualify(name string) string {
+	return e.Name + "_" + name
+}
+
+// streamLogs follows the logs of svc writing each line to out
+// with the given prefix until the service is removed.
+func (e *Environment) streamLogs(svc *Service, prefix string, out io.Writer) {
+	w := &prefixWriter{mu: &e.logMu, out: out, prefix: prefix}
+
+	e.logs.Add(1)
+
+	go func() {
+		defer e.logs.Done()
+
+		_, _ = e.client.execute(e.logCtx, Invocation{
+			Args:   []string{"logs", "--follow", svc.ID},
+			Stdout: w,
+			Stderr: w,
+		})
+
+		w.Flush()
+	}()
+}
+
+// prefixWriter writes complete lines to out with a prefix.
+// Writers sharing mu never interleave partial lines.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		if _, err := io.WriteString(w.out, w.prefix+string(w.buf[:i+1])); err != nil {
+			return 0, err
+		}
+
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes any trailing partial line.
+func (w *prefixWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		_, _ = io.WriteString(w.out, w.prefix+string(w.buf)+"\n")
+		w.buf = nil
+	}
+}
@@ -0,0 +1,269 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container_test
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/go-ci/container"
+	"github.com/mt-sre/go-ci/container/containertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEnvironmentSpec = `
+name: itest
+networks: [backend]
+services:
+  db:
+    image: docker.io/library/postgres:16
+    environment:
+      POSTGRES_PASSWORD: secret
+    networks: [backend]
+    volumes: ["/srv/init:/docker-entrypoint-initdb.d:ro"]
+    healthcheck:
+      log: ready to accept connections
+      timeout: 30s
+  mock:
+    image: quay.io/org/mock:v1
+  api:
+    image: quay.io/org/api:v1
+    command: [serve]
+    depends_on: [db, mock]
+    networks: [backend, default]
+    healthcheck:
+      exec: [/bin/healthcheck]
+`
+
+func TestParseEnvironmentSpec(t *testing.T) {
+	t.Parallel()
+
+	spec, err := container.ParseEnvironmentSpec(strings.NewReader(testEnvironmentSpec))
+	require.NoError(t, err)
+
+	assert.Equal(t, "itest", spec.Name)
+	require.Len(t, spec.Services, 3)
+
+	db := spec.Services["db"]
+
+	assert.Equal(t, []container.Volume{{
+		Source:   "/srv/init",
+		Target:   "/docker-entrypoint-initdb.d",
+		ReadOnly: true,
+	}}, db.Volumes)
+	require.NotNil(t, db.HealthCheck)
+	assert.Equal(t, 30*time.Second, db.HealthCheck.Timeout)
+
+	_, err = container.ParseEnvironmentSpec(strings.NewReader("services:\n  db:\n    imagee: postgres\n"))
+	assert.ErrorIs(t, err, container.ErrInvalidEnvironment)
+
+	_, err = container.ParseEnvironmentSpec(strings.NewReader("services:\n  db:\n    image: postgres\n    volumes: [data]\n"))
+	assert.ErrorIs(t, err, container.ErrInvalidEnvironment)
+}
+
+func TestEnvironmentSpecOrder(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Services  map[string]container.ServiceSpec
+		Expected  []string
+		Assertion require.ErrorAssertionFunc
+	}{
+		"dependency chain": {
+			Services: map[string]container.ServiceSpec{
+				"a": {Image: "a", DependsOn: []string{"b"}},
+				"b": {Image: "b", DependsOn: []string{"c"}},
+				"c": {Image: "c"},
+				"d": {Image: "d"},
+			},
+			Expected:  []string{"c", "b", "a", "d"},
+			Assertion: require.NoError,
+		},
+		"cycle": {
+			Services: map[string]container.ServiceSpec{
+				"a": {Image: "a", DependsOn: []string{"b"}},
+				"b": {Image: "b", DependsOn: []string{"a"}},
+				"c": {Image: "c"},
+			},
+			Assertion: require.Error,
+		},
+		"unknown dependency": {
+			Services: map[string]container.ServiceSpec{
+				"a": {Image: "a", DependsOn: []string{"b"}},
+			},
+			Assertion: require.Error,
+		},
+		"missing image": {
+			Services: map[string]container.ServiceSpec{
+				"a": {},
+			},
+			Assertion: require.Error,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			order, err := container.EnvironmentSpec{Services: tc.Services}.Order()
+			tc.Assertion(t, err)
+
+			assert.Equal(t, tc.Expected, order)
+		})
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writers.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+// fakeEnvironmentRuntime answers "run" with the container's
+// name as its ID and "logs" with a single readiness line.
+func fakeEnvironmentRuntime(exec *containertest.Executor) {
+	exec.Handle([]string{"run"}, func(inv container.Invocation) (container.Output, error) {
+		i := slices.Index(inv.Args, "--name")
+
+		return container.Output{Stdout: inv.Args[i+1] + "\n"}, nil
+	})
+	exec.Handle([]string{"inspect"}, containertest.Stdout("true\n"))
+	exec.Handle([]string{"logs"}, containertest.Stdout("ready to accept connections\npartial"))
+}
+
+func TestUp(t *testing.T) {
+	t.Parallel()
+
+	var exec containertest.Executor
+
+	fakeEnvironmentRuntime(&exec)
+
+	client := containertest.NewClient(&exec)
+
+	spec, err := container.ParseEnvironmentSpec(strings.NewReader(testEnvironmentSpec))
+	require.NoError(t, err)
+
+	var logs syncBuffer
+
+	env, err := client.Up(context.Background(), spec, container.WithLogOutput{Writer: &logs})
+	require.NoError(t, err)
+
+	api, ok := env.Service("api")
+	require.True(t, ok)
+	assert.Equal(t, "itest_api", api.ID)
+
+	var started []string
+
+	for _, inv := range exec.Calls("run") {
+		started = append(started, inv.Args[slices.Index(inv.Args, "--name")+1])
+	}
+
+	assert.Equal(t, []string{"itest_db", "itest_mock", "itest_api"}, started)
+
+	run := exec.Calls("run")[2].Args
+	assert.Subset(t, run, []string{"--network", "itest_backend", "--network-alias", "api"})
+	assert.Subset(t, run, []string{"--label", container.EnvironmentLabel + "=itest"})
+
+	connect := exec.Calls("network", "connect")
+	require.Len(t, connect, 1)
+	assert.Equal(t, []string{"network", "connect", "--alias", "api", "itest_default", "itest_api"}, connect[0].Args)
+
+	var networks []string
+
+	for _, inv := range exec.Calls("network", "create") {
+		networks = append(networks, inv.Args[len(inv.Args)-1])
+	}
+
+	assert.Equal(t, []string{"itest_backend", "itest_default"}, networks)
+
+	require.NoError(t, env.Down(context.Background()))
+
+	var removed []string
+
+	for _, inv := range exec.Calls("rm") {
+		removed = append(removed, inv.Args[len(inv.Args)-1])
+	}
+
+	assert.Equal(t, []string{"itest_api", "itest_mock", "itest_db"}, removed)
+	assert.Len(t, exec.Calls("network", "rm"), 2)
+
+	assert.Contains(t, logs.String(), "db   | ready to accept connections\n")
+	assert.Contains(t, logs.String(), "api  | partial\n")
+
+	require.NoError(t, env.Down(context.Background()))
+	assert.Len(t, exec.Calls("rm"), 3)
+}
+
+func TestUpDefaultNetwork(t *testing.T) {
+	t.Parallel()
+
+	var exec containertest.Executor
+
+	fakeEnvironmentRuntime(&exec)
+
+	client := containertest.NewClient(&exec)
+
+	env, err := client.Up(context.Background(), container.EnvironmentSpec{
+		Name:     "itest",
+		Networks: []string{"default", "backend", "backend"},
+		Services: map[string]container.ServiceSpec{
+			"mock": {Image: "quay.io/org/mock:v1"},
+		},
+	})
+	require.NoError(t, err)
+
+	var networks []string
+
+	for _, inv := range exec.Calls("network", "create") {
+		networks = append(networks, inv.Args[len(inv.Args)-1])
+	}
+
+	assert.Equal(t, []string{"itest_default", "itest_backend"}, networks)
+
+	require.NoError(t, env.Down(context.Background()))
+	assert.Len(t, exec.Calls("network", "rm"), 2)
+}
+
+func TestUpFailureTearsDown(t *testing.T) {
+	t.Parallel()
+
+	var exec containertest.Executor
+
+	fakeEnvironmentRuntime(&exec)
+	exec.Handle([]string{"run", "--detach", "--name", "itest_mock"}, containertest.Fail(125, "image not known"))
+
+	client := containertest.NewClient(&exec)
+
+	spec, err := container.ParseEnvironmentSpec(strings.NewReader(testEnvironmentSpec))
+	require.NoError(t, err)
+
+	_, err = client.Up(context.Background(), spec)
+	require.ErrorContains(t, err, `starting service "mock"`)
+
+	rm := exec.Calls("rm")
+	require.Len(t, rm, 1)
+	assert.Equal(t, "itest_db", rm[0].Args[len(rm[0].Args)-1])
+	assert.Len(t, exec.Calls("network", "rm"), 2)
+}
@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFiles are the files, in order of precedence, from which
+// patterns excluding paths from the build context are read.
+var IgnoreFiles = []string{".containerignore", ".dockerignore"}
+
+// IgnorePatterns excludes paths from a build context using
+// the ".dockerignore" syntax. Patterns are matched against
+// slash-separated paths relative to the context root with
+// later patterns taking precedence. Patterns prefixed with
+// "!" re-include previously excluded paths.
+type IgnorePatterns struct {
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	re     *regexp.Regexp
+	negate bool
+}
+
+// LoadIgnorePatterns reads the first of "IgnoreFiles" present in
+// contextDir. No patterns are returned when none are present.
+func LoadIgnorePatterns(contextDir string) (*IgnorePatterns, error) {
+	for _, name := range IgnoreFiles {
+		f, err := os.Open(filepath.Join(contextDir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("opening ignore file: %w", err)
+		}
+
+		defer f.Close()
+
+		return ParseIgnorePatterns(f)
+	}
+
+	return &IgnorePatterns{}, nil
+}
+
+// ParseIgnorePatterns parses ".dockerignore" formatted patterns.
+func ParseIgnorePatterns(r io.Reader) (*IgnorePatterns, error) {
+	var res IgnorePatterns
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var negate bool
+
+		if strings.HasPrefix(line, "!") {
+			negate = true
+			line = strings.TrimSpace(line[1:])
+		}
+
+		line = strings.TrimPrefix(path.Clean(filepath.ToSlash(line)), "/")
+		if line == "" || line == "." {
+			continue
+		}
+
+		re, err := ignoreRegexp(line)
+		if err != nil {
+			return nil, fmt.Errorf("compiling ignore pattern %q: %w", line, err)
+		}
+
+		res.patterns = append(res.patterns, ignorePattern{re: re, negate: negate})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading ignore patterns: %w", err)
+	}
+
+	return &res, nil
+}
+
+// Ignored reports whether the given relative path is excluded.
+// A path is also excluded when any of its parents match.
+func (p *IgnorePatterns) Ignored(rel string) bool {
+	rel = filepath.ToSlash(rel)
+
+	var ignored bool
+
+	for _, pattern := range p.patterns {
+		if pattern.negate == ignored && pattern.matches(rel) {
+			ignored = !pattern.negate
+		}
+	}
+
+	return ignored
+}
+
+func (p ignorePattern) matches(rel string) bool {
+	for {
+		if p.re.MatchString(rel) {
+			return true
+		}
+
+		i := strings.LastIndex(rel, "/")
+		if i < 0 {
+			return false
+		}
+
+		rel = rel[:i]
+	}
+}
+
+// ignoreRegexp translates a pattern to a regular expression where
+// "*" and "?" do not match "/" while "**" matches any number of
+// directories.
+func ignoreRegexp(pattern string) (*regexp.Regexp, error) {
+	var re strings.Builder
+
+	re.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" also matches zero directories
+					i++
+
+					re.WriteString("(?:.*/)?")
+				} else {
+					re.WriteString(".*")
+				}
+			} else {
+				re.WriteString("[^/]*")
+			}
+		case '?':
+			re.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+
+			re.WriteString("[" + class + "]")
+			i += end
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+
+			re.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	re.WriteString("$")
+
+	return regexp.Compile(re.String())
+}
@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mt-sre/go-ci/git"
+)
+
+// Pre-defined OCI image annotation keys.
+const (
+	LabelCreated     = "org.opencontainers.image.created"
+	LabelDescription = "org.opencontainers.image.description"
+	LabelLicenses    = "org.opencontainers.image.licenses"
+	LabelRevision    = "org.opencontainers.image.revision"
+	LabelSource      = "org.opencontainers.image.source"
+	LabelTitle       = "org.opencontainers.image.title"
+	LabelURL         = "org.opencontainers.image.url"
+	LabelVendor      = "org.opencontainers.image.vendor"
+	LabelVersion     = "org.opencontainers.image.version"
+)
+
+// Labels maps image label keys to values.
+type Labels map[string]string
+
+// Args renders the labels as "--label" arguments
+// for a runtime's "build" command in key order.
+func (l Labels) Args() []string {
+	args := make([]string, 0, 2*len(l))
+
+	for _, k := range sortedKeys(l) {
+		args = append(args, "--label", k+"="+l[k])
+	}
+
+	return args
+}
+
+// ImageLabels derives OCI image labels from the metadata of the
+// git repository in the current, or configured, working directory.
+//
+// The "created" label is taken from the SOURCE_DATE_EPOCH
+// environment variable when set and from the HEAD commit time
+// otherwise so that rebuilding a commit yields identical labels.
+// Labels which cannot be derived, such as the version of a
+// repository without semantic version tags, are omitted.
+func ImageLabels(ctx context.Context, opts ...ImageLabelsOption) (Labels, error) {
+	var cfg ImageLabelsConfig
+
+	cfg.Option(opts...)
+
+	labels := Labels{}
+
+	revParseOpts := []git.RevParseOption{}
+	versionOpts := []git.LatestVersionOption{}
+	remoteOpts := []git.RemoteURLOption{}
+	commitTimeOpts := []git.CommitTimeOption{}
+
+	if cfg.WorkingDir != "" {
+		wd := git.WithWorkingDirectory(cfg.WorkingDir)
+
+		revParseOpts = append(revParseOpts, wd)
+		versionOpts = append(versionOpts, wd)
+		remoteOpts = append(remoteOpts, wd)
+		commitTimeOpts = append(commitTimeOpts, wd)
+	}
+
+	if cfg.Remote != "" {
+		remoteOpts = append(remoteOpts, git.WithRemote(cfg.Remote))
+	}
+
+	rev, err := git.RevParse(ctx, revParseOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("getting revision: %w", err)
+	}
+
+	labels[LabelRevision] = rev
+
+	version := cfg.Version
+	if version == "" {
+		version, err = git.LatestVersion(ctx, versionOpts...)
+		if err != nil && !errors.Is(err, git.ErrNoTagsFound) {
+			return nil, fmt.Errorf("getting version: %w", err)
+		}
+	}
+
+	if version != "" {
+		labels[LabelVersion] = version
+	}
+
+	remote, err := git.RemoteURL(ctx, remoteOpts...)
+	if err != nil && !errors.Is(err, git.ErrRemoteNotFound) {
+		return nil, fmt.Errorf("getting remote url: %w", err)
+	}
+
+	if source := SourceURL(remote); source != "" {
+		labels[LabelSource] = source
+		labels[LabelURL] = source
+	}
+
+	created := cfg.Created
+	if created.IsZero() {
+		created, err = sourceDateEpoch()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if created.IsZero() {
+		created, err = git.CommitTime(ctx, commitTimeOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("getting commit time: %w", err)
+		}
+	}
+
+	labels[LabelCreated] = created.UTC().Format(time.RFC3339)
+
+	for k, v := range cfg.Extra {
+		labels[k] = v
+	}
+
+	return labels, nil
+}
+
+type ImageLabelsConfig struct {
+	Created    time.Time
+	Extra      Labels
+	Remote     string
+	Version    string
+	WorkingDir string
+}
+
+func (c *ImageLabelsConfig) Option(opts ...ImageLabelsOption) {
+	for _, opt := range opts {
+		opt.ConfigureImageLabels(c)
+	}
+}
+
+type ImageLabelsOption interface {
+	ConfigureImageLabels(*ImageLabelsConfig)
+}
+
+func sourceDateEpoch() (time.Time, error) {
+	val, ok := os.LookupEnv("SOURCE_DATE_EPOCH")
+	if !ok || val == "" {
+		return time.Time{}, nil
+	}
+
+	secs, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing SOURCE_DATE_EPOCH %q: %w", val, err)
+	}
+
+	return time.Unix(secs, 0).UTC(), nil
+}
+
+// SourceURL converts a git remote URL into a browsable "https"
+// URL with credentials and any ".git" suffix removed. SCP-like
+// and "ssh://" remotes are rewritten. An empty string is
+// returned for local remotes.
+func SourceURL(remote string) string {
+	remote = strings.TrimSpace(remote)
+	if remote == "" {
+		return ""
+	}
+
+	// scp-like syntax, e.g. "git@github.com:org/repo.git"
+	if !strings.Contains(remote, "://") {
+		userHost, path, ok := strings.Cut(remote, ":")
+		// single letters are windows drive letters
+		if !ok || len(userHost) < 2 || strings.ContainsAny(userHost, "/\\") {
+			return ""
+		}
+
+		_, host, found := strings.Cut(userHost, "@")
+		if !found {
+			host = userHost
+		}
+
+		remote = "https://" + host + "/" + strings.TrimPrefix(path, "/")
+	}
+
+	u, err := url.Parse(remote)
+	if err != nil {
+		return ""
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+	case "ssh", "git", "git+ssh":
+		// ports of other protocols do not serve https
+		u.Host = u.Hostname()
+	default:
+		return ""
+	}
+
+	u.Scheme = "https"
+	u.User = nil
+	u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), ".git")
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	return u.String()
+}
@@ -0,0 +1,480 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Media types of OCI and docker image artifacts.
+const (
+	MediaTypeOCIIndex       = "application/vnd.oci.image.index.v1+json"
+	MediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIConfig      = "application/vnd.oci.image.config.v1+json"
+	MediaTypeOCILayer       = "application/vnd.oci.image.layer.v1.tar+gzip"
+	MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeDockerConfig   = "application/vnd.docker.container.image.v1+json"
+	MediaTypeDockerLayer    = "application/vnd.docker.image.rootfs.diff.tar"
+)
+
+const (
+	annotationRefName        = "org.opencontainers.image.ref.name"
+	annotationContainerdName = "io.containerd.image.name"
+	// files larger than this are hashed but not retained
+	maxArchiveMetadataFileSize = 4 << 20
+)
+
+// Descriptor references content by digest.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType,omitempty"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *Platform         `json:"platform,omitempty"`
+}
+
+// Platform describes the platform an image runs on.
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+func (p Platform) String() string {
+	res := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		res += "/" + p.Variant
+	}
+
+	return res
+}
+
+// Manifest is an OCI image manifest or index as well as
+// docker's equivalent manifest and manifest list.
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Config        *Descriptor       `json:"config,omitempty"`
+	Layers        []Descriptor      `json:"layers,omitempty"`
+	Manifests     []Descriptor      `json:"manifests,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// IsIndex reports whether the manifest lists other manifests.
+func (m Manifest) IsIndex() bool {
+	return m.MediaType == MediaTypeOCIIndex || m.MediaType == MediaTypeDockerList ||
+		(m.MediaType == "" && m.Config == nil && len(m.Manifests) > 0)
+}
+
+// ArchiveImage is a single image found within an image archive.
+type ArchiveImage struct {
+	// RepoTags are the references the image was saved as.
+	RepoTags []string
+	// Manifest describes the image manifest. It is unset for
+	// docker archives which do not contain OCI manifests.
+	Manifest Descriptor
+	Config   Descriptor
+	Layers   []Descriptor
+	Platform Platform
+}
+
+// Size returns the total size of the image's config and layers.
+func (i ArchiveImage) Size() int64 {
+	size := i.Config.Size
+	for _, l := range i.Layers {
+		size += l.Size
+	}
+
+	return size
+}
+
+// ImageArchive describes the contents of an image archive.
+type ImageArchive struct {
+	// Format is either ArchiveFormatDocker or ArchiveFormatOCI.
+	Format ArchiveFormat
+	Images []ArchiveImage
+	// Problems lists missing content as well as content
+	// whose digest or size does not match its descriptor.
+	Problems []error
+}
+
+// Err joins all problems found within the archive.
+func (a *ImageArchive) Err() error {
+	return errors.Join(a.Problems...)
+}
+
+// ErrUnknownArchive is returned for archives which are
+// neither docker archives nor OCI image layouts.
+var ErrUnknownArchive = errors.New("unknown image archive format")
+
+// InspectArchive reads an OCI image layout directory or a
+// docker or OCI archive, optionally gzip compressed, without
+// requiring a container runtime. Every file in the archive is
+// hashed so that content can be verified against descriptors.
+func InspectArchive(path string) (*ImageArchive, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("inspecting archive: %w", err)
+	}
+
+	var idx archiveIndex
+
+	if info.IsDir() {
+		idx, err = indexDir(path)
+	} else {
+		idx, err = indexTarFile(path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("indexing %q: %w", path, err)
+	}
+
+	return idx.inspect()
+}
+
+type archiveEntry struct {
+	digest string
+	size   int64
+	data   []byte
+}
+
+// archiveIndex maps slash separated paths to archive entries.
+type archiveIndex map[string]archiveEntry
+
+func (idx archiveIndex) add(name string, r io.Reader) error {
+	h := sha256.New()
+
+	var buf bytes.Buffer
+
+	n, err := io.Copy(io.MultiWriter(h, &limitedBuffer{buf: &buf, limit: maxArchiveMetadataFileSize}), r)
+	if err != nil {
+		return fmt.Errorf("reading %q: %w", name, err)
+	}
+
+	entry := archiveEntry{
+		digest: "sha256:" + hex.EncodeToString(h.Sum(nil)),
+		size:   n,
+	}
+
+	if n <= maxArchiveMetadataFileSize {
+		entry.data = buf.Bytes()
+	}
+
+	idx[path.Clean(name)] = entry
+
+	return nil
+}
+
+// limitedBuffer discards writes once limit is exceeded.
+type limitedBuffer struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.buf.Len()+len(p) <= b.limit {
+		b.buf.Write(p)
+	}
+
+	return len(p), nil
+}
+
+func indexDir(root string) (archiveIndex, error) {
+	idx := archiveIndex{}
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		return idx.add(filepath.ToSlash(rel), f)
+	})
+
+	return idx, err
+}
+
+func indexTarFile(p string) (archiveIndex, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+
+	var r io.Reader = br
+
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+
+		defer gz.Close()
+
+		r = gz
+	}
+
+	idx := archiveIndex{}
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return idx, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if err := idx.add(hdr.Name, tr); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (idx archiveIndex) inspect() (*ImageArchive, error) {
+	_, hasIndex := idx["index.json"]
+	_, hasManifest := idx["manifest.json"]
+
+	res := &ImageArchive{}
+
+	var err error
+
+	switch {
+	case hasIndex:
+		res.Format = ArchiveFormatOCI
+		err = idx.inspectOCI(res)
+	case hasManifest:
+		res.Format = ArchiveFormatDocker
+		err = idx.inspectDocker(res)
+	default:
+		return nil, ErrUnknownArchive
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// docker archives since v25 are also OCI layouts carrying
+	// tags only within their docker manifest
+	if hasIndex && hasManifest {
+		var docker ImageArchive
+		if err := idx.inspectDocker(&docker); err == nil {
+			res.Format = ArchiveFormatDocker
+			mergeRepoTags(res.Images, docker.Images)
+		}
+	}
+
+	return res, nil
+}
+
+func mergeRepoTags(dst, src []ArchiveImage) {
+	for i := range dst {
+		if len(dst[i].RepoTags) > 0 {
+			continue
+		}
+
+		for _, img := range src {
+			if img.Config.Digest == dst[i].Config.Digest {
+				dst[i].RepoTags = img.RepoTags
+			}
+		}
+	}
+}
+
+func (idx archiveIndex) readJSON(name string, v any) error {
+	entry, ok := idx[name]
+	if !ok {
+		return fmt.Errorf("%q: %w", name, fs.ErrNotExist)
+	}
+
+	if entry.data == nil && entry.size > 0 {
+		return fmt.Errorf("%q exceeds %d bytes", name, maxArchiveMetadataFileSize)
+	}
+
+	if err := json.Unmarshal(entry.data, v); err != nil {
+		return fmt.Errorf("parsing %q: %w", name, err)
+	}
+
+	return nil
+}
+
+func blobPath(digest string) string {
+	alg, hex, _ := strings.Cut(digest, ":")
+
+	return path.Join("blobs", alg, hex)
+}
+
+// verify records a problem if the descriptor's content is
+// absent or does not match. Only sha256 digests are verified.
+func (idx archiveIndex) verify(res *ImageArchive, desc Descriptor) {
+	entry, ok := idx[blobPath(desc.Digest)]
+
+	switch {
+	case !ok:
+		res.Problems = append(res.Problems, fmt.Errorf("blob %s: %w", desc.Digest, fs.ErrNotExist))
+	case entry.size != desc.Size:
+		res.Problems = append(res.Problems, fmt.Errorf("blob %s: size %d does not match descriptor size %d", desc.Digest, entry.size, desc.Size))
+	case strings.HasPrefix(desc.Digest, "sha256:") && entry.digest != desc.Digest:
+		res.Problems = append(res.Problems, fmt.Errorf("blob %s: content digest is %s", desc.Digest, entry.digest))
+	}
+}
+
+func (idx archiveIndex) inspectOCI(res *ImageArchive) error {
+	var index Manifest
+	if err := idx.readJSON("index.json", &index); err != nil {
+		return err
+	}
+
+	for _, desc := range index.Manifests {
+		if err := idx.inspectOCIManifest(res, desc, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (idx archiveIndex) inspectOCIManifest(res *ImageArchive, desc Descriptor, tags []string) error {
+	idx.verify(res, desc)
+
+	if name := desc.Annotations[annotationContainerdName]; name != "" {
+		tags = append(tags, name)
+	} else if name := desc.Annotations[annotationRefName]; name != "" {
+		tags = append(tags, name)
+	}
+
+	var manifest Manifest
+
+	if err := idx.readJSON(blobPath(desc.Digest), &manifest); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if manifest.IsIndex() {
+		for _, child := range manifest.Manifests {
+			if err := idx.inspectOCIManifest(res, child, tags); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	img := ArchiveImage{
+		RepoTags: tags,
+		Manifest: desc,
+		Layers:   manifest.Layers,
+	}
+
+	if manifest.Config != nil {
+		img.Config = *manifest.Config
+		idx.verify(res, img.Config)
+
+		_ = idx.readJSON(blobPath(img.Config.Digest), &img.Platform)
+	}
+
+	for _, l := range manifest.Layers {
+		idx.verify(res, l)
+	}
+
+	res.Images = append(res.Images, img)
+
+	return nil
+}
+
+func (idx archiveIndex) inspectDocker(res *ImageArchive) error {
+	var manifest []struct {
+		Config   string
+		RepoTags []string
+		Layers   []string
+	}
+
+	if err := idx.readJSON("manifest.json", &manifest); err != nil {
+		return err
+	}
+
+	for _, m := range manifest {
+		img := ArchiveImage{RepoTags: m.RepoTags}
+
+		img.Config = idx.dockerDescriptor(res, m.Config, MediaTypeDockerConfig)
+		_ = idx.readJSON(path.Clean(m.Config), &img.Platform)
+
+		for _, l := range m.Layers {
+			img.Layers = append(img.Layers, idx.dockerDescriptor(res, l, MediaTypeDockerLayer))
+		}
+
+		res.Images = append(res.Images, img)
+	}
+
+	return nil
+}
+
+// dockerDescriptor describes a file referenced by a docker archive
+// manifest. Files named by digest are verified against their name.
+func (idx archiveIndex) dockerDescriptor(res *ImageArchive, name, mediaType string) Descriptor {
+	name = path.Clean(name)
+
+	entry, ok := idx[name]
+	if !ok {
+		res.Problems = append(res.Problems, fmt.Errorf("%q: %w", name, fs.ErrNotExist))
+
+		return Descriptor{MediaType: mediaType}
+	}
+
+	desc := Descriptor{
+		MediaType: mediaType,
+		Digest:    entry.digest,
+		Size:      entry.size,
+	}
+
+	expected := ""
+
+	switch dir, base := path.Split(name); {
+	case dir == "blobs/sha256/":
+		expected = "sha256:" + base
+	case mediaType == MediaTypeDockerConfig:
+		expected = "sha256:" + strings.TrimSuffix(base, ".json")
+	}
+
+	if expected != "" && expected != entry.digest {
+		res.Problems = append(res.Problems, fmt.Errorf("%q: content digest is %s", name, entry.digest))
+	}
+
+	return desc
+}
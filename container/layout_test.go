@@ -0,0 +1,281 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mt-sre/go-ci/container"
+	"github.com/mt-sre/go-ci/container/containertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	return data
+}
+
+// writeLayout writes a single image OCI layout to dir
+// returning the image's config and layer descriptors.
+func writeLayout(t *testing.T, dir string) (container.Descriptor, container.Descriptor) {
+	t.Helper()
+
+	files := map[string][]byte{
+		"oci-layout": []byte(`{"imageLayoutVersion":"1.0.0"}`),
+	}
+
+	addBlob := func(mediaType string, data []byte) container.Descriptor {
+		digest := digestOf(data)
+		files[filepath.Join("blobs", "sha256", digest[len("sha256:"):])] = data
+
+		return container.Descriptor{MediaType: mediaType, Digest: digest, Size: int64(len(data))}
+	}
+
+	layer := addBlob(container.MediaTypeOCILayer, []byte("layer contents"))
+	config := addBlob(container.MediaTypeOCIConfig, []byte(`{"architecture":"arm64","os":"linux","variant":"v8"}`))
+	manifest := addBlob(container.MediaTypeOCIManifest, mustJSON(t, container.Manifest{
+		SchemaVersion: 2,
+		MediaType:     container.MediaTypeOCIManifest,
+		Config:        &config,
+		Layers:        []container.Descriptor{layer},
+	}))
+
+	manifest.Annotations = map[string]string{"org.opencontainers.image.ref.name": "quay.io/org/app:v1"}
+
+	files["index.json"] = mustJSON(t, container.Manifest{
+		SchemaVersion: 2,
+		MediaType:     container.MediaTypeOCIIndex,
+		Manifests:     []container.Descriptor{manifest},
+	})
+
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+	}
+
+	return config, layer
+}
+
+// tarDir writes the contents of dir to a, optionally
+// compressed, tarball at out.
+func tarDir(t *testing.T, dir, out string, compress bool) {
+	t.Helper()
+
+	f, err := os.Create(out)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	var w io.Writer = f
+
+	if compress {
+		gz := gzip.NewWriter(f)
+		defer gz.Close()
+
+		w = gz
+	}
+
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	require.NoError(t, tw.AddFS(os.DirFS(dir)))
+}
+
+func TestInspectArchiveOCI(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	layout := filepath.Join(dir, "layout")
+	config, layer := writeLayout(t, layout)
+
+	tarDir(t, layout, filepath.Join(dir, "image.tar"), false)
+	tarDir(t, layout, filepath.Join(dir, "image.tar.gz"), true)
+
+	for _, path := range []string{"layout", "image.tar", "image.tar.gz"} {
+		res, err := container.InspectArchive(filepath.Join(dir, path))
+		require.NoError(t, err, path)
+		require.NoError(t, res.Err(), path)
+
+		assert.Equal(t, container.ArchiveFormatOCI, res.Format)
+		require.Len(t, res.Images, 1)
+
+		img := res.Images[0]
+
+		assert.Equal(t, []string{"quay.io/org/app:v1"}, img.RepoTags)
+		assert.Equal(t, config, img.Config)
+		assert.Equal(t, []container.Descriptor{layer}, img.Layers)
+		assert.Equal(t, "linux/arm64/v8", img.Platform.String())
+		assert.Equal(t, config.Size+layer.Size, img.Size())
+		assert.NotEmpty(t, img.Manifest.Digest)
+	}
+}
+
+func TestInspectArchiveCorrupt(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	_, layer := writeLayout(t, dir)
+
+	layerPath := filepath.Join(dir, "blobs", "sha256", layer.Digest[len("sha256:"):])
+	require.NoError(t, os.WriteFile(layerPath, []byte("tampered contents"), 0o644))
+
+	res, err := container.InspectArchive(dir)
+	require.NoError(t, err)
+
+	require.Len(t, res.Problems, 1)
+	assert.ErrorContains(t, res.Err(), "size 17 does not match descriptor size 14")
+
+	require.NoError(t, os.Remove(layerPath))
+
+	res, err = container.InspectArchive(dir)
+	require.NoError(t, err)
+	assert.ErrorIs(t, res.Err(), fs.ErrNotExist)
+}
+
+func TestInspectArchiveDocker(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	config := []byte(`{"architecture":"amd64","os":"linux"}`)
+	configName := digestOf(config)[len("sha256:"):] + ".json"
+	layer := []byte("layer contents")
+
+	files := map[string][]byte{
+		configName:      config,
+		"abc/layer.tar": layer,
+		"manifest.json": mustJSON(t, []map[string]any{{
+			"Config":   configName,
+			"RepoTags": []string{"busybox:latest"},
+			"Layers":   []string{"abc/layer.tar"},
+		}}),
+	}
+
+	for name, data := range files {
+		path := filepath.Join(dir, "archive", name)
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+	}
+
+	out := filepath.Join(dir, "image.tar")
+	tarDir(t, filepath.Join(dir, "archive"), out, false)
+
+	res, err := container.InspectArchive(out)
+	require.NoError(t, err)
+	require.NoError(t, res.Err())
+
+	assert.Equal(t, container.ArchiveFormatDocker, res.Format)
+	require.Len(t, res.Images, 1)
+
+	img := res.Images[0]
+
+	assert.Equal(t, []string{"busybox:latest"}, img.RepoTags)
+	assert.Equal(t, digestOf(config), img.Config.Digest)
+	assert.Equal(t, "linux/amd64", img.Platform.String())
+	require.Len(t, img.Layers, 1)
+	assert.Equal(t, container.Descriptor{
+		MediaType: container.MediaTypeDockerLayer,
+		Digest:    digestOf(layer),
+		Size:      int64(len(layer)),
+	}, img.Layers[0])
+}
+
+func TestInspectArchiveUnknown(t *testing.T) {
+	t.Parallel()
+
+	_, err := container.InspectArchive(t.TempDir())
+	assert.ErrorIs(t, err, container.ErrUnknownArchive)
+}
+
+func TestSave(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Refs         []string
+		Options      []container.SaveOption
+		ExpectedArgs []string
+	}{
+		"single image": {
+			Refs:         []string{"a"},
+			ExpectedArgs: []string{"save", "--output", "out.tar", "a"},
+		},
+		"default format": {
+			Refs:         []string{"a", "b"},
+			ExpectedArgs: []string{"save", "--output", "out.tar", "--multi-image-archive", "a", "b"},
+		},
+		"docker archive": {
+			Refs:         []string{"a", "b"},
+			Options:      []container.SaveOption{container.WithFormat(container.ArchiveFormatDocker)},
+			ExpectedArgs: []string{"save", "--output", "out.tar", "--format", "docker-archive", "--multi-image-archive", "a", "b"},
+		},
+		"oci archive": {
+			Refs:         []string{"a", "b"},
+			Options:      []container.SaveOption{container.WithFormat(container.ArchiveFormatOCI)},
+			ExpectedArgs: []string{"save", "--output", "out.tar", "--format", "oci-archive", "a", "b"},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var exec containertest.Executor
+
+			client := containertest.NewClient(&exec)
+
+			require.NoError(t, client.Save(context.Background(), "out.tar", tc.Refs, tc.Options...))
+
+			save := exec.Calls("save")
+			require.Len(t, save, 1)
+			assert.Equal(t, tc.ExpectedArgs, save[0].Args)
+		})
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	t.Parallel()
+
+	var exec containertest.Executor
+
+	exec.Handle([]string{"load"}, containertest.Stdout("Loaded image(s): quay.io/org/a:v1,quay.io/org/b:v1\n"))
+
+	client := containertest.NewClient(&exec)
+
+	require.NoError(t, client.Save(context.Background(), "out.tar", []string{"a", "b"},
+		container.WithFormat(container.ArchiveFormatOCI)))
+
+	loaded, err := client.Load(context.Background(), "out.tar")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"quay.io/org/a:v1", "quay.io/org/b:v1"}, loaded)
+
+	docker := containertest.NewClient(&exec, container.WithFlavor(container.FlavorDocker))
+
+	err = docker.Save(context.Background(), "out.tar", []string{"a"}, container.WithFormat(container.ArchiveFormatOCIDir))
+	assert.ErrorIs(t, err, container.ErrUnsupportedFormat)
+}
@@ -0,0 +1,269 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoEmulation is reported for platforms which can neither
+	// run natively nor through a registered emulator.
+	ErrNoEmulation = errors.New("no emulation available for platform")
+	// ErrPlatformBuildsFailed is returned when one or more
+	// per-platform builds of a manifest list fail.
+	ErrPlatformBuildsFailed = errors.New("platform builds failed")
+	// ErrManifestListRequiresPush is returned when docker is asked
+	// to assemble a manifest list without pushing it since docker
+	// can only assemble lists from images within a registry.
+	ErrManifestListRequiresPush = errors.New("docker manifest lists must be pushed")
+)
+
+// BuildManifestList builds the context directory once for each
+// configured platform, tagging each image as 'ref' suffixed with
+// the platform, e.g. "quay.io/org/app:v1-linux-arm64", and then
+// assembles the images into a manifest list named 'ref'.
+//
+// The outcome of every platform build is reported in the result.
+// The list is only assembled, and optionally pushed, when all
+// builds succeed unless partial lists are allowed in which case
+// failed builds are only reported through the result's "Err".
+func (c *Client) BuildManifestList(ctx context.Context, contextDir, ref string, opts ...ManifestListOption) (*ManifestListResult, error) {
+	var cfg ManifestListConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	if c.cfg.Flavor == FlavorDocker && !cfg.Push {
+		return nil, ErrManifestListRequiresPush
+	}
+
+	res := &ManifestListResult{Ref: ref}
+
+	var built []string
+
+	for _, platform := range cfg.Platforms {
+		pr := c.buildPlatform(ctx, contextDir, ref, platform, cfg)
+		if pr.Err == nil {
+			built = append(built, pr.Ref)
+		}
+
+		res.Platforms = append(res.Platforms, pr)
+	}
+
+	if err := res.Err(); err != nil && (!cfg.AllowPartial || len(built) == 0) {
+		return res, err
+	}
+
+	var err error
+
+	switch c.cfg.Flavor {
+	case FlavorDocker:
+		err = c.assembleDocker(ctx, ref, built, cfg)
+	default:
+		err = c.assemblePodman(ctx, ref, built, cfg)
+	}
+
+	if err != nil {
+		return res, fmt.Errorf("assembling manifest list %q: %w", ref, err)
+	}
+
+	res.Pushed = cfg.Push
+
+	return res, nil
+}
+
+func (c *Client) buildPlatform(ctx context.Context, contextDir, ref, platform string, cfg ManifestListConfig) PlatformResult {
+	pr := PlatformResult{
+		Platform: platform,
+		Ref:      PlatformRef(ref, platform),
+	}
+
+	if !cfg.EmulationCheck(platform) {
+		pr.Err = fmt.Errorf("%s: %w", platform, ErrNoEmulation)
+
+		return pr
+	}
+
+	build := cfg.Build
+	build.Platform = platform
+	build.Tags = []string{pr.Ref}
+
+	start := time.Now()
+
+	if err := c.build(ctx, contextDir, build); err != nil {
+		pr.Err = err
+	} else if c.cfg.Flavor == FlavorDocker {
+		// imagetools can only reference images within a registry
+		pr.Err = c.Push(ctx, pr.Ref, WithAuthFile(cfg.Build.AuthFile))
+	}
+
+	pr.Duration = time.Since(start)
+
+	return pr
+}
+
+func (c *Client) assemblePodman(ctx context.Context, ref string, images []string, cfg ManifestListConfig) error {
+	// an existing list or image of the same name would be extended
+	// rather than replaced so it is removed first
+	_, _ = c.run(ctx, "manifest", "rm", ref)
+
+	if _, err := c.run(ctx, "manifest", "create", ref); err != nil {
+		return err
+	}
+
+	for _, img := range images {
+		if _, err := c.run(ctx, "manifest", "add", ref, "containers-storage:"+img); err != nil {
+			return err
+		}
+	}
+
+	if !cfg.Push {
+		return nil
+	}
+
+	_, err := c.runWithEnv(ctx, authEnv(c.cfg.Flavor, cfg.Build.AuthFile),
+		"manifest", "push", "--all", ref, "docker://"+ref)
+
+	return err
+}
+
+func (c *Client) assembleDocker(ctx context.Context, ref string, images []string, cfg ManifestListConfig) error {
+	args := append([]string{"buildx", "imagetools", "create", "--tag", ref}, images...)
+
+	_, err := c.runWithEnv(ctx, authEnv(c.cfg.Flavor, cfg.Build.AuthFile), args...)
+
+	return err
+}
+
+type ManifestListConfig struct {
+	AllowPartial   bool
+	Build          BuildConfig
+	EmulationCheck func(platform string) bool
+	Platforms      []string
+	Push           bool
+}
+
+func (c *ManifestListConfig) Option(opts ...ManifestListOption) {
+	for _, opt := range opts {
+		opt.ConfigureManifestList(c)
+	}
+}
+
+func (c *ManifestListConfig) Default() {
+	if len(c.Platforms) == 0 {
+		c.Platforms = []string{"linux/" + runtime.GOARCH}
+	}
+
+	if c.EmulationCheck == nil {
+		c.EmulationCheck = EmulationAvailable
+	}
+}
+
+type ManifestListOption interface {
+	ConfigureManifestList(*ManifestListConfig)
+}
+
+// ManifestListResult reports the outcome of "BuildManifestList".
+type ManifestListResult struct {
+	// Ref is the name of the manifest list.
+	Ref string
+	// Platforms holds the result of each platform build
+	// in the order the platforms were configured.
+	Platforms []PlatformResult
+	// Pushed is true once the manifest list has been pushed.
+	Pushed bool
+}
+
+// Err joins the errors of all failed platform builds.
+func (r *ManifestListResult) Err() error {
+	var errs []error
+
+	for _, p := range r.Platforms {
+		if p.Err != nil {
+			errs = append(errs, p.Err)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %w", ErrPlatformBuildsFailed, errors.Join(errs...))
+}
+
+// PlatformResult is the outcome of a single platform build.
+type PlatformResult struct {
+	Platform string
+	// Ref is the platform specific image reference.
+	Ref      string
+	Duration time.Duration
+	Err      error
+}
+
+// PlatformRef returns the reference used for the 'platform'
+// specific image of a manifest list named 'ref'. Any digest
+// of 'ref' is dropped since it identifies the manifest list.
+func PlatformRef(ref, platform string) string {
+	name, tag := splitTag(ref)
+	if tag == "" {
+		tag = "latest"
+	}
+
+	return name + ":" + tag + "-" + strings.ReplaceAll(platform, "/", "-")
+}
+
+// splitTag splits an image reference into its name and
+// tag discarding any digest.
+func splitTag(ref string) (string, string) {
+	ref, _, _ = strings.Cut(ref, "@")
+
+	i := strings.LastIndex(ref, ":")
+	if i < 0 || strings.Contains(ref[i:], "/") {
+		return ref, ""
+	}
+
+	return ref[:i], ref[i+1:]
+}
+
+// EmulationAvailable reports whether images for the given
+// platform, e.g. "linux/arm64", can be built on this host.
+// Foreign architectures require a QEMU binfmt_misc handler
+// on Linux. Other host operating systems are assumed to run
+// builds within a VM providing emulation.
+func EmulationAvailable(platform string) bool {
+	goos, arch, _ := strings.Cut(platform, "/")
+	arch, _, _ = strings.Cut(arch, "/")
+
+	if goos != "linux" {
+		return false
+	}
+
+	if arch == runtime.GOARCH || runtime.GOOS != "linux" {
+		return true
+	}
+
+	qemuArch, ok := qemuArchs[arch]
+	if !ok {
+		qemuArch = arch
+	}
+
+	_, err := os.Stat(filepath.Join("/proc/sys/fs/binfmt_misc", "qemu-"+qemuArch))
+
+	return err == nil
+}
+
+var qemuArchs = map[string]string{
+	"386":   "i386",
+	"amd64": "x86_64",
+	"arm64": "aarch64",
+}
@@ -0,0 +1,228 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/mt-sre/go-ci/container"
+	"github.com/mt-sre/go-ci/container/containertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	var exec containertest.Executor
+
+	client := containertest.NewClient(&exec)
+
+	err := client.Build(context.Background(), ".",
+		container.WithContainerfile("build/Containerfile"),
+		container.WithPlatform("linux/arm64"),
+		container.WithTags{"quay.io/org/app:v1"},
+		container.WithBuildArgs{"TOKEN": "secret"},
+		container.WithLabels{container.LabelVersion: "v1"},
+		container.WithExtraArgs{"--pull=never"},
+	)
+	require.NoError(t, err)
+
+	calls := exec.Calls("build")
+	require.Len(t, calls, 1)
+
+	assert.Equal(t, []string{
+		"build",
+		"--file", "build/Containerfile",
+		"--platform", "linux/arm64",
+		"--tag", "quay.io/org/app:v1",
+		"--build-arg", "TOKEN",
+		"--label", "org.opencontainers.image.version=v1",
+		"--pull=never",
+		".",
+	}, calls[0].Args)
+	assert.Equal(t, map[string]string{"TOKEN": "secret"}, calls[0].Env)
+}
+
+func TestPlatformRef(t *testing.T) {
+	t.Parallel()
+
+	for ref, expected := range map[string]string{
+		"quay.io/org/app:v1":                         "quay.io/org/app:v1-linux-arm64",
+		"quay.io/org/app":                            "quay.io/org/app:latest-linux-arm64",
+		"localhost:5000/org/app":                     "localhost:5000/org/app:latest-linux-arm64",
+		"localhost:5000/app:v1.0":                    "localhost:5000/app:v1.0-linux-arm64",
+		"quay.io/org/app@sha256:0123456789abcdef":    "quay.io/org/app:latest-linux-arm64",
+		"quay.io/org/app:v1@sha256:0123456789abcdef": "quay.io/org/app:v1-linux-arm64",
+	} {
+		assert.Equal(t, expected, container.PlatformRef(ref, "linux/arm64"), ref)
+	}
+}
+
+func TestEmulationAvailable(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, container.EmulationAvailable("linux/"+runtime.GOARCH))
+	assert.False(t, container.EmulationAvailable("windows/amd64"))
+}
+
+func alwaysEmulated(string) bool { return true }
+
+func TestBuildManifestListPodman(t *testing.T) {
+	t.Parallel()
+
+	var exec containertest.Executor
+
+	client := containertest.NewClient(&exec)
+
+	res, err := client.BuildManifestList(context.Background(), ".", "quay.io/org/app:v1",
+		container.WithPlatforms{"linux/amd64", "linux/arm64"},
+		container.WithBuildArgs{"GO_VERSION": "1.23"},
+		container.WithPush(true),
+		container.WithEmulationCheck(alwaysEmulated),
+	)
+	require.NoError(t, err)
+	require.NoError(t, res.Err())
+
+	assert.True(t, res.Pushed)
+	require.Len(t, res.Platforms, 2)
+	assert.Equal(t, "quay.io/org/app:v1-linux-amd64", res.Platforms[0].Ref)
+	assert.Equal(t, "quay.io/org/app:v1-linux-arm64", res.Platforms[1].Ref)
+
+	builds := exec.Calls("build")
+	require.Len(t, builds, 2)
+	assert.Contains(t, builds[1].Args, "linux/arm64")
+	assert.Contains(t, builds[1].Args, "GO_VERSION")
+
+	var manifestCmds [][]string
+	for _, inv := range exec.Calls("manifest") {
+		manifestCmds = append(manifestCmds, inv.Args)
+	}
+
+	assert.Equal(t, [][]string{
+		{"manifest", "rm", "quay.io/org/app:v1"},
+		{"manifest", "create", "quay.io/org/app:v1"},
+		{"manifest", "add", "quay.io/org/app:v1", "containers-storage:quay.io/org/app:v1-linux-amd64"},
+		{"manifest", "add", "quay.io/org/app:v1", "containers-storage:quay.io/org/app:v1-linux-arm64"},
+		{"manifest", "push", "--all", "quay.io/org/app:v1", "docker://quay.io/org/app:v1"},
+	}, manifestCmds)
+}
+
+func TestBuildManifestListDocker(t *testing.T) {
+	t.Parallel()
+
+	var exec containertest.Executor
+
+	client := containertest.NewClient(&exec, container.WithFlavor(container.FlavorDocker))
+
+	_, err := client.BuildManifestList(context.Background(), ".", "quay.io/org/app:v1")
+	require.ErrorIs(t, err, container.ErrManifestListRequiresPush)
+
+	_, err = client.BuildManifestList(context.Background(), ".", "quay.io/org/app:v1",
+		container.WithPlatforms{"linux/amd64", "linux/s390x"},
+		container.WithPush(true),
+		container.WithEmulationCheck(alwaysEmulated),
+	)
+	require.NoError(t, err)
+
+	assert.Len(t, exec.Calls("push"), 2)
+
+	create := exec.Calls("buildx", "imagetools", "create")
+	require.Len(t, create, 1)
+	assert.Equal(t, []string{
+		"buildx", "imagetools", "create", "--tag", "quay.io/org/app:v1",
+		"quay.io/org/app:v1-linux-amd64", "quay.io/org/app:v1-linux-s390x",
+	}, create[0].Args)
+}
+
+func TestBuildManifestListFailures(t *testing.T) {
+	t.Parallel()
+
+	emulated := func(platform string) bool { return platform != "linux/s390x" }
+
+	newClient := func() (*containertest.Executor, *container.Client) {
+		var exec containertest.Executor
+
+		exec.Handle([]string{"build"}, func(inv container.Invocation) (container.Output, error) {
+			if slices.Contains(inv.Args, "linux/ppc64le") {
+				return containertest.Fail(1, "exec format error")(inv)
+			}
+
+			return container.Output{}, nil
+		})
+
+		return &exec, containertest.NewClient(&exec)
+	}
+
+	opts := []container.ManifestListOption{
+		container.WithPlatforms{"linux/amd64", "linux/ppc64le", "linux/s390x"},
+		container.WithEmulationCheck(emulated),
+	}
+
+	t.Run("all or nothing", func(t *testing.T) {
+		t.Parallel()
+
+		exec, client := newClient()
+
+		res, err := client.BuildManifestList(context.Background(), ".", "app:v1", opts...)
+		require.ErrorIs(t, err, container.ErrPlatformBuildsFailed)
+
+		require.Len(t, res.Platforms, 3)
+		assert.NoError(t, res.Platforms[0].Err)
+
+		var execErr *container.ExecError
+
+		assert.True(t, errors.As(res.Platforms[1].Err, &execErr))
+		assert.ErrorIs(t, res.Platforms[2].Err, container.ErrNoEmulation)
+
+		assert.Empty(t, exec.Calls("manifest"))
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		t.Parallel()
+
+		exec, client := newClient()
+
+		res, err := client.BuildManifestList(context.Background(), ".", "app:v1",
+			append(opts, container.WithAllowPartial(true))...)
+		require.NoError(t, err)
+		require.ErrorIs(t, res.Err(), container.ErrPlatformBuildsFailed)
+
+		assert.Len(t, exec.Calls("manifest", "add"), 1)
+	})
+}
+
+func TestBuildManifestListRuntime(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping runtime test in short mode")
+	}
+
+	client, err := container.NewClient()
+	if errors.Is(err, container.ErrNoRuntimeFound) {
+		t.Skip("no container runtime available")
+	}
+
+	require.NoError(t, err)
+
+	if client.Flavor() != container.FlavorPodman {
+		t.Skip("local manifest lists require podman")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Containerfile"), []byte("FROM scratch\nLABEL test=true\n"), 0o644))
+
+	ref := "localhost/go-ci-test/manifest:latest"
+
+	res, err := client.BuildManifestList(context.Background(), dir, ref)
+	require.NoError(t, err)
+	require.Len(t, res.Platforms, 1)
+	assert.NoError(t, res.Platforms[0].Err)
+}
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"context"
+	"fmt"
+)
+
+// CreateNetwork creates a bridge network with the given name
+// on which containers may reach one another by name or alias.
+func (c *Client) CreateNetwork(ctx context.Context, name string, opts ...NetworkOption) error {
+	var cfg NetworkConfig
+
+	cfg.Option(opts...)
+
+	args := []string{"network", "create"}
+
+	for _, k := range sortedKeys(cfg.Labels) {
+		args = append(args, "--label", k+"="+cfg.Labels[k])
+	}
+
+	if _, err := c.run(ctx, append(args, name)...); err != nil {
+		return fmt.Errorf("creating network %q: %w", name, err)
+	}
+
+	return nil
+}
+
+type NetworkConfig struct {
+	Labels map[string]string
+}
+
+func (c *NetworkConfig) Option(opts ...NetworkOption) {
+	for _, opt := range opts {
+		opt.ConfigureNetwork(c)
+	}
+}
+
+type NetworkOption interface {
+	ConfigureNetwork(*NetworkConfig)
+}
+
+// RemoveNetwork removes the named network.
+func (c *Client) RemoveNetwork(ctx context.Context, name string) error {
+	if _, err := c.run(ctx, "network", "rm", name); err != nil {
+		return fmt.Errorf("removing network %q: %w", name, err)
+	}
+
+	return nil
+}
+
+func (c *Client) connectNetwork(ctx context.Context, network, id string, aliases []string) error {
+	args := []string{"network", "connect"}
+
+	for _, alias := range aliases {
+		args = append(args, "--alias", alias)
+	}
+
+	if _, err := c.run(ctx, append(args, network, id)...); err != nil {
+		return fmt.Errorf("connecting container to network %q: %w", network, err)
+	}
+
+	return nil
+}
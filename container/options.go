@@ -0,0 +1,405 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// WithAllowPartial assembles manifest lists from the
+// successfully built platforms when others fail.
+type WithAllowPartial bool
+
+func (w WithAllowPartial) ConfigureManifestList(c *ManifestListConfig) {
+	c.AllowPartial = bool(w)
+}
+
+// WithAuthFile authenticates against registries using the auth
+// file at the given path. The path is passed to the runtime through
+// its environment as REGISTRY_AUTH_FILE for podman, or as the parent
+// directory in DOCKER_CONFIG for docker in which case the file must
+// be named "config.json". See "AuthFile.TempAuthFile".
+type WithAuthFile string
+
+func (w WithAuthFile) ConfigureBuild(c *BuildConfig) {
+	c.AuthFile = string(w)
+}
+
+func (w WithAuthFile) ConfigureEnvironment(c *EnvironmentConfig) {
+	c.AuthFile = string(w)
+}
+
+func (w WithAuthFile) ConfigureManifestList(c *ManifestListConfig) {
+	w.ConfigureBuild(&c.Build)
+}
+
+func (w WithAuthFile) ConfigurePush(c *PushConfig) {
+	c.AuthFile = string(w)
+}
+
+func (w WithAuthFile) ConfigureService(c *ServiceConfig) {
+	c.AuthFile = string(w)
+}
+
+func (w WithAuthFile) ConfigureWrap(c *WrapConfig) {
+	c.AuthFile = string(w)
+}
+
+// WithBinPath uses the runtime executable at the given path.
+type WithBinPath string
+
+func (w WithBinPath) ConfigureClient(c *ClientConfig) {
+	c.BinPath = string(w)
+}
+
+// WithExecutor runs runtime invocations through the given Executor.
+type WithExecutor struct{ Executor }
+
+func (w WithExecutor) ConfigureClient(c *ClientConfig) {
+	c.Executor = w.Executor
+}
+
+// WithFlavor overrides the runtime flavor which is
+// otherwise inferred from the executable name.
+type WithFlavor Flavor
+
+func (w WithFlavor) ConfigureClient(c *ClientConfig) {
+	c.Flavor = Flavor(w)
+}
+
+// WithBuildArgs sets build-time variables.
+type WithBuildArgs map[string]string
+
+func (w WithBuildArgs) ConfigureBuild(c *BuildConfig) {
+	if c.BuildArgs == nil {
+		c.BuildArgs = make(map[string]string, len(w))
+	}
+
+	for k, v := range w {
+		c.BuildArgs[k] = v
+	}
+}
+
+func (w WithBuildArgs) ConfigureManifestList(c *ManifestListConfig) {
+	w.ConfigureBuild(&c.Build)
+}
+
+func (w WithBuildArgs) ConfigureParse(c *ParseConfig) {
+	if c.BuildArgs == nil {
+		c.BuildArgs = make(map[string]string, len(w))
+	}
+
+	for k, v := range w {
+		c.BuildArgs[k] = v
+	}
+}
+
+// WithCleanup removes the container when the
+// given Cleaner, e.g. a *testing.T, cleans up.
+type WithCleanup struct{ Cleaner }
+
+func (w WithCleanup) ConfigureService(c *ServiceConfig) {
+	c.Cleaner = w.Cleaner
+}
+
+func (w WithCleanup) ConfigureEnvironment(c *EnvironmentConfig) {
+	c.Cleaner = w.Cleaner
+}
+
+// WithCmd overrides the image's default command.
+type WithCmd []string
+
+func (w WithCmd) ConfigureService(c *ServiceConfig) {
+	c.Cmd = append(c.Cmd, w...)
+}
+
+// WithContainerfile builds using the Containerfile at the given path.
+type WithContainerfile string
+
+func (w WithContainerfile) ConfigureBuild(c *BuildConfig) {
+	c.Containerfile = string(w)
+}
+
+func (w WithContainerfile) ConfigureManifestList(c *ManifestListConfig) {
+	w.ConfigureBuild(&c.Build)
+}
+
+// WithCreated sets the image creation time.
+type WithCreated time.Time
+
+func (w WithCreated) ConfigureImageLabels(c *ImageLabelsConfig) {
+	c.Created = time.Time(w)
+}
+
+// WithCredentials resolves registry credentials using
+// the given CredentialResolver, e.g. an *AuthFile.
+type WithCredentials struct{ CredentialResolver }
+
+func (w WithCredentials) ConfigureRegistryClient(c *RegistryClientConfig) {
+	c.Credentials = w.CredentialResolver
+}
+
+// WithEmulationCheck replaces the check used to determine
+// whether a platform can be built on the current host.
+type WithEmulationCheck func(platform string) bool
+
+func (w WithEmulationCheck) ConfigureManifestList(c *ManifestListConfig) {
+	c.EmulationCheck = w
+}
+
+// WithEnv sets environment variables within the container.
+type WithEnv map[string]string
+
+func (w WithEnv) ConfigureService(c *ServiceConfig) {
+	if c.Env == nil {
+		c.Env = make(map[string]string, len(w))
+	}
+
+	for k, v := range w {
+		c.Env[k] = v
+	}
+}
+
+// WithExtraArgs passes additional flags to the runtime.
+type WithExtraArgs []string
+
+func (w WithExtraArgs) ConfigureBuild(c *BuildConfig) {
+	c.ExtraArgs = append(c.ExtraArgs, w...)
+}
+
+func (w WithExtraArgs) ConfigureManifestList(c *ManifestListConfig) {
+	w.ConfigureBuild(&c.Build)
+}
+
+func (w WithExtraArgs) ConfigurePush(c *PushConfig) {
+	c.ExtraArgs = append(c.ExtraArgs, w...)
+}
+
+// WithForwardEnv passes the named variables from
+// the host's environment into the container.
+type WithForwardEnv []string
+
+func (w WithForwardEnv) ConfigureWrap(c *WrapConfig) {
+	c.ForwardEnv = append(c.ForwardEnv, w...)
+}
+
+// WithFormat selects the archive format images are saved in.
+type WithFormat ArchiveFormat
+
+func (w WithFormat) ConfigureSave(c *SaveConfig) {
+	c.Format = ArchiveFormat(w)
+}
+
+// WithHTTPClient sets the client used to send HTTP requests.
+type WithHTTPClient struct{ *http.Client }
+
+func (w WithHTTPClient) ConfigureRegistryClient(c *RegistryClientConfig) {
+	c.HTTPClient = w.Client
+}
+
+// WithImageChecker skips cached builds of images which the given
+// ImageChecker, e.g. one querying a registry, reports to exist.
+type WithImageChecker struct{ ImageChecker }
+
+func (w WithImageChecker) ConfigureBuild(c *BuildConfig) {
+	c.ImageChecker = w.ImageChecker
+}
+
+// WithInsecure permits pushing to registries served over
+// plain HTTP or with untrusted certificates, e.g. those
+// started by the registrytest package. Registry clients
+// are only permitted to use plain HTTP.
+type WithInsecure bool
+
+func (w WithInsecure) ConfigurePush(c *PushConfig) {
+	c.Insecure = bool(w)
+}
+
+// ConfigureRegistryClient accesses registries over plain HTTP
+// instead of HTTPS. Untrusted certificates may be permitted by
+// configuring the transport of the client given "WithHTTPClient".
+func (w WithInsecure) ConfigureRegistryClient(c *RegistryClientConfig) {
+	c.Insecure = bool(w)
+}
+
+// WithLabels applies the given labels.
+type WithLabels map[string]string
+
+func (w WithLabels) ConfigureService(c *ServiceConfig) {
+	if c.Labels == nil {
+		c.Labels = make(map[string]string, len(w))
+	}
+
+	for k, v := range w {
+		c.Labels[k] = v
+	}
+}
+
+func (w WithLabels) ConfigureImageLabels(c *ImageLabelsConfig) {
+	if c.Extra == nil {
+		c.Extra = make(Labels, len(w))
+	}
+
+	for k, v := range w {
+		c.Extra[k] = v
+	}
+}
+
+func (w WithLabels) ConfigureBuild(c *BuildConfig) {
+	if c.Labels == nil {
+		c.Labels = make(Labels, len(w))
+	}
+
+	for k, v := range w {
+		c.Labels[k] = v
+	}
+}
+
+func (w WithLabels) ConfigureManifestList(c *ManifestListConfig) {
+	w.ConfigureBuild(&c.Build)
+}
+
+func (w WithLabels) ConfigureNetwork(c *NetworkConfig) {
+	if c.Labels == nil {
+		c.Labels = make(map[string]string, len(w))
+	}
+
+	for k, v := range w {
+		c.Labels[k] = v
+	}
+}
+
+// WithLogOutput streams the logs of every service to the
+// given writer with each line prefixed by the service name.
+type WithLogOutput struct{ io.Writer }
+
+func (w WithLogOutput) ConfigureEnvironment(c *EnvironmentConfig) {
+	c.LogOutput = w.Writer
+}
+
+// WithName names the container.
+type WithName string
+
+func (w WithName) ConfigureService(c *ServiceConfig) {
+	c.Name = string(w)
+}
+
+// WithNetworkAliases makes the container reachable by
+// the given names on each of its networks.
+type WithNetworkAliases []string
+
+func (w WithNetworkAliases) ConfigureService(c *ServiceConfig) {
+	c.NetworkAliases = append(c.NetworkAliases, w...)
+}
+
+// WithNetworks attaches the container to the given networks.
+type WithNetworks []string
+
+func (w WithNetworks) ConfigureService(c *ServiceConfig) {
+	c.Networks = append(c.Networks, w...)
+}
+
+// WithPlatform builds for the given platform, e.g. "linux/arm64".
+type WithPlatform string
+
+func (w WithPlatform) ConfigureBuild(c *BuildConfig) {
+	c.Platform = string(w)
+}
+
+// WithPlatforms builds manifest lists for the given platforms.
+type WithPlatforms []string
+
+func (w WithPlatforms) ConfigureManifestList(c *ManifestListConfig) {
+	c.Platforms = append(c.Platforms, w...)
+}
+
+// WithPorts publishes the given container ports, e.g. "5432"
+// or "53/udp", on randomly allocated loopback host ports.
+type WithPorts []string
+
+func (w WithPorts) ConfigureService(c *ServiceConfig) {
+	c.Ports = append(c.Ports, w...)
+}
+
+// WithPush pushes manifest lists once assembled.
+type WithPush bool
+
+func (w WithPush) ConfigureManifestList(c *ManifestListConfig) {
+	c.Push = bool(w)
+}
+
+// WithReadiness waits for the given Probe to succeed.
+type WithReadiness struct{ Probe }
+
+func (w WithReadiness) ConfigureService(c *ServiceConfig) {
+	c.Probe = w.Probe
+}
+
+// WithReadinessInterval sets the delay between probe attempts.
+type WithReadinessInterval time.Duration
+
+func (w WithReadinessInterval) ConfigureService(c *ServiceConfig) {
+	c.ReadinessInterval = time.Duration(w)
+}
+
+// WithReadinessTimeout bounds the time waited for readiness.
+type WithReadinessTimeout time.Duration
+
+func (w WithReadinessTimeout) ConfigureService(c *ServiceConfig) {
+	c.ReadinessTimeout = time.Duration(w)
+}
+
+// WithRemote selects the git remote used as the image source.
+type WithRemote string
+
+func (w WithRemote) ConfigureImageLabels(c *ImageLabelsConfig) {
+	c.Remote = string(w)
+}
+
+// WithRunArgs passes additional flags to the runtime's "run" command.
+type WithRunArgs []string
+
+func (w WithRunArgs) ConfigureService(c *ServiceConfig) {
+	c.RunArgs = append(c.RunArgs, w...)
+}
+
+func (w WithRunArgs) ConfigureWrap(c *WrapConfig) {
+	c.RunArgs = append(c.RunArgs, w...)
+}
+
+// WithVolumes mounts the given volumes.
+type WithVolumes []Volume
+
+func (w WithVolumes) ConfigureService(c *ServiceConfig) {
+	c.Volumes = append(c.Volumes, w...)
+}
+
+func (w WithVolumes) ConfigureWrap(c *WrapConfig) {
+	c.Volumes = append(c.Volumes, w...)
+}
+
+// WithTags tags built images with the given references.
+type WithTags []string
+
+func (w WithTags) ConfigureBuild(c *BuildConfig) {
+	c.Tags = append(c.Tags, w...)
+}
+
+// WithVersion overrides the version otherwise
+// derived from the latest git tag.
+type WithVersion string
+
+func (w WithVersion) ConfigureImageLabels(c *ImageLabelsConfig) {
+	c.Version = string(w)
+}
+
+// WithWorkingDirectory applies the given working directory.
+type WithWorkingDirectory string
+
+func (w WithWorkingDirectory) ConfigureImageLabels(c *ImageLabelsConfig) {
+	c.WorkingDir = string(w)
+}
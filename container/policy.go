@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule identifies a base image policy rule.
+type Rule string
+
+const (
+	// RuleUnpinned reports base images without a digest.
+	RuleUnpinned Rule = "unpinned-base"
+	// RuleRegistry reports base images from registries
+	// outside of the allowed list.
+	RuleRegistry Rule = "disallowed-registry"
+	// RuleLatest reports base images using the
+	// "latest" tag, whether explicitly or implicitly.
+	RuleLatest Rule = "latest-tag"
+	// RuleUser reports final stages which do not
+	// switch to a non-root user.
+	RuleUser Rule = "missing-user"
+	// RuleInvalid reports base images which cannot
+	// be parsed after ARG substitution.
+	RuleInvalid Rule = "invalid-base"
+)
+
+// Violation is a single policy violation.
+type Violation struct {
+	Path    string
+	Line    int
+	Rule    Rule
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", v.Path, v.Line, v.Rule, v.Message)
+}
+
+// Policy describes the requirements placed on Containerfiles.
+type Policy struct {
+	// AllowedRegistries restricts base images to the given
+	// registries, e.g. "quay.io", or repository prefixes,
+	// e.g. "registry.access.redhat.com/ubi9". No restriction
+	// is applied when empty.
+	AllowedRegistries []string
+	// RequireDigest requires base images to be pinned by digest.
+	RequireDigest bool
+	// ForbidLatest forbids the "latest" tag. References with
+	// neither tag nor digest implicitly use "latest".
+	ForbidLatest bool
+	// RequireUser requires the final stage to contain
+	// a USER instruction which is not root.
+	RequireUser bool
+}
+
+// Check returns all violations of the policy within cf. Stages
+// based on "scratch" or an earlier stage are not checked
+// against base image rules.
+func (p Policy) Check(cf *Containerfile) []Violation {
+	var res []Violation
+
+	report := func(line int, rule Rule, format string, args ...any) {
+		res = append(res, Violation{
+			Path:    cf.Path,
+			Line:    line,
+			Rule:    rule,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	stages := map[string]struct{}{}
+
+	for _, stage := range cf.Stages {
+		// FROM may only refer to previously declared stages
+		_, isStage := stages[strings.ToLower(stage.BaseImage)]
+		if !isStage && stage.BaseImage != "scratch" {
+			p.checkBase(stage, report)
+		}
+
+		if stage.Name != "" {
+			stages[stage.Name] = struct{}{}
+		}
+	}
+
+	if p.RequireUser && len(cf.Stages) > 0 {
+		final := cf.Stages[len(cf.Stages)-1]
+
+		user := ""
+		line := final.From.Line
+
+		for _, inst := range final.Instructions {
+			if inst.Command == "USER" && len(inst.Args) > 0 {
+				user, line = inst.Args[0], inst.Line
+			}
+		}
+
+		name, _, _ := strings.Cut(user, ":")
+
+		switch name {
+		case "":
+			report(line, RuleUser, "final stage does not set USER")
+		case "root", "0":
+			report(line, RuleUser, "final stage runs as root")
+		}
+	}
+
+	return res
+}
+
+func (p Policy) checkBase(stage Stage, report func(int, Rule, string, ...any)) {
+	line := stage.From.Line
+
+	ref, err := ParseReference(stage.BaseImage)
+	if err != nil {
+		report(line, RuleInvalid, "base image %q: %v", stage.BaseImage, err)
+
+		return
+	}
+
+	if p.RequireDigest && ref.Digest == "" {
+		report(line, RuleUnpinned, "base image %q is not pinned by digest", stage.BaseImage)
+	}
+
+	if p.ForbidLatest && (ref.Tag == "latest" || (ref.Tag == "" && ref.Digest == "")) {
+		report(line, RuleLatest, "base image %q uses the latest tag", stage.BaseImage)
+	}
+
+	if len(p.AllowedRegistries) > 0 && !p.allowed(ref) {
+		report(line, RuleRegistry, "base image %q is not from an allowed registry", stage.BaseImage)
+	}
+}
+
+func (p Policy) allowed(ref Reference) bool {
+	name := ref.Name()
+
+	for _, allowed := range p.AllowedRegistries {
+		allowed = strings.TrimSuffix(allowed, "/")
+
+		if name == allowed || strings.HasPrefix(name, allowed+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckFiles parses each Containerfile in paths and returns
+// the combined violations of the policy. Paths will commonly
+// be supplied by "FindContainerfiles".
+func (p Policy) CheckFiles(paths []string, opts ...ParseOption) ([]Violation, error) {
+	var res []Violation
+
+	for _, path := range paths {
+		cf, err := ParseContainerfileFile(path, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, p.Check(cf)...)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Probe reports whether a Service is ready by returning
+// a nil error. Probes are polled until they succeed or
+// the readiness timeout elapses.
+type Probe interface {
+	Ready(ctx context.Context, svc *Service) error
+}
+
+// ErrNotReady is returned by probes when a
+// service has not yet become ready.
+var ErrNotReady = errors.New("not ready")
+
+// ErrInvalidProbe is returned when a probe is
+// missing required configuration.
+var ErrInvalidProbe = errors.New("invalid probe")
+
+// validator is implemented by probes which can detect
+// misconfiguration before a service is started.
+type validator interface {
+	validate() error
+}
+
+// LogProbe is ready once the container's combined output
+// matches Pattern at least Occurrences times. Occurrences
+// defaults to 1.
+type LogProbe struct {
+	Pattern     *regexp.Regexp
+	Occurrences int
+}
+
+func (p LogProbe) Ready(ctx context.Context, svc *Service) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	logs, err := svc.Logs(ctx)
+	if err != nil {
+		return err
+	}
+
+	want := max(p.Occurrences, 1)
+
+	if got := len(p.Pattern.FindAllStringIndex(logs, want)); got < want {
+		return fmt.Errorf("found %d of %d occurrences of %q in logs: %w", got, want, p.Pattern, ErrNotReady)
+	}
+
+	return nil
+}
+
+func (p LogProbe) validate() error {
+	if p.Pattern == nil {
+		return fmt.Errorf("%w: log probe has no pattern", ErrInvalidProbe)
+	}
+
+	return nil
+}
+
+// TCPProbe is ready once a connection can be established to
+// the host endpoint of the container Port. Note that some
+// runtimes accept connections on published ports before the
+// containerized process is listening.
+type TCPProbe struct {
+	Port string
+}
+
+func (p TCPProbe) Ready(ctx context.Context, svc *Service) error {
+	endpoint, ok := svc.Endpoint(p.Port)
+	if !ok {
+		return fmt.Errorf("port %q is not published", p.Port)
+	}
+
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", endpoint)
+	if err != nil {
+		return fmt.Errorf("dialing %q: %w", endpoint, err)
+	}
+
+	return conn.Close()
+}
+
+// HTTPProbe is ready once a GET request to Path on the host
+// endpoint of the container Port responds with Status. If
+// Status is unset any 2xx status is accepted.
+type HTTPProbe struct {
+	Port   string
+	Path   string
+	Status int
+}
+
+func (p HTTPProbe) Ready(ctx context.Context, svc *Service) error {
+	endpoint, ok := svc.Endpoint(p.Port)
+	if !ok {
+		return fmt.Errorf("port %q is not published", p.Port)
+	}
+
+	url := "http://" + endpoint + "/" + strings.TrimPrefix(p.Path, "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("constructing request: %w", err)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting %q: %w", url, err)
+	}
+
+	defer res.Body.Close()
+
+	switch {
+	case p.Status == 0 && res.StatusCode >= 200 && res.StatusCode < 300:
+		return nil
+	case p.Status == res.StatusCode:
+		return nil
+	default:
+		return fmt.Errorf("requesting %q returned status %d: %w", url, res.StatusCode, ErrNotReady)
+	}
+}
+
+// ExecProbe is ready once Command exits successfully
+// when executed within the container.
+type ExecProbe struct {
+	Command []string
+}
+
+func (p ExecProbe) Ready(ctx context.Context, svc *Service) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	_, err := svc.Exec(ctx, p.Command...)
+
+	return err
+}
+
+func (p ExecProbe) validate() error {
+	if len(p.Command) == 0 {
+		return fmt.Errorf("%w: exec probe has no command", ErrInvalidProbe)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DockerHub is the registry assumed for references
+// which do not include a registry host.
+const DockerHub = "docker.io"
+
+// ErrInvalidReference is returned for malformed image references.
+var ErrInvalidReference = errors.New("invalid image reference")
+
+// Reference is a parsed image reference such as
+// "quay.io/org/app:v1" or "busybox@sha256:...".
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+var (
+	registryPattern   = regexp.MustCompile(`^(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)*|\[[0-9a-fA-F:]+\])(?::[0-9]+)?$`)
+	repositoryPattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	tagPattern        = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestPattern     = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-zA-Z0-9=_-]{32,}$`)
+)
+
+// ParseReference parses an image reference normalizing references
+// without a registry to Docker Hub. Neither a tag nor a digest
+// is implied when absent.
+func ParseReference(ref string) (Reference, error) {
+	var res Reference
+
+	name := ref
+
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, res.Digest = name[:i], name[i+1:]
+
+		if !digestPattern.MatchString(res.Digest) {
+			return Reference{}, fmt.Errorf("%w %q: malformed digest", ErrInvalidReference, ref)
+		}
+	}
+
+	if i := strings.LastIndex(name, ":"); i >= 0 && !strings.Contains(name[i:], "/") {
+		name, res.Tag = name[:i], name[i+1:]
+
+		if !tagPattern.MatchString(res.Tag) {
+			return Reference{}, fmt.Errorf("%w %q: malformed tag", ErrInvalidReference, ref)
+		}
+	}
+
+	res.Registry = DockerHub
+	res.Repository = name
+
+	if first, rest, ok := strings.Cut(name, "/"); ok && isRegistryHost(first) {
+		res.Registry, res.Repository = first, rest
+	}
+
+	switch res.Registry {
+	case "index.docker.io", "registry-1.docker.io":
+		res.Registry = DockerHub
+	}
+
+	if res.Registry == DockerHub && !strings.Contains(res.Repository, "/") {
+		res.Repository = "library/" + res.Repository
+	}
+
+	if !registryPattern.MatchString(res.Registry) {
+		return Reference{}, fmt.Errorf("%w %q: malformed registry", ErrInvalidReference, ref)
+	}
+
+	if !repositoryPattern.MatchString(res.Repository) {
+		return Reference{}, fmt.Errorf("%w %q: malformed repository", ErrInvalidReference, ref)
+	}
+
+	return res, nil
+}
+
+func isRegistryHost(s string) bool {
+	return s == "localhost" || strings.ContainsAny(s, ".:")
+}
+
+// Name returns the registry qualified repository name.
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// String returns the fully qualified reference.
+func (r Reference) String() string {
+	res := r.Name()
+
+	if r.Tag != "" {
+		res += ":" + r.Tag
+	}
+
+	if r.Digest != "" {
+		res += "@" + r.Digest
+	}
+
+	return res
+}
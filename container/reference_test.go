@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container_test
+
+import (
+	"testing"
+
+	"github.com/mt-sre/go-ci/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	t.Parallel()
+
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	for ref, expected := range map[string]container.Reference{
+		"busybox": {
+			Registry:   "docker.io",
+			Repository: "library/busybox",
+		},
+		"org/app:v1": {
+			Registry:   "docker.io",
+			Repository: "org/app",
+			Tag:        "v1",
+		},
+		"index.docker.io/library/alpine:3": {
+			Registry:   "docker.io",
+			Repository: "library/alpine",
+			Tag:        "3",
+		},
+		"quay.io/org/sub/app:v1.2.3@" + digest: {
+			Registry:   "quay.io",
+			Repository: "org/sub/app",
+			Tag:        "v1.2.3",
+			Digest:     digest,
+		},
+		"localhost:5000/app": {
+			Registry:   "localhost:5000",
+			Repository: "app",
+		},
+		"localhost/app:latest": {
+			Registry:   "localhost",
+			Repository: "app",
+			Tag:        "latest",
+		},
+	} {
+		res, err := container.ParseReference(ref)
+		require.NoError(t, err, ref)
+
+		assert.Equal(t, expected, res, ref)
+	}
+}
+
+func TestParseReferenceInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, ref := range []string{
+		"",
+		"UPPER/case",
+		"app:bad/tag",
+		"app@sha256:short",
+		"quay.io/",
+		"app:",
+	} {
+		_, err := container.ParseReference(ref)
+		assert.ErrorIs(t, err, container.ErrInvalidReference, ref)
+	}
+}
+
+func TestReferenceString(t *testing.T) {
+	t.Parallel()
+
+	ref, err := container.ParseReference("busybox:1.36")
+	require.NoError(t, err)
+
+	assert.Equal(t, "docker.io/library/busybox", ref.Name())
+	assert.Equal(t, "docker.io/library/busybox:1.36", ref.String())
+}
@@ -0,0 +1,671 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned when a registry does not
+// contain the requested repository, manifest or blob.
+var ErrNotFound = errors.New("not found in registry")
+
+// RegistryError is returned for unsuccessful registry responses.
+// Errors for 404 responses match ErrNotFound.
+type RegistryError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *RegistryError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("registry responded with status %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("registry responded with status %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+func (e *RegistryError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// CredentialResolver resolves credentials for image
+// references. It is satisfied by *AuthFile.
+type CredentialResolver interface {
+	Resolve(ctx context.Context, ref string) (Credentials, error)
+}
+
+// NewRegistryClient returns a client for the distribution
+// API of container registries. Registries are accessed
+// anonymously unless credentials are configured with
+// "WithCredentials".
+func NewRegistryClient(opts ...RegistryClientOption) *RegistryClient {
+	var cfg RegistryClientConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return &RegistryClient{
+		cfg:   cfg,
+		auths: make(map[string]string),
+	}
+}
+
+// RegistryClient queries and copies content between registries
+// implementing the OCI distribution specification without
+// requiring a container runtime.
+type RegistryClient struct {
+	cfg RegistryClientConfig
+
+	mu sync.Mutex
+	// auths caches Authorization header values
+	// by registry and requested scope
+	auths map[string]string
+}
+
+type RegistryClientConfig struct {
+	Credentials CredentialResolver
+	HTTPClient  *http.Client
+	// Insecure accesses registries over plain HTTP.
+	Insecure bool
+}
+
+func (c *RegistryClientConfig) Option(opts ...RegistryClientOption) {
+	for _, opt := range opts {
+		opt.ConfigureRegistryClient(c)
+	}
+}
+
+func (c *RegistryClientConfig) Default() {
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{}
+	}
+}
+
+type RegistryClientOption interface {
+	ConfigureRegistryClient(*RegistryClientConfig)
+}
+
+// manifestMediaTypes are accepted when fetching manifests.
+var manifestMediaTypes = []string{
+	MediaTypeOCIManifest,
+	MediaTypeOCIIndex,
+	MediaTypeDockerManifest,
+	MediaTypeDockerList,
+}
+
+// RemoteManifest is a manifest, or manifest list,
+// fetched from a registry.
+type RemoteManifest struct {
+	Descriptor Descriptor
+	Manifest   Manifest
+	// Raw holds the manifest exactly as served.
+	Raw []byte
+}
+
+// ListTags returns all tags of the given repository,
+// e.g. "quay.io/org/app", following paginated responses.
+func (c *RegistryClient) ListTags(ctx context.Context, repository string) ([]string, error) {
+	ref, err := ParseReference(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+
+	next := c.url(ref, "/tags/list")
+
+	for next != "" {
+		resp, err := c.do(ctx, ref, "pull", func() (*http.Request, error) {
+			return http.NewRequestWithContext(ctx, http.MethodGet, next, nil)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing tags of %q: %w", repository, err)
+		}
+
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("decoding tags of %q: %w", repository, err)
+		}
+
+		res = append(res, page.Tags...)
+
+		if next, err = nextLink(resp); err != nil {
+			return nil, fmt.Errorf("listing tags of %q: %w", repository, err)
+		}
+	}
+
+	return res, nil
+}
+
+// nextLink returns the absolute URL of the next page
+// given by a response's Link header, if any.
+func nextLink(resp *http.Response) (string, error) {
+	link := resp.Header.Get("Link")
+	if link == "" {
+		return "", nil
+	}
+
+	target, params, _ := strings.Cut(link, ";")
+	if !strings.Contains(params, `rel="next"`) {
+		return "", nil
+	}
+
+	next, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+	if err != nil {
+		return "", fmt.Errorf("parsing link %q: %w", link, err)
+	}
+
+	return resp.Request.URL.ResolveReference(next).String(), nil
+}
+
+// Manifest fetches the manifest, or manifest list, referenced
+// by ref. References without a tag or digest use "latest".
+// The content of digest references is verified.
+func (c *RegistryClient) Manifest(ctx context.Context, ref string) (*RemoteManifest, error) {
+	parsed, err := ParseReference(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.manifestRequest(ctx, parsed, http.MethodGet)
+	if err != nil {
+		return nil, fmt.Errorf("fetching manifest %q: %w", ref, err)
+	}
+
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest %q: %w", ref, err)
+	}
+
+	digest := digestOf(raw)
+	if parsed.Digest != "" && parsed.Digest != digest {
+		return nil, fmt.Errorf("manifest %q has digest %q", ref, digest)
+	}
+
+	res := &RemoteManifest{
+		Descriptor: Descriptor{
+			MediaType: resp.Header.Get("Content-Type"),
+			Digest:    digest,
+			Size:      int64(len(raw)),
+		},
+		Raw: raw,
+	}
+
+	if err := json.Unmarshal(raw, &res.Manifest); err != nil {
+		return nil, fmt.Errorf("decoding manifest %q: %w", ref, err)
+	}
+
+	if res.Descriptor.MediaType == "" {
+		res.Descriptor.MediaType = res.Manifest.MediaType
+	}
+
+	return res, nil
+}
+
+// ResolveDigest returns the digest of the manifest referenced by ref.
+func (c *RegistryClient) ResolveDigest(ctx context.Context, ref string) (string, error) {
+	parsed, err := ParseReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.manifestRequest(ctx, parsed, http.MethodHead)
+	if err != nil {
+		return "", fmt.Errorf("resolving %q: %w", ref, err)
+	}
+
+	resp.Body.Close()
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	// the header is optional so fall back to hashing the manifest
+	m, err := c.Manifest(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	return m.Descriptor.Digest, nil
+}
+
+// ImageExists reports whether the manifest referenced by ref exists.
+// It satisfies ImageChecker allowing "BuildCached" to skip builds
+// of images already present in a registry.
+func (c *RegistryClient) ImageExists(ctx context.Context, ref string) (bool, error) {
+	_, err := c.ResolveDigest(ctx, ref)
+
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, ErrNotFound):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func (c *RegistryClient) manifestRequest(ctx context.Context, ref Reference, method string) (*http.Response, error) {
+	target := ref.Digest
+	if target == "" {
+		target = ref.Tag
+	}
+
+	if target == "" {
+		target = "latest"
+	}
+
+	return c.do(ctx, ref, "pull", func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, c.url(ref, "/manifests/"+target), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+
+		return req, nil
+	})
+}
+
+// FetchBlob returns the contents of the blob with the given
+// digest from repository. The caller must close the result.
+func (c *RegistryClient) FetchBlob(ctx context.Context, repository, digest string) (io.ReadCloser, int64, error) {
+	ref, err := ParseReference(repository)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := c.do(ctx, ref, "pull", func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, c.url(ref, "/blobs/"+digest), nil)
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("fetching blob %q from %q: %w", digest, repository, err)
+	}
+
+	return resp.Body, resp.ContentLength, nil
+}
+
+// CopyBlob copies the blob with the given digest from the src
+// repository to the dst repository. Nothing is transferred if the
+// blob exists in dst and blobs are mounted rather than transferred
+// when both repositories share a registry which supports mounting.
+func (c *RegistryClient) CopyBlob(ctx context.Context, src, dst, digest string) error {
+	srcRef, err := ParseReference(src)
+	if err != nil {
+		return err
+	}
+
+	dstRef, err := ParseReference(dst)
+	if err != nil {
+		return err
+	}
+
+	if err := c.copyBlob(ctx, srcRef, dstRef, digest); err != nil {
+		return fmt.Errorf("copying blob %q from %q to %q: %w", digest, src, dst, err)
+	}
+
+	return nil
+}
+
+func (c *RegistryClient) copyBlob(ctx context.Context, src, dst Reference, digest string) error {
+	resp, err := c.do(ctx, dst, "pull,push", func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodHead, c.url(dst, "/blobs/"+digest), nil)
+	})
+	if err == nil {
+		resp.Body.Close()
+
+		return nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	start := c.url(dst, "/blobs/uploads/")
+	if src.Registry == dst.Registry {
+		start += "?" + url.Values{"mount": {digest}, "from": {src.Repository}}.Encode()
+	}
+
+	resp, err = c.do(ctx, dst, "pull,push", func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodPost, start, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("starting upload: %w", err)
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusCreated {
+		return nil
+	}
+
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return fmt.Errorf("parsing upload location: %w", err)
+	}
+
+	query := location.Query()
+	query.Set("digest", digest)
+	location.RawQuery = query.Encode()
+
+	var blob io.ReadCloser
+
+	defer func() {
+		if blob != nil {
+			blob.Close()
+		}
+	}()
+
+	// the blob is fetched for each attempt as the streamed body
+	// of a request rejected for its credentials cannot be replayed
+	resp, err = c.do(ctx, dst, "pull,push", func() (*http.Request, error) {
+		if blob != nil {
+			blob.Close()
+		}
+
+		var (
+			size int64
+			err  error
+		)
+
+		if blob, size, err = c.FetchBlob(ctx, src.Name(), digest); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, location.String(), blob)
+		if err != nil {
+			return nil, err
+		}
+
+		req.ContentLength = size
+		req.Header.Set("Content-Type", "application/octet-stream")
+
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("uploading: %w", err)
+	}
+
+	resp.Body.Close()
+
+	return nil
+}
+
+func (c *RegistryClient) url(ref Reference, path string) string {
+	scheme := "https"
+	if c.cfg.Insecure {
+		scheme = "http"
+	}
+
+	host := ref.Registry
+	if host == DockerHub {
+		host = "registry-1.docker.io"
+	}
+
+	return scheme + "://" + host + "/v2/" + ref.Repository + path
+}
+
+// do sends the request built by newReq authenticating when challenged
+// for the given repository actions, e.g. "pull". Responses outside the
+// 2xx range are returned as *RegistryError.
+func (c *RegistryClient) do(ctx context.Context, ref Reference, actions string, newReq func() (*http.Request, error)) (*http.Response, error) {
+	key := ref.Registry + " repository:" + ref.Repository + ":" + actions
+
+	for attempt := 0; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return nil, fmt.Errorf("constructing request: %w", err)
+		}
+
+		c.mu.Lock()
+		auth, ok := c.auths[key]
+		c.mu.Unlock()
+
+		if ok {
+			req.Header.Set("Authorization", auth)
+		}
+
+		resp, err := c.cfg.HTTPClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("sending request: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+
+			auth, err := c.authenticate(ctx, ref, challenge)
+			if err != nil {
+				return nil, err
+			}
+
+			c.mu.Lock()
+			c.auths[key] = auth
+			c.mu.Unlock()
+
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			defer resp.Body.Close()
+
+			return nil, registryError(resp)
+		}
+
+		return resp, nil
+	}
+}
+
+func registryError(resp *http.Response) error {
+	res := &RegistryError{StatusCode: resp.StatusCode}
+
+	var body struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err == nil && len(body.Errors) > 0 {
+		res.Code, res.Message = body.Errors[0].Code, body.Errors[0].Message
+	}
+
+	return res
+}
+
+// authenticate answers a WWW-Authenticate challenge returning
+// the value of the Authorization header to retry with.
+func (c *RegistryClient) authenticate(ctx context.Context, ref Reference, challenge string) (string, error) {
+	scheme, params := parseChallenge(challenge)
+
+	creds, err := c.credentials(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if creds.Username == "" {
+			return "", fmt.Errorf("registry %q requires credentials: %w", ref.Registry, ErrCredentialsNotFound)
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(creds.Username, creds.Password)
+
+		return req.Header.Get("Authorization"), nil
+	case "bearer":
+		token, err := c.fetchToken(ctx, params, creds)
+		if err != nil {
+			return "", fmt.Errorf("fetching token for %q: %w", ref.Registry, err)
+		}
+
+		return "Bearer " + token, nil
+	default:
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+}
+
+func (c *RegistryClient) credentials(ctx context.Context, ref Reference) (Credentials, error) {
+	if c.cfg.Credentials == nil {
+		return Credentials{}, nil
+	}
+
+	creds, err := c.cfg.Credentials.Resolve(ctx, ref.Name())
+	if errors.Is(err, ErrCredentialsNotFound) {
+		return Credentials{}, nil
+	} else if err != nil {
+		return Credentials{}, fmt.Errorf("resolving credentials: %w", err)
+	}
+
+	return creds, nil
+}
+
+// fetchToken obtains a bearer token from the challenge's realm
+// using an OAuth2 refresh token when an identity token is
+// available and otherwise basic authentication, if any.
+func (c *RegistryClient) fetchToken(ctx context.Context, params map[string][]string, creds Credentials) (string, error) {
+	realm := first(params["realm"])
+	if realm == "" {
+		return "", errors.New("challenge has no realm")
+	}
+
+	query := url.Values{}
+
+	if service := first(params["service"]); service != "" {
+		query.Set("service", service)
+	}
+
+	for _, scope := range params["scope"] {
+		query.Add("scope", scope)
+	}
+
+	var (
+		req *http.Request
+		err error
+	)
+
+	if creds.IdentityToken != "" {
+		query.Set("grant_type", "refresh_token")
+		query.Set("refresh_token", creds.IdentityToken)
+		query.Set("client_id", "go-ci")
+
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, realm, strings.NewReader(query.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+query.Encode(), nil)
+		if err == nil && creds.Username != "" {
+			req.SetBasicAuth(creds.Username, creds.Password)
+		}
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("constructing request: %w", err)
+	}
+
+	resp, err := c.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sending request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", registryError(resp)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding token: %w", err)
+	}
+
+	if body.Token != "" {
+		return body.Token, nil
+	}
+
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+
+	return "", errors.New("token response contained no token")
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// parseChallenge parses a WWW-Authenticate header such as
+// `Bearer realm="https://auth.example.com/token",scope="a:b:pull,push"`
+// where values may be quoted and contain commas.
+func parseChallenge(header string) (string, map[string][]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := make(map[string][]string)
+
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, after, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		var value string
+
+		if strings.HasPrefix(after, `"`) {
+			end := strings.Index(after[1:], `"`)
+			if end < 0 {
+				value, rest = after[1:], ""
+			} else {
+				value, rest = after[1:end+1], after[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(after, ",")
+		}
+
+		params[key] = append(params[key], value)
+		rest = strings.TrimLeft(strings.TrimSpace(rest), ",")
+		rest = strings.TrimSpace(rest)
+	}
+
+	return scheme, params
+}
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+var (
+	_ ImageChecker       = (*RegistryClient)(nil)
+	_ CredentialResolver = (*AuthFile)(nil)
+)
@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mt-sre/go-ci/container"
+	"github.com/mt-sre/go-ci/container/registrytest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRegistryClient(srv *registrytest.Server, username, password string) *container.RegistryClient {
+	var auth container.AuthFile
+
+	if username != "" {
+		auth.Set(srv.Host(), container.Credentials{Username: username, Password: password})
+	}
+
+	return container.NewRegistryClient(
+		container.WithCredentials{CredentialResolver: &auth},
+		container.WithInsecure(true),
+	)
+}
+
+func TestRegistryClient(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Options []registrytest.Option
+	}{
+		"anonymous": {},
+		"basic auth": {
+			Options: []registrytest.Option{registrytest.WithBasicAuth{Username: "user", Password: "pass"}},
+		},
+		"token auth": {
+			Options: []registrytest.Option{registrytest.WithTokenAuth{Username: "user", Password: "pass"}},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := registrytest.NewServer(tc.Options...)
+			t.Cleanup(srv.Close)
+
+			desc := srv.Registry.PushImage("org/app", "v1", []byte("layer"))
+			srv.Registry.PushImage("org/app", "v2")
+
+			client := newRegistryClient(srv, "user", "pass")
+			ctx := context.Background()
+
+			tags, err := client.ListTags(ctx, srv.Host()+"/org/app")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"v1", "v2"}, tags)
+
+			digest, err := client.ResolveDigest(ctx, srv.Host()+"/org/app:v1")
+			require.NoError(t, err)
+			assert.Equal(t, desc.Digest, digest)
+
+			m, err := client.Manifest(ctx, srv.Host()+"/org/app@"+desc.Digest)
+			require.NoError(t, err)
+			assert.Equal(t, desc, m.Descriptor)
+			require.Len(t, m.Manifest.Layers, 1)
+
+			blob, size, err := client.FetchBlob(ctx, srv.Host()+"/org/app", m.Manifest.Layers[0].Digest)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = blob.Close() })
+
+			data, err := io.ReadAll(blob)
+			require.NoError(t, err)
+			assert.Equal(t, []byte("layer"), data)
+			assert.Equal(t, int64(5), size)
+
+			exists, err := client.ImageExists(ctx, srv.Host()+"/org/app:v3")
+			require.NoError(t, err)
+			assert.False(t, exists)
+
+			_, err = client.Manifest(ctx, srv.Host()+"/org/app:v3")
+			assert.ErrorIs(t, err, container.ErrNotFound)
+
+			require.NoError(t, client.CopyBlob(ctx, srv.Host()+"/org/app", srv.Host()+"/org/mirror", m.Manifest.Layers[0].Digest))
+
+			_, ok := srv.Registry.Blob("org/mirror", m.Manifest.Layers[0].Digest)
+			assert.True(t, ok, "blob is mounted")
+		})
+	}
+}
+
+func TestRegistryClientDenied(t *testing.T) {
+	t.Parallel()
+
+	srv := registrytest.NewServer(registrytest.WithTokenAuth{Username: "user", Password: "pass"})
+	t.Cleanup(srv.Close)
+
+	srv.Registry.PushImage("org/app", "v1")
+
+	_, err := newRegistryClient(srv, "user", "wrong").ListTags(context.Background(), srv.Host()+"/org/app")
+
+	var regErr *container.RegistryError
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, "DENIED", regErr.Code)
+}
+
+func TestRegistryClientManifestList(t *testing.T) {
+	t.Parallel()
+
+	srv := registrytest.NewServer()
+	t.Cleanup(srv.Close)
+
+	amd64 := srv.Registry.PushImage("org/app", "amd64")
+	amd64.Platform = &container.Platform{OS: "linux", Architecture: "amd64"}
+
+	index, err := json.Marshal(container.Manifest{
+		SchemaVersion: 2,
+		MediaType:     container.MediaTypeOCIIndex,
+		Manifests:     []container.Descriptor{amd64},
+	})
+	require.NoError(t, err)
+
+	srv.Registry.PutManifest("org/app", "v1", container.MediaTypeOCIIndex, index)
+
+	m, err := newRegistryClient(srv, "", "").Manifest(context.Background(), srv.Host()+"/org/app:v1")
+	require.NoError(t, err)
+
+	assert.True(t, m.Manifest.IsIndex())
+	assert.Equal(t, container.MediaTypeOCIIndex, m.Descriptor.MediaType)
+	assert.Equal(t, index, m.Raw)
+	assert.Equal(t, []container.Descriptor{amd64}, m.Manifest.Manifests)
+}
+
+func TestRegistryClientCopyBlobBetweenRegistries(t *testing.T) {
+	t.Parallel()
+
+	src := registrytest.NewServer()
+	t.Cleanup(src.Close)
+
+	dst := registrytest.NewServer(registrytest.WithTokenAuth{Username: "user", Password: "pass"})
+	t.Cleanup(dst.Close)
+
+	data := []byte("shared layer")
+	digest := src.Registry.PutBlob("org/app", data)
+
+	var auth container.AuthFile
+
+	auth.Set(dst.Host(), container.Credentials{Username: "user", Password: "pass"})
+
+	client := container.NewRegistryClient(
+		container.WithCredentials{CredentialResolver: &auth},
+		container.WithInsecure(true),
+	)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, client.CopyBlob(context.Background(), src.Host()+"/org/app", dst.Host()+"/mirror/app", digest))
+	}
+
+	got, ok := dst.Registry.Blob("mirror/app", digest)
+	require.True(t, ok)
+	assert.Equal(t, data, got)
+}
+
+func TestRegistryClientCopyBlobReauthenticates(t *testing.T) {
+	t.Parallel()
+
+	src := registrytest.NewServer()
+	t.Cleanup(src.Close)
+
+	data := []byte("shared layer")
+	digest := src.Registry.PutBlob("org/app", data)
+
+	reg := registrytest.New(registrytest.WithTokenAuth{Username: "user", Password: "pass"})
+
+	var expired atomic.Bool
+
+	// the token used to start the upload is rejected when completing it
+	dst := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPut && expired.CompareAndSwap(false, true) {
+			req.Header.Del("Authorization")
+		}
+
+		reg.ServeHTTP(w, req)
+	}))
+	t.Cleanup(dst.Close)
+
+	host := strings.TrimPrefix(dst.URL, "http://")
+
+	var auth container.AuthFile
+
+	auth.Set(host, container.Credentials{Username: "user", Password: "pass"})
+
+	client := container.NewRegistryClient(
+		container.WithCredentials{CredentialResolver: &auth},
+		container.WithInsecure(true),
+	)
+
+	require.NoError(t, client.CopyBlob(context.Background(), src.Host()+"/org/app", host+"/mirror/app", digest))
+	assert.True(t, expired.Load())
+
+	got, ok := reg.Blob("mirror/app", digest)
+	require.True(t, ok)
+	assert.Equal(t, data, got)
+}
@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package registrytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const tokenService = "registrytest"
+
+// authorized reports whether req may proceed writing
+// an authentication challenge to w when it may not.
+func (r *Registry) authorized(w http.ResponseWriter, req *http.Request) bool {
+	switch r.cfg.Auth {
+	case AuthBasic:
+		if user, pass, ok := req.BasicAuth(); ok && user == r.cfg.Username && pass == r.cfg.Password {
+			return true
+		}
+
+		w.Header().Set("WWW-Authenticate", `Basic realm="registrytest"`)
+		writeError(w, http.StatusUnauthorized, codeUnauthorized, "authentication required")
+
+		return false
+	case AuthToken:
+		scopes := requiredScopes(req)
+
+		r.mu.Lock()
+		granted, ok := r.tokens[strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")]
+		r.mu.Unlock()
+
+		missing := !ok
+
+		for _, scope := range scopes {
+			for _, action := range scope.actions {
+				if _, ok := granted[scope.resource+":"+action]; !ok {
+					missing = true
+				}
+			}
+		}
+
+		if !missing {
+			return true
+		}
+
+		challenge := fmt.Sprintf(`Bearer realm="http://%s/token",service="%s"`, req.Host, tokenService)
+		for _, scope := range scopes {
+			challenge += fmt.Sprintf(`,scope="%s:%s"`, scope.resource, strings.Join(scope.actions, ","))
+		}
+
+		w.Header().Set("WWW-Authenticate", challenge)
+		writeError(w, http.StatusUnauthorized, codeUnauthorized, "authentication required")
+
+		return false
+	default:
+		return true
+	}
+}
+
+type scope struct {
+	// resource is e.g. "repository:org/app"
+	resource string
+	actions  []string
+}
+
+// requiredScopes returns the scopes needed to serve req.
+func requiredScopes(req *http.Request) []scope {
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+
+	if path == "_catalog" {
+		return []scope{{resource: "registry:catalog", actions: []string{"*"}}}
+	}
+
+	var repo string
+
+	for _, marker := range []string{"/blobs/", "/manifests/", "/tags/list"} {
+		if i := strings.LastIndex(path, marker); i > 0 {
+			repo = path[:i]
+
+			break
+		}
+	}
+
+	if repo == "" {
+		return nil
+	}
+
+	actions := []string{"pull"}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		actions = append(actions, "push")
+	}
+
+	res := []scope{{resource: "repository:" + repo, actions: actions}}
+
+	if from := req.URL.Query().Get("from"); from != "" {
+		res = append(res, scope{resource: "repository:" + from, actions: []string{"pull"}})
+	}
+
+	return res
+}
+
+// serveToken issues tokens granting every requested
+// scope to clients presenting valid credentials.
+func (r *Registry) serveToken(w http.ResponseWriter, req *http.Request) {
+	if user, pass, ok := req.BasicAuth(); !ok || user != r.cfg.Username || pass != r.cfg.Password {
+		writeError(w, http.StatusUnauthorized, codeDenied, "invalid credentials")
+
+		return
+	}
+
+	granted := make(map[string]struct{})
+
+	for _, s := range req.URL.Query()["scope"] {
+		i := strings.LastIndex(s, ":")
+		if i < 0 {
+			continue
+		}
+
+		for _, action := range strings.Split(s[i+1:], ",") {
+			granted[s[:i]+":"+action] = struct{}{}
+		}
+	}
+
+	token := newID()
+
+	r.mu.Lock()
+	r.tokens[token] = granted
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"token":        token,
+		"access_token": token,
+		"expires_in":   300,
+	})
+}
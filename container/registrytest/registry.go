@@ -0,0 +1,749 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package registrytest provides an in-memory registry implementing
+// the OCI distribution specification for use in tests.
+package registrytest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mt-sre/go-ci/container"
+)
+
+// NewServer starts a Registry on an httptest.Server. Images
+// pushed to it are referenced as "<Host>/<repository>:<tag>".
+// The caller must call Close when finished.
+func NewServer(opts ...Option) *Server {
+	reg := New(opts...)
+
+	srv := httptest.NewServer(reg)
+
+	return &Server{
+		Server:   srv,
+		Registry: reg,
+	}
+}
+
+// Server is a Registry served over HTTP.
+type Server struct {
+	*httptest.Server
+	Registry *Registry
+}
+
+// Host returns the "host:port" address of the registry.
+func (s *Server) Host() string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+// New returns an empty Registry.
+func New(opts ...Option) *Registry {
+	var cfg Config
+
+	cfg.Option(opts...)
+
+	return &Registry{
+		cfg:     cfg,
+		repos:   make(map[string]*repository),
+		blobs:   make(map[string][]byte),
+		uploads: make(map[string]*upload),
+		tokens:  make(map[string]map[string]struct{}),
+	}
+}
+
+type Config struct {
+	Auth     AuthMode
+	Username string
+	Password string
+}
+
+func (c *Config) Option(opts ...Option) {
+	for _, opt := range opts {
+		opt.ConfigureRegistry(c)
+	}
+}
+
+type Option interface {
+	ConfigureRegistry(*Config)
+}
+
+// AuthMode selects how clients authenticate.
+type AuthMode string
+
+const (
+	// AuthNone permits anonymous access.
+	AuthNone AuthMode = ""
+	// AuthBasic requires HTTP basic authentication.
+	AuthBasic AuthMode = "basic"
+	// AuthToken requires bearer tokens issued by the
+	// registry's "/token" endpoint for each repository
+	// and action following the distribution token spec.
+	AuthToken AuthMode = "token"
+)
+
+// WithBasicAuth requires clients to authenticate
+// with the given username and password.
+type WithBasicAuth struct {
+	Username string
+	Password string
+}
+
+func (w WithBasicAuth) ConfigureRegistry(c *Config) {
+	c.Auth, c.Username, c.Password = AuthBasic, w.Username, w.Password
+}
+
+// WithTokenAuth requires clients to present bearer tokens
+// obtained using the given username and password.
+type WithTokenAuth struct {
+	Username string
+	Password string
+}
+
+func (w WithTokenAuth) ConfigureRegistry(c *Config) {
+	c.Auth, c.Username, c.Password = AuthToken, w.Username, w.Password
+}
+
+// Registry is an in-memory http.Handler implementing the OCI
+// distribution specification's pull, push, content discovery
+// and content management endpoints. Blob contents are shared
+// between repositories while each repository tracks the blobs
+// pushed or mounted to it.
+type Registry struct {
+	cfg     Config
+	mu      sync.Mutex
+	repos   map[string]*repository
+	blobs   map[string][]byte
+	uploads map[string]*upload
+	// tokens maps issued tokens to their granted scopes
+	tokens map[string]map[string]struct{}
+}
+
+type repository struct {
+	blobs     map[string]struct{}
+	manifests map[string]manifest
+	tags      map[string]string
+}
+
+type manifest struct {
+	mediaType string
+	data      []byte
+}
+
+type upload struct {
+	repo string
+	buf  bytes.Buffer
+}
+
+func (r *Registry) repo(name string) *repository {
+	repo, ok := r.repos[name]
+	if !ok {
+		repo = &repository{
+			blobs:     make(map[string]struct{}),
+			manifests: make(map[string]manifest),
+			tags:      make(map[string]string),
+		}
+
+		r.repos[name] = repo
+	}
+
+	return repo
+}
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// PutBlob stores data in the given repository.
+func (r *Registry) PutBlob(repo string, data []byte) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.putBlob(repo, data)
+}
+
+func (r *Registry) putBlob(repo string, data []byte) string {
+	digest := digestOf(data)
+
+	r.blobs[digest] = append([]byte(nil), data...)
+	r.repo(repo).blobs[digest] = struct{}{}
+
+	return digest
+}
+
+// Blob returns the blob with the given digest in repo.
+func (r *Registry) Blob(repo, digest string) ([]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.blob(repo, digest)
+}
+
+func (r *Registry) blob(repo, digest string) ([]byte, bool) {
+	if rep, ok := r.repos[repo]; !ok {
+		return nil, false
+	} else if _, ok := rep.blobs[digest]; !ok {
+		return nil, false
+	}
+
+	return r.blobs[digest], true
+}
+
+// PutManifest stores a manifest in repo under its digest and,
+// unless ref is empty or a digest, tags it as ref.
+func (r *Registry) PutManifest(repo, ref, mediaType string, data []byte) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.putManifest(repo, ref, mediaType, data)
+}
+
+func (r *Registry) putManifest(repo, ref, mediaType string, data []byte) string {
+	digest := digestOf(data)
+	rep := r.repo(repo)
+
+	rep.manifests[digest] = manifest{mediaType: mediaType, data: append([]byte(nil), data...)}
+
+	if ref != "" && !strings.Contains(ref, ":") {
+		rep.tags[ref] = digest
+	}
+
+	return digest
+}
+
+// Manifest returns the media type and contents of the manifest
+// referenced by tag or digest in repo.
+func (r *Registry) Manifest(repo, ref string) (string, []byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.manifest(repo, ref)
+
+	return m.mediaType, m.data, ok
+}
+
+func (r *Registry) manifest(repo, ref string) (manifest, bool) {
+	rep, ok := r.repos[repo]
+	if !ok {
+		return manifest{}, false
+	}
+
+	if digest, ok := rep.tags[ref]; ok {
+		ref = digest
+	}
+
+	m, ok := rep.manifests[ref]
+
+	return m, ok
+}
+
+// Tags returns the sorted tags of repo.
+func (r *Registry) Tags(repo string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.tags(repo)
+}
+
+func (r *Registry) tags(repo string) []string {
+	rep, ok := r.repos[repo]
+	if !ok {
+		return nil
+	}
+
+	res := make([]string, 0, len(rep.tags))
+	for tag := range rep.tags {
+		res = append(res, tag)
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+// PushImage stores a single platform OCI image with the given
+// layers and a minimal config tagged as tag in repo. The
+// manifest's descriptor is returned.
+func (r *Registry) PushImage(repo, tag string, layers ...[]byte) container.Descriptor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	config := []byte(`{"architecture":"amd64","os":"linux","rootfs":{"type":"layers"}}`)
+
+	m := container.Manifest{
+		SchemaVersion: 2,
+		MediaType:     container.MediaTypeOCIManifest,
+		Config: &container.Descriptor{
+			MediaType: container.MediaTypeOCIConfig,
+			Digest:    r.putBlob(repo, config),
+			Size:      int64(len(config)),
+		},
+		Layers: []container.Descriptor{},
+	}
+
+	for _, layer := range layers {
+		m.Layers = append(m.Layers, container.Descriptor{
+			MediaType: container.MediaTypeOCILayer,
+			Digest:    r.putBlob(repo, layer),
+			Size:      int64(len(layer)),
+		})
+	}
+
+	data, _ := json.Marshal(m)
+
+	return container.Descriptor{
+		MediaType: container.MediaTypeOCIManifest,
+		Digest:    r.putManifest(repo, tag, container.MediaTypeOCIManifest, data),
+		Size:      int64(len(data)),
+	}
+}
+
+// Error codes defined by the distribution specification.
+const (
+	codeBlobUnknown         = "BLOB_UNKNOWN"
+	codeBlobUploadUnknown   = "BLOB_UPLOAD_UNKNOWN"
+	codeBlobUploadInvalid   = "BLOB_UPLOAD_INVALID"
+	codeDenied              = "DENIED"
+	codeDigestInvalid       = "DIGEST_INVALID"
+	codeManifestBlobUnknown = "MANIFEST_BLOB_UNKNOWN"
+	codeManifestInvalid     = "MANIFEST_INVALID"
+	codeManifestUnknown     = "MANIFEST_UNKNOWN"
+	codeNameUnknown         = "NAME_UNKNOWN"
+	codeUnauthorized        = "UNAUTHORIZED"
+	codeUnsupported         = "UNSUPPORTED"
+)
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"errors": []map[string]string{{"code": code, "message": message}},
+	})
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+
+	path := req.URL.Path
+
+	if path == "/token" && r.cfg.Auth == AuthToken {
+		r.serveToken(w, req)
+
+		return
+	}
+
+	if !r.authorized(w, req) {
+		return
+	}
+
+	if path == "/v2/" || path == "/v2" {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, "{}")
+
+		return
+	}
+
+	if path == "/v2/_catalog" {
+		r.serveCatalog(w, req)
+
+		return
+	}
+
+	path = strings.TrimPrefix(path, "/v2/")
+
+	if strings.HasSuffix(path, "/blobs/uploads") {
+		path += "/"
+	}
+
+	for _, route := range []struct {
+		marker string
+		serve  func(http.ResponseWriter, *http.Request, string, string)
+	}{
+		{"/blobs/uploads/", r.serveUpload},
+		{"/blobs/", r.serveBlob},
+		{"/manifests/", r.serveManifest},
+		{"/tags/list", r.serveTags},
+	} {
+		if i := strings.LastIndex(path, route.marker); i > 0 {
+			route.serve(w, req, path[:i], path[i+len(route.marker):])
+
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, codeUnsupported, "unknown endpoint")
+}
+
+func (r *Registry) serveBlob(w http.ResponseWriter, req *http.Request, repo, digest string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, ok := r.blob(repo, digest)
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		if !ok {
+			writeError(w, http.StatusNotFound, codeBlobUnknown, "blob unknown to registry")
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Docker-Content-Digest", digest)
+
+		if req.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	case http.MethodDelete:
+		if !ok {
+			writeError(w, http.StatusNotFound, codeBlobUnknown, "blob unknown to registry")
+
+			return
+		}
+
+		delete(r.repos[repo].blobs, digest)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *Registry) serveUpload(w http.ResponseWriter, req *http.Request, repo, id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id == "" {
+		if req.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		r.startUpload(w, req, repo)
+
+		return
+	}
+
+	up, ok := r.uploads[id]
+	if !ok || up.repo != repo {
+		writeError(w, http.StatusNotFound, codeBlobUploadUnknown, "blob upload unknown to registry")
+
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		writeUploadStatus(w, http.StatusNoContent, repo, id, up.buf.Len())
+	case http.MethodPatch:
+		if start, ok := contentRangeStart(req); ok && start != up.buf.Len() {
+			writeUploadStatus(w, http.StatusRequestedRangeNotSatisfiable, repo, id, up.buf.Len())
+
+			return
+		}
+
+		if _, err := io.Copy(&up.buf, req.Body); err != nil {
+			writeError(w, http.StatusBadRequest, codeBlobUploadInvalid, err.Error())
+
+			return
+		}
+
+		writeUploadStatus(w, http.StatusAccepted, repo, id, up.buf.Len())
+	case http.MethodPut:
+		if _, err := io.Copy(&up.buf, req.Body); err != nil {
+			writeError(w, http.StatusBadRequest, codeBlobUploadInvalid, err.Error())
+
+			return
+		}
+
+		if r.completeUpload(w, repo, req.URL.Query().Get("digest"), up.buf.Bytes()) {
+			delete(r.uploads, id)
+		}
+	case http.MethodDelete:
+		delete(r.uploads, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *Registry) startUpload(w http.ResponseWriter, req *http.Request, repo string) {
+	query := req.URL.Query()
+
+	if mount, from := query.Get("mount"), query.Get("from"); mount != "" && from != "" {
+		if _, ok := r.blob(from, mount); ok {
+			r.repo(repo).blobs[mount] = struct{}{}
+
+			w.Header().Set("Location", "/v2/"+repo+"/blobs/"+mount)
+			w.Header().Set("Docker-Content-Digest", mount)
+			w.WriteHeader(http.StatusCreated)
+
+			return
+		}
+	}
+
+	// monolithic uploads complete in a single request
+	if digest := query.Get("digest"); digest != "" {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeBlobUploadInvalid, err.Error())
+
+			return
+		}
+
+		r.completeUpload(w, repo, digest, data)
+
+		return
+	}
+
+	id := newID()
+	r.uploads[id] = &upload{repo: repo}
+
+	writeUploadStatus(w, http.StatusAccepted, repo, id, 0)
+}
+
+func (r *Registry) completeUpload(w http.ResponseWriter, repo, digest string, data []byte) bool {
+	if digest == "" || digest != digestOf(data) {
+		writeError(w, http.StatusBadRequest, codeDigestInvalid, "provided digest did not match uploaded content")
+
+		return false
+	}
+
+	r.putBlob(repo, data)
+
+	w.Header().Set("Location", "/v2/"+repo+"/blobs/"+digest)
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.WriteHeader(http.StatusCreated)
+
+	return true
+}
+
+func writeUploadStatus(w http.ResponseWriter, status int, repo, id string, size int) {
+	w.Header().Set("Location", "/v2/"+repo+"/blobs/uploads/"+id)
+	w.Header().Set("Docker-Upload-UUID", id)
+	w.Header().Set("Range", fmt.Sprintf("0-%d", max(size-1, 0)))
+	w.WriteHeader(status)
+}
+
+// contentRangeStart returns the start of a "start-end"
+// Content-Range header when present.
+func contentRangeStart(req *http.Request) (int, bool) {
+	start, _, ok := strings.Cut(req.Header.Get("Content-Range"), "-")
+	if !ok {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(start)
+
+	return n, err == nil
+}
+
+func newID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+
+	return hex.EncodeToString(buf)
+}
+
+func (r *Registry) serveManifest(w http.ResponseWriter, req *http.Request, repo, ref string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		m, ok := r.manifest(repo, ref)
+		if !ok {
+			writeError(w, http.StatusNotFound, codeManifestUnknown, "manifest unknown to registry")
+
+			return
+		}
+
+		w.Header().Set("Content-Type", m.mediaType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(m.data)))
+		w.Header().Set("Docker-Content-Digest", digestOf(m.data))
+
+		if req.Method == http.MethodGet {
+			_, _ = w.Write(m.data)
+		}
+	case http.MethodPut:
+		r.putManifestRequest(w, req, repo, ref)
+	case http.MethodDelete:
+		rep, ok := r.repos[repo]
+		if !ok {
+			writeError(w, http.StatusNotFound, codeManifestUnknown, "manifest unknown to registry")
+
+			return
+		}
+
+		if _, ok := rep.tags[ref]; ok {
+			delete(rep.tags, ref)
+		} else if _, ok := rep.manifests[ref]; ok {
+			delete(rep.manifests, ref)
+
+			for tag, digest := range rep.tags {
+				if digest == ref {
+					delete(rep.tags, tag)
+				}
+			}
+		} else {
+			writeError(w, http.StatusNotFound, codeManifestUnknown, "manifest unknown to registry")
+
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *Registry) putManifestRequest(w http.ResponseWriter, req *http.Request, repo, ref string) {
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeManifestInvalid, err.Error())
+
+		return
+	}
+
+	var m container.Manifest
+
+	if err := json.Unmarshal(data, &m); err != nil {
+		writeError(w, http.StatusBadRequest, codeManifestInvalid, err.Error())
+
+		return
+	}
+
+	if strings.Contains(ref, ":") && ref != digestOf(data) {
+		writeError(w, http.StatusBadRequest, codeDigestInvalid, "manifest digest did not match reference")
+
+		return
+	}
+
+	// manifests may only reference content already pushed
+	for _, desc := range m.Manifests {
+		if _, ok := r.manifest(repo, desc.Digest); !ok {
+			writeError(w, http.StatusBadRequest, codeManifestBlobUnknown, "unknown manifest "+desc.Digest)
+
+			return
+		}
+	}
+
+	blobs := m.Layers
+	if m.Config != nil {
+		blobs = append([]container.Descriptor{*m.Config}, blobs...)
+	}
+
+	for _, desc := range blobs {
+		if _, ok := r.blob(repo, desc.Digest); !ok {
+			writeError(w, http.StatusBadRequest, codeManifestBlobUnknown, "unknown blob "+desc.Digest)
+
+			return
+		}
+	}
+
+	mediaType := req.Header.Get("Content-Type")
+	if mediaType == "" {
+		mediaType = m.MediaType
+	}
+
+	digest := r.putManifest(repo, ref, mediaType, data)
+
+	w.Header().Set("Location", "/v2/"+repo+"/manifests/"+digest)
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (r *Registry) serveTags(w http.ResponseWriter, req *http.Request, repo, rest string) {
+	if rest != "" || req.Method != http.MethodGet {
+		writeError(w, http.StatusNotFound, codeUnsupported, "unknown endpoint")
+
+		return
+	}
+
+	r.mu.Lock()
+
+	_, known := r.repos[repo]
+	tags := r.tags(repo)
+
+	r.mu.Unlock()
+
+	if !known {
+		writeError(w, http.StatusNotFound, codeNameUnknown, "repository name not known to registry")
+
+		return
+	}
+
+	page, next := paginate(tags, req.URL.Query())
+	if next != "" {
+		w.Header().Set("Link", fmt.Sprintf(`</v2/%s/tags/list?%s>; rel="next"`, repo, next))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(map[string]any{"name": repo, "tags": page})
+}
+
+func (r *Registry) serveCatalog(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+
+	repos := make([]string, 0, len(r.repos))
+	for name := range r.repos {
+		repos = append(repos, name)
+	}
+
+	r.mu.Unlock()
+
+	sort.Strings(repos)
+
+	page, next := paginate(repos, req.URL.Query())
+	if next != "" {
+		w.Header().Set("Link", fmt.Sprintf(`</v2/_catalog?%s>; rel="next"`, next))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(map[string]any{"repositories": page})
+}
+
+// paginate applies the "n" and "last" query parameters to the
+// sorted items returning the page and the next page's query.
+func paginate(items []string, query url.Values) ([]string, string) {
+	if last := query.Get("last"); last != "" {
+		i := sort.SearchStrings(items, last)
+		if i < len(items) && items[i] == last {
+			i++
+		}
+
+		items = items[i:]
+	}
+
+	n, err := strconv.Atoi(query.Get("n"))
+	if err != nil || n < 0 || n >= len(items) {
+		return items, ""
+	}
+
+	page := items[:n]
+	if n == 0 {
+		return page, ""
+	}
+
+	next := url.Values{"n": {strconv.Itoa(n)}, "last": {page[n-1]}}
+
+	return page, next.Encode()
+}
@@ -0,0 +1,204 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package registrytest_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/mt-sre/go-ci/container"
+	"github.com/mt-sre/go-ci/container/containertest"
+	"github.com/mt-sre/go-ci/container/registrytest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func do(t *testing.T, method, url string, body []byte, headers ...string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), method, url, bytes.NewReader(body))
+	require.NoError(t, err)
+
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = res.Body.Close() })
+
+	return res
+}
+
+func TestChunkedUpload(t *testing.T) {
+	t.Parallel()
+
+	srv := registrytest.NewServer()
+	t.Cleanup(srv.Close)
+
+	data := []byte("hello, registry")
+
+	res := do(t, http.MethodPost, srv.URL+"/v2/org/app/blobs/uploads/", nil)
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+
+	location := srv.URL + res.Header.Get("Location")
+
+	res = do(t, http.MethodPatch, location, data[:5], "Content-Range", "0-4")
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+	assert.Equal(t, "0-4", res.Header.Get("Range"))
+
+	res = do(t, http.MethodPatch, location, data[5:], "Content-Range", "0-9")
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, res.StatusCode, "out of order chunk")
+
+	res = do(t, http.MethodPatch, location, data[5:], "Content-Range", fmt.Sprintf("5-%d", len(data)-1))
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+
+	res = do(t, http.MethodPut, location+"?digest=sha256:0000", nil)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "digest mismatch")
+
+	res = do(t, http.MethodPut, location+"?digest="+digestOf(data), nil)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, digestOf(data), res.Header.Get("Docker-Content-Digest"))
+
+	res = do(t, http.MethodGet, srv.URL+"/v2/org/app/blobs/"+digestOf(data), nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	got, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	res = do(t, http.MethodHead, srv.URL+"/v2/other/blobs/"+digestOf(data), nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode, "blobs are scoped to repositories")
+
+	res = do(t, http.MethodPost, srv.URL+"/v2/other/blobs/uploads/?mount="+digestOf(data)+"&from=org/app", nil)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	res = do(t, http.MethodHead, srv.URL+"/v2/other/blobs/"+digestOf(data), nil)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestManifests(t *testing.T) {
+	t.Parallel()
+
+	srv := registrytest.NewServer()
+	t.Cleanup(srv.Close)
+
+	config := []byte("{}")
+
+	res := do(t, http.MethodPost, srv.URL+"/v2/app/blobs/uploads/?digest="+digestOf(config), config)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	layer := container.Descriptor{MediaType: container.MediaTypeOCILayer, Digest: digestOf([]byte("missing")), Size: 7}
+	manifest := container.Manifest{
+		SchemaVersion: 2,
+		MediaType:     container.MediaTypeOCIManifest,
+		Config:        &container.Descriptor{MediaType: container.MediaTypeOCIConfig, Digest: digestOf(config), Size: 2},
+		Layers:        []container.Descriptor{layer},
+	}
+
+	data, err := json.Marshal(manifest)
+	require.NoError(t, err)
+
+	res = do(t, http.MethodPut, srv.URL+"/v2/app/manifests/v1", data, "Content-Type", container.MediaTypeOCIManifest)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "layers must exist")
+
+	srv.Registry.PutBlob("app", []byte("missing"))
+
+	res = do(t, http.MethodPut, srv.URL+"/v2/app/manifests/v1", data, "Content-Type", container.MediaTypeOCIManifest)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, digestOf(data), res.Header.Get("Docker-Content-Digest"))
+
+	for _, ref := range []string{"v1", digestOf(data)} {
+		res = do(t, http.MethodGet, srv.URL+"/v2/app/manifests/"+ref, nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, container.MediaTypeOCIManifest, res.Header.Get("Content-Type"))
+
+		got, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, data, got)
+	}
+
+	res = do(t, http.MethodDelete, srv.URL+"/v2/app/manifests/"+digestOf(data), nil)
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+
+	res = do(t, http.MethodGet, srv.URL+"/v2/app/manifests/v1", nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestTagsList(t *testing.T) {
+	t.Parallel()
+
+	srv := registrytest.NewServer()
+	t.Cleanup(srv.Close)
+
+	for _, tag := range []string{"v3", "v1", "v2"} {
+		srv.Registry.PushImage("org/app", tag, []byte(tag))
+	}
+
+	type tagList struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+
+	var pages [][]string
+
+	next := "/v2/org/app/tags/list?n=2"
+
+	for next != "" {
+		res := do(t, http.MethodGet, srv.URL+next, nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var list tagList
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&list))
+		assert.Equal(t, "org/app", list.Name)
+
+		pages = append(pages, list.Tags)
+
+		next = ""
+		if link := res.Header.Get("Link"); link != "" {
+			_, err := fmt.Sscanf(link, "<%s", &next)
+			require.NoError(t, err)
+
+			next = next[:len(next)-2]
+		}
+	}
+
+	assert.Equal(t, [][]string{{"v1", "v2"}, {"v3"}}, pages)
+
+	res := do(t, http.MethodGet, srv.URL+"/v2/unknown/tags/list", nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestPushInsecure(t *testing.T) {
+	t.Parallel()
+
+	srv := registrytest.NewServer()
+	t.Cleanup(srv.Close)
+
+	var exec containertest.Executor
+
+	client := containertest.NewClient(&exec)
+	ref := srv.Host() + "/org/app:v1"
+
+	require.NoError(t, client.Push(context.Background(), ref, container.WithInsecure(true)))
+
+	push := exec.Calls("push")
+	require.Len(t, push, 1)
+	assert.Equal(t, []string{"push", "--tls-verify=false", ref}, push[0].Args)
+}
@@ -0,0 +1,388 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StartService runs 'image' detached with the given options and
+// blocks until the configured readiness probe succeeds. The
+// container is removed if it fails to become ready, when the
+// supplied context is cancelled, or when a registered Cleaner
+// runs its cleanups; whichever happens first.
+func (c *Client) StartService(ctx context.Context, image string, opts ...ServiceOption) (*Service, error) {
+	var cfg ServiceConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	if v, ok := cfg.Probe.(validator); ok {
+		if err := v.validate(); err != nil {
+			return nil, fmt.Errorf("starting service from %q: %w", image, err)
+		}
+	}
+
+	args := []string{"run", "--detach"}
+
+	if cfg.Name != "" {
+		args = append(args, "--name", cfg.Name)
+	}
+
+	for _, k := range sortedKeys(cfg.Labels) {
+		args = append(args, "--label", k+"="+cfg.Labels[k])
+	}
+
+	// values are passed through the runtime's environment
+	// so that they never appear in argv
+	for _, k := range sortedKeys(cfg.Env) {
+		args = append(args, "--env", k)
+	}
+
+	for _, port := range cfg.Ports {
+		args = append(args, "--publish", "127.0.0.1::"+port)
+	}
+
+	for _, vol := range cfg.Volumes {
+		args = append(args, "--volume", vol.String())
+	}
+
+	// additional networks are connected once the container exists
+	// since not all runtimes accept multiple networks on "run"
+	if len(cfg.Networks) > 0 {
+		args = append(args, "--network", cfg.Networks[0])
+
+		for _, alias := range cfg.NetworkAliases {
+			args = append(args, "--network-alias", alias)
+		}
+	}
+
+	args = append(args, cfg.RunArgs...)
+	args = append(args, image)
+	args = append(args, cfg.Cmd...)
+
+	out, err := c.execute(ctx, Invocation{
+		Args: args,
+		Env:  mergeEnv(cfg.Env, authEnv(c.cfg.Flavor, cfg.AuthFile)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("starting container from %q: %w", image, err)
+	}
+
+	id := lastLine(out.Stdout)
+	if id == "" {
+		return nil, fmt.Errorf("starting container from %q: no container ID returned", image)
+	}
+
+	svc := &Service{
+		ID:     id,
+		Name:   cfg.Name,
+		Image:  image,
+		client: c,
+		ports:  make(map[string]string, len(cfg.Ports)),
+		done:   make(chan struct{}),
+	}
+
+	// Removal is registered before anything else can fail so that
+	// errors and panics while waiting never leak the container.
+	ready := false
+
+	defer func() {
+		if !ready {
+			_ = svc.Remove(context.Background())
+		}
+	}()
+
+	if cfg.Cleaner != nil {
+		cfg.Cleaner.Cleanup(func() { _ = svc.Remove(context.Background()) })
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = svc.Remove(context.Background())
+		case <-svc.done:
+		}
+	}()
+
+	if len(cfg.Networks) > 1 {
+		for _, network := range cfg.Networks[1:] {
+			if err := c.connectNetwork(ctx, network, id, cfg.NetworkAliases); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, port := range cfg.Ports {
+		endpoint, err := svc.lookupPort(ctx, port)
+		if err != nil {
+			return nil, fmt.Errorf("looking up host port for %q: %w", port, err)
+		}
+
+		svc.ports[normalizePort(port)] = endpoint
+	}
+
+	if err := svc.waitReady(ctx, cfg.Probe, cfg.ReadinessTimeout, cfg.ReadinessInterval); err != nil {
+		return nil, err
+	}
+
+	ready = true
+
+	return svc, nil
+}
+
+type ServiceConfig struct {
+	AuthFile          string
+	Cleaner           Cleaner
+	Cmd               []string
+	Env               map[string]string
+	Labels            map[string]string
+	Name              string
+	NetworkAliases    []string
+	Networks          []string
+	Ports             []string
+	Probe             Probe
+	ReadinessInterval time.Duration
+	ReadinessTimeout  time.Duration
+	RunArgs           []string
+	Volumes           []Volume
+}
+
+func (c *ServiceConfig) Option(opts ...ServiceOption) {
+	for _, opt := range opts {
+		opt.ConfigureService(c)
+	}
+}
+
+func (c *ServiceConfig) Default() {
+	if c.ReadinessTimeout == 0 {
+		c.ReadinessTimeout = time.Minute
+	}
+
+	if c.ReadinessInterval == 0 {
+		c.ReadinessInterval = 250 * time.Millisecond
+	}
+}
+
+type ServiceOption interface {
+	ConfigureService(*ServiceConfig)
+}
+
+// Cleaner registers functions to be run once a test completes.
+// It is satisfied by testing.TB.
+type Cleaner interface {
+	Cleanup(func())
+}
+
+// Volume describes a bind mount or named volume.
+type Volume struct {
+	// Source is a host path or volume name.
+	Source string
+	// Target is the path within the container.
+	Target   string
+	ReadOnly bool
+}
+
+func (v Volume) String() string {
+	res := v.Source + ":" + v.Target
+	if v.ReadOnly {
+		res += ":ro"
+	}
+
+	return res
+}
+
+func (v Volume) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText parses volumes in the "source:target[:ro]"
+// form accepted by container runtimes.
+func (v *Volume) UnmarshalText(text []byte) error {
+	parts := strings.Split(string(text), ":")
+
+	switch {
+	case len(parts) == 3 && (parts[2] == "ro" || parts[2] == "rw"):
+		v.ReadOnly = parts[2] == "ro"
+	case len(parts) != 2:
+		return fmt.Errorf("invalid volume %q: expected source:target[:ro]", text)
+	}
+
+	if parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("invalid volume %q: expected source:target[:ro]", text)
+	}
+
+	v.Source, v.Target = parts[0], parts[1]
+
+	return nil
+}
+
+// Service is a running container started by "StartService".
+type Service struct {
+	ID    string
+	Name  string
+	Image string
+
+	client *Client
+	ports  map[string]string
+
+	removeOnce sync.Once
+	removeErr  error
+	done       chan struct{}
+}
+
+// Endpoint returns the "host:port" address which the given
+// container port, e.g. "5432" or "53/udp", is published on.
+func (s *Service) Endpoint(port string) (string, bool) {
+	endpoint, ok := s.ports[normalizePort(port)]
+
+	return endpoint, ok
+}
+
+// Endpoints returns all published container ports
+// mapped to their "host:port" addresses.
+func (s *Service) Endpoints() map[string]string {
+	res := make(map[string]string, len(s.ports))
+	for k, v := range s.ports {
+		res[k] = v
+	}
+
+	return res
+}
+
+// Logs returns the combined output of the container.
+func (s *Service) Logs(ctx context.Context) (string, error) {
+	out, err := s.client.execute(ctx, Invocation{Args: []string{"logs", s.ID}})
+	if err != nil {
+		return "", fmt.Errorf("getting logs for %q: %w", s.ID, err)
+	}
+
+	return out.Stdout + out.Stderr, nil
+}
+
+// Exec runs the given command within the container
+// returning its standard output.
+func (s *Service) Exec(ctx context.Context, cmd ...string) (string, error) {
+	out, err := s.client.run(ctx, append([]string{"exec", s.ID}, cmd...)...)
+	if err != nil {
+		return "", fmt.Errorf("executing %q in %q: %w", strings.Join(cmd, " "), s.ID, err)
+	}
+
+	return out, nil
+}
+
+// Running reports whether the container is still running.
+func (s *Service) Running(ctx context.Context) (bool, error) {
+	out, err := s.client.run(ctx, "inspect", "--format", "{{.State.Running}}", s.ID)
+	if err != nil {
+		return false, fmt.Errorf("inspecting %q: %w", s.ID, err)
+	}
+
+	return strings.TrimSpace(out) == "true", nil
+}
+
+// Remove forcibly removes the container and its anonymous
+// volumes. It is safe to call Remove multiple times.
+func (s *Service) Remove(ctx context.Context) error {
+	s.removeOnce.Do(func() {
+		defer close(s.done)
+
+		if _, err := s.client.run(ctx, "rm", "--force", "--volumes", s.ID); err != nil {
+			s.removeErr = fmt.Errorf("removing container %q: %w", s.ID, err)
+		}
+	})
+
+	return s.removeErr
+}
+
+func (s *Service) lookupPort(ctx context.Context, port string) (string, error) {
+	out, err := s.client.run(ctx, "port", s.ID, normalizePort(port))
+	if err != nil {
+		return "", err
+	}
+
+	// docker may list both IPv4 and IPv6 bindings
+	for _, line := range strings.Split(out, "\n") {
+		host, hostPort, err := net.SplitHostPort(strings.TrimSpace(line))
+		if err != nil {
+			continue
+		}
+
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "127.0.0.1"
+		}
+
+		return net.JoinHostPort(host, hostPort), nil
+	}
+
+	return "", fmt.Errorf("unexpected port output %q", out)
+}
+
+// ErrServiceExited is returned when a service's container
+// stops before becoming ready.
+var ErrServiceExited = errors.New("service exited before becoming ready")
+
+func (s *Service) waitReady(ctx context.Context, probe Probe, timeout, interval time.Duration) error {
+	if probe == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := probe.Ready(ctx, s)
+		if err == nil {
+			return nil
+		}
+
+		if running, rErr := s.Running(ctx); rErr == nil && !running {
+			logs, _ := s.Logs(context.Background())
+
+			return fmt.Errorf("waiting for %q: %w\n%s", s.ID, ErrServiceExited, logs)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for %q to become ready: %w: %w", s.ID, ctx.Err(), err)
+		case <-ticker.C:
+		}
+	}
+}
+
+func normalizePort(port string) string {
+	if strings.Contains(port, "/") {
+		return port
+	}
+
+	return port + "/tcp"
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
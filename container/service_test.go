@@ -0,0 +1,276 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/go-ci/container"
+	"github.com/mt-sre/go-ci/container/containertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeService(t *testing.T) (*containertest.Executor, *container.Client) {
+	t.Helper()
+
+	var exec containertest.Executor
+
+	exec.Handle([]string{"run"}, containertest.Stdout("Trying to pull...\nabc123\n"))
+	exec.Handle([]string{"port"}, containertest.Stdout("0.0.0.0:40001\n[::]:40001\n"))
+	exec.Handle([]string{"inspect"}, containertest.Stdout("true\n"))
+
+	return &exec, containertest.NewClient(&exec)
+}
+
+func TestStartServiceArgs(t *testing.T) {
+	t.Parallel()
+
+	exec, client := newFakeService(t)
+
+	svc, err := client.StartService(context.Background(), "postgres:16",
+		container.WithName("db"),
+		container.WithEnv{"POSTGRES_PASSWORD": "secret"},
+		container.WithPorts{"5432"},
+		container.WithVolumes{{Source: "/data", Target: "/var/lib/postgresql/data", ReadOnly: true}},
+		container.WithCmd{"postgres", "-c", "fsync=off"},
+	)
+	require.NoError(t, err)
+
+	runs := exec.Calls("run")
+	require.Len(t, runs, 1)
+
+	assert.Equal(t, []string{
+		"run", "--detach",
+		"--name", "db",
+		"--env", "POSTGRES_PASSWORD",
+		"--publish", "127.0.0.1::5432",
+		"--volume", "/data:/var/lib/postgresql/data:ro",
+		"postgres:16", "postgres", "-c", "fsync=off",
+	}, runs[0].Args)
+	assert.Equal(t, map[string]string{"POSTGRES_PASSWORD": "secret"}, runs[0].Env)
+	assert.NotContains(t, strings.Join(runs[0].Args, " "), "secret")
+
+	assert.Equal(t, "abc123", svc.ID)
+
+	endpoint, ok := svc.Endpoint("5432")
+	require.True(t, ok)
+	assert.Equal(t, "127.0.0.1:40001", endpoint)
+
+	require.NoError(t, svc.Remove(context.Background()))
+	require.NoError(t, svc.Remove(context.Background()))
+	assert.Len(t, exec.Calls("rm", "--force", "--volumes", "abc123"), 1)
+}
+
+type fakeCleaner struct {
+	funcs []func()
+}
+
+func (c *fakeCleaner) Cleanup(f func()) { c.funcs = append(c.funcs, f) }
+
+func (c *fakeCleaner) run() {
+	for i := len(c.funcs) - 1; i >= 0; i-- {
+		c.funcs[i]()
+	}
+}
+
+func TestStartServiceCleanup(t *testing.T) {
+	t.Parallel()
+
+	exec, client := newFakeService(t)
+
+	var cleaner fakeCleaner
+
+	_, err := client.StartService(context.Background(), "redis", container.WithCleanup{Cleaner: &cleaner})
+	require.NoError(t, err)
+	assert.Empty(t, exec.Calls("rm"))
+
+	cleaner.run()
+	assert.Len(t, exec.Calls("rm"), 1)
+}
+
+func TestStartServiceContextCancellation(t *testing.T) {
+	t.Parallel()
+
+	exec, client := newFakeService(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	_, err := client.StartService(ctx, "redis")
+	require.NoError(t, err)
+
+	cancel()
+
+	assert.Eventually(t, func() bool {
+		return len(exec.Calls("rm")) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+type panicProbe struct{}
+
+func (panicProbe) Ready(context.Context, *container.Service) error { panic("boom") }
+
+func TestStartServiceRemovedOnPanic(t *testing.T) {
+	t.Parallel()
+
+	exec, client := newFakeService(t)
+
+	require.Panics(t, func() {
+		_, _ = client.StartService(context.Background(), "redis", container.WithReadiness{Probe: panicProbe{}})
+	})
+
+	assert.Len(t, exec.Calls("rm"), 1)
+}
+
+func TestStartServiceReadiness(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	_, srvPort, err := net.SplitHostPort(srv.Listener.Addr().String())
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		Probe     container.Probe
+		Setup     func(*containertest.Executor)
+		Assertion require.ErrorAssertionFunc
+	}{
+		"log line": {
+			Probe: container.LogProbe{Pattern: regexp.MustCompile(`ready to accept`), Occurrences: 2},
+			Setup: func(e *containertest.Executor) {
+				e.Handle([]string{"logs"}, func(container.Invocation) (container.Output, error) {
+					return container.Output{Stderr: "ready to accept\nrestarting\nready to accept\n"}, nil
+				})
+			},
+			Assertion: require.NoError,
+		},
+		"log line missing": {
+			Probe: container.LogProbe{Pattern: regexp.MustCompile(`ready to accept`)},
+			Setup: func(e *containertest.Executor) {
+				e.Handle([]string{"logs"}, containertest.Stdout("starting\n"))
+			},
+			Assertion: require.Error,
+		},
+		"tcp": {
+			Probe:     container.TCPProbe{Port: "80"},
+			Assertion: require.NoError,
+		},
+		"http": {
+			Probe:     container.HTTPProbe{Port: "80", Path: "/healthz"},
+			Assertion: require.NoError,
+		},
+		"http unexpected status": {
+			Probe:     container.HTTPProbe{Port: "80", Status: http.StatusOK},
+			Assertion: require.Error,
+		},
+		"exec": {
+			Probe:     container.ExecProbe{Command: []string{"pg_isready"}},
+			Assertion: require.NoError,
+		},
+		"exec failing": {
+			Probe: container.ExecProbe{Command: []string{"pg_isready"}},
+			Setup: func(e *containertest.Executor) {
+				e.Handle([]string{"exec"}, containertest.Fail(1, "no response"))
+			},
+			Assertion: require.Error,
+		},
+		"container exited": {
+			Probe: container.ExecProbe{Command: []string{"pg_isready"}},
+			Setup: func(e *containertest.Executor) {
+				e.Handle([]string{"exec"}, containertest.Fail(1, "no response"))
+				e.Handle([]string{"inspect"}, containertest.Stdout("false\n"))
+			},
+			Assertion: func(t require.TestingT, err error, _ ...interface{}) {
+				require.True(t, errors.Is(err, container.ErrServiceExited), err)
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			exec, client := newFakeService(t)
+			exec.Handle([]string{"port"}, containertest.Stdout("0.0.0.0:"+srvPort+"\n"))
+
+			if tc.Setup != nil {
+				tc.Setup(exec)
+			}
+
+			_, err := client.StartService(context.Background(), "image",
+				container.WithPorts{"80"},
+				container.WithReadiness{Probe: tc.Probe},
+				container.WithReadinessInterval(time.Millisecond),
+				container.WithReadinessTimeout(100*time.Millisecond),
+			)
+			tc.Assertion(t, err)
+
+			if err != nil {
+				assert.Len(t, exec.Calls("rm"), 1, "container must be removed when not ready")
+			}
+		})
+	}
+}
+
+func TestStartServiceInvalidProbe(t *testing.T) {
+	t.Parallel()
+
+	for name, probe := range map[string]container.Probe{
+		"log probe without pattern":  container.LogProbe{},
+		"exec probe without command": container.ExecProbe{},
+	} {
+		probe := probe
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			exec, client := newFakeService(t)
+
+			_, err := client.StartService(context.Background(), "image",
+				container.WithReadiness{Probe: probe},
+			)
+			require.ErrorIs(t, err, container.ErrInvalidProbe)
+
+			assert.Empty(t, exec.Calls("run"), "container must not be started")
+		})
+	}
+}
+
+func TestStartServiceRuntime(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping runtime test in short mode")
+	}
+
+	if _, ok := container.Runtime(); !ok {
+		t.Skip("no container runtime available")
+	}
+
+	t.Parallel()
+
+	client, err := container.NewClient()
+	require.NoError(t, err)
+
+	svc, err := client.StartService(context.Background(), "docker.io/library/busybox:latest",
+		container.WithCleanup{Cleaner: t},
+		container.WithCmd{"sh", "-c", "echo started && sleep 60"},
+		container.WithReadiness{Probe: container.LogProbe{Pattern: regexp.MustCompile("started")}},
+	)
+	require.NoError(t, err)
+
+	out, err := svc.Exec(context.Background(), "echo", "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", out)
+}
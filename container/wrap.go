@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mt-sre/go-ci/command"
+)
+
+// InContainer returns a CommandOption which runs a command.Command
+// within the given image rather than on the host, e.g.
+//
+//	lint := command.NewCommand("golangci-lint",
+//		command.WithArgs{"run"},
+//		client.InContainer("docker.io/golangci/golangci-lint:v1.60.3"),
+//	)
+//
+// The working directory is bind-mounted at the same path within the
+// container and used as its working directory. Variables supplied by
+// "command.WithEnv" are forwarded to the container, but the host's
+// environment is not. The command runs as the calling user so that
+// files written to the working directory are not owned by root.
+//
+// The runtime's exit code is that of the containerized command
+// and its output is captured by the Command as for native runs.
+func (c *Client) InContainer(image string, opts ...WrapOption) command.WithWrapper {
+	var cfg WrapConfig
+
+	cfg.Option(opts...)
+
+	return command.WithWrapper{Wrapper: &Wrapper{
+		cfg:    cfg,
+		client: c,
+		image:  image,
+	}}
+}
+
+// Wrapper is a command.Wrapper returned by "InContainer".
+type Wrapper struct {
+	cfg    WrapConfig
+	client *Client
+	image  string
+}
+
+// Wrap rewrites the named command as a runtime invocation.
+func (w *Wrapper) Wrap(name string, cfg command.CommandConfig) (string, command.CommandConfig) {
+	wd := cfg.WorkDir
+	if wd == "" {
+		wd, _ = os.Getwd()
+	}
+
+	if abs, err := filepath.Abs(wd); err == nil {
+		wd = abs
+	}
+
+	args := []string{"run", "--rm"}
+
+	if cfg.Stdin != nil {
+		args = append(args, "--interactive")
+	}
+
+	args = append(args, w.userArgs()...)
+	args = append(args, "--volume", Volume{Source: wd, Target: wd}.String(), "--workdir", wd)
+
+	for _, v := range w.cfg.Volumes {
+		args = append(args, "--volume", v.String())
+	}
+
+	for _, kv := range cfg.Env {
+		key, _, _ := strings.Cut(kv, "=")
+		args = append(args, "--env", key)
+	}
+
+	for _, key := range w.cfg.ForwardEnv {
+		args = append(args, "--env", key)
+	}
+
+	args = append(args, w.cfg.RunArgs...)
+	args = append(args, w.image, name)
+	args = append(args, cfg.Args...)
+
+	env := cfg.Env
+	auth := authEnv(w.client.cfg.Flavor, w.cfg.AuthFile)
+
+	for _, k := range sortedKeys(auth) {
+		env = append(env, fmt.Sprintf("%s=%s", k, auth[k]))
+	}
+
+	return w.client.cfg.BinPath, command.CommandConfig{
+		Args:    args,
+		Ctx:     cfg.Ctx,
+		Env:     env,
+		Stderr:  cfg.Stderr,
+		Stdin:   cfg.Stdin,
+		Stdout:  cfg.Stdout,
+		Verbose: cfg.Verbose,
+		// the runtime itself always requires the caller's environment
+		WithCurrentEnv: true,
+		WorkDir:        wd,
+	}
+}
+
+// userArgs maps the calling user into the container. Rootless podman
+// maps the user's ID through the user namespace while otherwise the
+// container process runs with the caller's UID and GID.
+func (w *Wrapper) userArgs() []string {
+	uid, gid := os.Getuid(), os.Getgid()
+
+	switch {
+	case uid < 0:
+		// user IDs are unavailable on Windows
+		return nil
+	case w.client.cfg.Flavor == FlavorPodman:
+		args := []string{"--security-opt", "label=disable"}
+		if uid != 0 {
+			args = append(args, "--userns=keep-id")
+		}
+
+		return args
+	case uid != 0:
+		return []string{"--user", fmt.Sprintf("%d:%d", uid, gid)}
+	default:
+		return nil
+	}
+}
+
+type WrapConfig struct {
+	AuthFile string
+	// ForwardEnv names host variables passed to the container.
+	ForwardEnv []string
+	RunArgs    []string
+	Volumes    []Volume
+}
+
+func (c *WrapConfig) Option(opts ...WrapOption) {
+	for _, opt := range opts {
+		opt.ConfigureWrap(c)
+	}
+}
+
+type WrapOption interface {
+	ConfigureWrap(*WrapConfig)
+}
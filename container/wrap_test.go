@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package container_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mt-sre/go-ci/command"
+	"github.com/mt-sre/go-ci/container"
+	"github.com/mt-sre/go-ci/container/containertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInContainerWrap(t *testing.T) {
+	t.Parallel()
+
+	var exec containertest.Executor
+
+	client := containertest.NewClient(&exec)
+	dir := t.TempDir()
+
+	opt := client.InContainer("quay.io/org/lint:v1",
+		container.WithAuthFile("/run/auth.json"),
+		container.WithForwardEnv{"GOFLAGS"},
+		container.WithRunArgs{"--network=none"},
+		container.WithVolumes{{Source: "/cache", Target: "/root/.cache", ReadOnly: true}},
+	)
+
+	name, cfg := opt.Wrap("golangci-lint", command.CommandConfig{
+		Args:    []string{"run", "./..."},
+		Env:     []string{"LINT=1"},
+		Stdin:   bytes.NewBufferString("input"),
+		WorkDir: dir,
+	})
+
+	assert.Equal(t, "podman", name)
+	assert.Equal(t, dir, cfg.WorkDir)
+	assert.True(t, cfg.WithCurrentEnv)
+	assert.Equal(t, []string{"LINT=1", "REGISTRY_AUTH_FILE=/run/auth.json"}, cfg.Env)
+
+	podmanUser := []string{"--security-opt", "label=disable"}
+
+	var dockerUser []string
+
+	if uid := os.Getuid(); uid > 0 {
+		podmanUser = append(podmanUser, "--userns=keep-id")
+		dockerUser = []string{"--user", fmt.Sprintf("%d:%d", uid, os.Getgid())}
+	}
+
+	expected := []string{"run", "--rm", "--interactive"}
+	expected = append(expected, podmanUser...)
+	expected = append(expected,
+		"--volume", dir+":"+dir,
+		"--workdir", dir,
+		"--volume", "/cache:/root/.cache:ro",
+		"--env", "LINT",
+		"--env", "GOFLAGS",
+		"--network=none",
+		"quay.io/org/lint:v1", "golangci-lint", "run", "./...",
+	)
+
+	assert.Equal(t, expected, cfg.Args)
+
+	docker := containertest.NewClient(&exec, container.WithFlavor(container.FlavorDocker))
+	_, cfg = docker.InContainer("img").Wrap("true", command.CommandConfig{WorkDir: dir})
+
+	expected = []string{"run", "--rm"}
+	expected = append(expected, dockerUser...)
+	expected = append(expected, "--volume", dir+":"+dir, "--workdir", dir, "img", "true")
+
+	assert.Equal(t, expected, cfg.Args)
+}
+
+func TestInContainerRuntime(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping runtime test in short mode")
+	}
+
+	if _, ok := container.Runtime(); !ok {
+		t.Skip("no container runtime available")
+	}
+
+	t.Parallel()
+
+	client, err := container.NewClient()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+
+	cmd := command.NewCommand("sh",
+		command.WithArgs{"-c", `echo "$GREETING" > out && cat out && echo oops >&2 && exit 3`},
+		command.WithContext{Context: context.Background()},
+		command.WithEnv{"GREETING": "hello"},
+		command.WithWorkingDirectory(dir),
+		client.InContainer("docker.io/library/busybox:latest"),
+	)
+
+	require.NoError(t, cmd.Run())
+	assert.Equal(t, 3, cmd.ExitCode())
+	assert.Equal(t, "hello\n", cmd.Stdout())
+	assert.Equal(t, "oops\n", cmd.Stderr())
+
+	info, err := os.Stat(filepath.Join(dir, "out"))
+	require.NoError(t, err)
+	assert.True(t, info.Mode().IsRegular())
+}
@@ -0,0 +1,548 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrChecksumMismatch is returned when files
+// do not match the checksums of a manifest.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrInvalidManifest is returned when a
+// checksum manifest cannot be parsed.
+var ErrInvalidManifest = errors.New("invalid manifest")
+
+// Algorithm is a hash algorithm used to compute checksums.
+type Algorithm string
+
+const (
+	// AlgorithmSHA256 computes SHA-256 checksums.
+	AlgorithmSHA256 Algorithm = "sha256"
+	// AlgorithmSHA512 computes SHA-512 checksums.
+	AlgorithmSHA512 Algorithm = "sha512"
+)
+
+func (a Algorithm) newHash() (hash.Hash, error) {
+	switch a {
+	case AlgorithmSHA256:
+		return sha256.New(), nil
+	case AlgorithmSHA512:
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", a)
+	}
+}
+
+// algorithmOf returns the algorithm producing hex digests of the
+// given length.
+func algorithmOf(digest string) (Algorithm, bool) {
+	switch len(digest) {
+	case sha256.Size * 2:
+		return AlgorithmSHA256, true
+	case sha512.Size * 2:
+		return AlgorithmSHA512, true
+	default:
+		return "", false
+	}
+}
+
+// Checksum returns the hex encoded digest of the file at path
+// computed with the configured algorithm, SHA-256 by default.
+func Checksum(path string, opts ...ChecksumOption) (string, error) {
+	var cfg checksumConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return checksum(path, cfg.Algorithm)
+}
+
+func checksum(path string, alg Algorithm) (string, error) {
+	h, err := alg.newHash()
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("opening %q: %w", path, err)
+	}
+
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("reading %q: %w", path, err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ChecksumTree returns the hex encoded digest of the manifest of
+// the regular files below root, as written by Manifest.WriteTo,
+// which changes whenever any file is added, removed, renamed or
+// modified.
+func ChecksumTree(root string, opts ...ChecksumOption) (string, error) {
+	m, err := GenerateManifest(root, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	h, err := m.Algorithm.newHash()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := m.WriteTo(h); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Manifest lists the checksums of a set of files.
+type Manifest struct {
+	Algorithm Algorithm
+	// Entries are sorted by path.
+	Entries []ManifestEntry
+}
+
+// ManifestEntry is the checksum of a single file.
+type ManifestEntry struct {
+	// Path is the slash separated path of the file
+	// relative to the manifest's root.
+	Path   string
+	Digest string
+}
+
+// GenerateManifest computes the checksums of the regular files
+// below root. Symbolic links are not followed and files may be
+// excluded using "WithExcludes" or "WithGitIgnore". Files are
+// hashed concurrently by runtime.GOMAXPROCS(0) workers unless
+// configured with "WithWorkers".
+func GenerateManifest(root string, opts ...ChecksumOption) (Manifest, error) {
+	var cfg checksumConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	paths, err := cfg.files(root)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	digests, err := cfg.checksums(root, paths)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	res := Manifest{
+		Algorithm: cfg.Algorithm,
+		Entries:   make([]ManifestEntry, 0, len(paths)),
+	}
+
+	for i, p := range paths {
+		res.Entries = append(res.Entries, ManifestEntry{Path: p, Digest: digests[i]})
+	}
+
+	return res, nil
+}
+
+// LoadManifest parses the manifest file at the given path.
+func LoadManifest(path string) (Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("opening %q: %w", path, err)
+	}
+
+	defer f.Close()
+
+	m, err := ParseManifest(f)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("parsing %q: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// ParseManifest parses a manifest in the format written by
+// sha256sum and sha512sum, e.g. "<digest>  <path>" per line.
+// The algorithm is inferred from the length of the digests.
+func ParseManifest(r io.Reader) (Manifest, error) {
+	var (
+		res     Manifest
+		scanner = bufio.NewScanner(r)
+		line    int
+	)
+
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		// names containing newlines or backslashes
+		// are escaped and the line prefixed by "\"
+		escaped := strings.HasPrefix(text, `\`)
+		if escaped {
+			text = text[1:]
+		}
+
+		digest, name, ok := strings.Cut(text, " ")
+		if !ok || (!strings.HasPrefix(name, " ") && !strings.HasPrefix(name, "*")) {
+			return Manifest{}, fmt.Errorf("%w: line %d: expected \"<digest>  <path>\"", ErrInvalidManifest, line)
+		}
+
+		name = name[1:]
+		if escaped {
+			name = unescapeName(name)
+		}
+
+		if _, err := hex.DecodeString(digest); err != nil {
+			return Manifest{}, fmt.Errorf("%w: line %d: invalid digest %q", ErrInvalidManifest, line, digest)
+		}
+
+		alg, ok := algorithmOf(digest)
+		if !ok || (res.Algorithm != "" && alg != res.Algorithm) {
+			return Manifest{}, fmt.Errorf("%w: line %d: unexpected digest length %d", ErrInvalidManifest, line, len(digest))
+		}
+
+		path := strings.TrimPrefix(filepath.ToSlash(name), "./")
+		if !filepath.IsLocal(filepath.FromSlash(path)) {
+			return Manifest{}, fmt.Errorf("%w: line %d: unsafe path %q", ErrInvalidManifest, line, name)
+		}
+
+		res.Algorithm = alg
+		res.Entries = append(res.Entries, ManifestEntry{
+			Path:   path,
+			Digest: strings.ToLower(digest),
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Manifest{}, err
+	}
+
+	if res.Algorithm == "" {
+		res.Algorithm = AlgorithmSHA256
+	}
+
+	slices.SortFunc(res.Entries, func(a, b ManifestEntry) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return res, nil
+}
+
+func escapeName(name string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`).Replace(name)
+}
+
+func unescapeName(name string) string {
+	return strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\r`, "\r").Replace(name)
+}
+
+// WriteTo writes the manifest to w in the format read by
+// "sha256sum --check" or "sha512sum --check".
+func (m Manifest) WriteTo(w io.Writer) (int64, error) {
+	var res int64
+
+	for _, ent := range m.Entries {
+		line := ent.Digest + "  " + ent.Path + "\n"
+
+		if strings.ContainsAny(ent.Path, "\\\n\r") {
+			line = `\` + ent.Digest + "  " + escapeName(ent.Path) + "\n"
+		}
+
+		n, err := io.WriteString(w, line)
+		res += int64(n)
+
+		if err != nil {
+			return res, fmt.Errorf("writing manifest: %w", err)
+		}
+	}
+
+	return res, nil
+}
+
+// Write writes the manifest to the file at the given path.
+func (m Manifest) Write(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating %q: %w", path, err)
+	}
+
+	if _, err := m.WriteTo(f); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+// VerifyReport details the result of verifying files
+// against a manifest.
+type VerifyReport struct {
+	// Matched lists the files matching their checksums.
+	Matched []string
+	// Mismatched lists the files not matching their checksums.
+	Mismatched []Mismatch
+	// Missing lists the files in the manifest which do not exist.
+	Missing []string
+	// Extra lists the files which are not in the manifest
+	// when verifying with "WithStrict".
+	Extra []string
+}
+
+// Mismatch describes a file not matching its checksum.
+type Mismatch struct {
+	Path     string
+	Expected string
+	// Actual is empty when the path is not a regular file.
+	Actual string
+}
+
+// OK reports whether every file matched.
+func (r VerifyReport) OK() bool {
+	return len(r.Mismatched) == 0 && len(r.Missing) == 0 && len(r.Extra) == 0
+}
+
+func (r VerifyReport) String() string {
+	var b strings.Builder
+
+	for _, m := range r.Mismatched {
+		if m.Actual == "" {
+			fmt.Fprintf(&b, "%s: FAILED (not a regular file)\n", m.Path)
+		} else {
+			fmt.Fprintf(&b, "%s: FAILED (expected %s, got %s)\n", m.Path, m.Expected, m.Actual)
+		}
+	}
+
+	for _, p := range r.Missing {
+		fmt.Fprintf(&b, "%s: MISSING\n", p)
+	}
+
+	for _, p := range r.Extra {
+		fmt.Fprintf(&b, "%s: EXTRA\n", p)
+	}
+
+	fmt.Fprintf(&b, "%d matched, %d mismatched, %d missing, %d extra",
+		len(r.Matched), len(r.Mismatched), len(r.Missing), len(r.Extra))
+
+	return b.String()
+}
+
+// Verify checks the files below root against the manifest m
+// returning a report and an error wrapping ErrChecksumMismatch
+// when any file does not match. Files not in the manifest are
+// only reported when verifying with "WithStrict". Paths which are
+// not regular files are reported as mismatched and an error
+// wrapping ErrInvalidManifest is returned for paths outside root.
+func Verify(root string, m Manifest, opts ...ChecksumOption) (VerifyReport, error) {
+	cfg := checksumConfig{Algorithm: m.Algorithm}
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	var (
+		res   VerifyReport
+		paths = make([]string, 0, len(m.Entries))
+		known = make(map[string]struct{}, len(m.Entries))
+		// irregular holds entries which are not regular files
+		irregular = make(map[string]struct{})
+	)
+
+	for _, ent := range m.Entries {
+		if !filepath.IsLocal(filepath.FromSlash(ent.Path)) {
+			return res, fmt.Errorf("verifying %q: %w: unsafe path %q", root, ErrInvalidManifest, ent.Path)
+		}
+
+		known[ent.Path] = struct{}{}
+
+		// symbolic links are followed so dangling
+		// links are reported as missing
+		info, err := os.Stat(filepath.Join(root, filepath.FromSlash(ent.Path)))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			res.Missing = append(res.Missing, ent.Path)
+		case err != nil:
+			return res, fmt.Errorf("verifying %q: %w", root, err)
+		case !info.Mode().IsRegular():
+			irregular[ent.Path] = struct{}{}
+		default:
+			paths = append(paths, ent.Path)
+		}
+	}
+
+	digests, err := cfg.checksums(root, paths)
+	if err != nil {
+		return res, err
+	}
+
+	actual := make(map[string]string, len(paths))
+	for i, p := range paths {
+		actual[p] = digests[i]
+	}
+
+	for _, ent := range m.Entries {
+		digest, ok := actual[ent.Path]
+		if _, irr := irregular[ent.Path]; !ok && !irr {
+			continue
+		}
+
+		if digest == ent.Digest {
+			res.Matched = append(res.Matched, ent.Path)
+		} else {
+			res.Mismatched = append(res.Mismatched, Mismatch{Path: ent.Path, Expected: ent.Digest, Actual: digest})
+		}
+	}
+
+	if cfg.Strict {
+		files, err := cfg.files(root)
+		if err != nil {
+			return res, err
+		}
+
+		for _, p := range files {
+			if _, ok := known[p]; !ok {
+				res.Extra = append(res.Extra, p)
+			}
+		}
+	}
+
+	if !res.OK() {
+		return res, fmt.Errorf("verifying %q: %w: %s", root, ErrChecksumMismatch, res.String())
+	}
+
+	return res, nil
+}
+
+type checksumConfig struct {
+	Algorithm Algorithm
+	Excludes  []string
+	GitIgnore bool
+	Strict    bool
+	Workers   int
+}
+
+func (c *checksumConfig) Option(opts ...ChecksumOption) {
+	for _, opt := range opts {
+		opt.ConfigureChecksum(c)
+	}
+}
+
+func (c *checksumConfig) Default() {
+	if c.Algorithm == "" {
+		c.Algorithm = AlgorithmSHA256
+	}
+
+	if c.Workers < 1 {
+		c.Workers = runtime.GOMAXPROCS(0)
+	}
+}
+
+// files returns the sorted slash separated paths of
+// the regular files below root which are not excluded.
+func (c *checksumConfig) files(root string) ([]string, error) {
+	ents, err := FindEntries(root,
+		WithEntType(EntTypeFile),
+		WithExcludes(c.Excludes),
+		WithGitIgnore(c.GitIgnore),
+		WithWorkers(c.Workers),
+		WithSorted(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]string, 0, len(ents))
+	for _, ent := range ents {
+		res = append(res, ent.Rel)
+	}
+
+	return res, nil
+}
+
+// checksums computes the digests of the files at the slash
+// separated paths below root using the configured workers.
+func (c *checksumConfig) checksums(root string, paths []string) ([]string, error) {
+	var (
+		res  = make([]string, len(paths))
+		errs = make([]error, len(paths))
+		sem  = make(chan struct{}, c.Workers)
+		wg   sync.WaitGroup
+	)
+
+	for i, p := range paths {
+		sem <- struct{}{}
+
+		wg.Add(1)
+
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			res[i], errs[i] = checksum(filepath.Join(root, filepath.FromSlash(p)), c.Algorithm)
+		}()
+	}
+
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+type ChecksumOption interface {
+	ConfigureChecksum(*checksumConfig)
+}
+
+// WithAlgorithm selects the hash algorithm used to compute checksums.
+type WithAlgorithm Algorithm
+
+func (a WithAlgorithm) ConfigureChecksum(c *checksumConfig) {
+	c.Algorithm = Algorithm(a)
+}
+
+// WithStrict reports files below the verified root
+// which are not listed in the manifest.
+type WithStrict bool
+
+func (s WithStrict) ConfigureChecksum(c *checksumConfig) {
+	c.Strict = bool(s)
+}
+
+func (e WithExcludes) ConfigureChecksum(c *checksumConfig) {
+	c.Excludes = append(c.Excludes, e...)
+}
+
+func (g WithGitIgnore) ConfigureChecksum(c *checksumConfig) {
+	c.GitIgnore = bool(g)
+}
+
+func (w WithWorkers) ConfigureChecksum(c *checksumConfig) {
+	c.Workers = int(w)
+}
@@ -0,0 +1,369 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file_test
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mt-sre/go-ci/file"
+	"github.com/mt-sre/go-ci/internal/filetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	sha256Hello = "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+	sha512Hello = "e7c22b994c59d9cf2b48e549b1e24666636045930d3da7c1acb299d1c3b7f931" +
+		"f94aae41edda2c2b207a36e10f8bcb8d45223e54878f5b316e7ce3b6bc019629"
+	sha256A = "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"
+	sha256B = "3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d"
+)
+
+func TestChecksum(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	filetest.WriteTree(t, root, map[string]string{"hello": "hello\n"})
+
+	for name, tc := range map[string]struct {
+		Options  []file.ChecksumOption
+		Expected string
+	}{
+		"default": {
+			Expected: sha256Hello,
+		},
+		"sha256": {
+			Options:  []file.ChecksumOption{file.WithAlgorithm(file.AlgorithmSHA256)},
+			Expected: sha256Hello,
+		},
+		"sha512": {
+			Options:  []file.ChecksumOption{file.WithAlgorithm(file.AlgorithmSHA512)},
+			Expected: sha512Hello,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			digest, err := file.Checksum(filepath.Join(root, "hello"), tc.Options...)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.Expected, digest)
+		})
+	}
+
+	_, err := file.Checksum(filepath.Join(root, "hello"), file.WithAlgorithm("md5"))
+	assert.Error(t, err)
+
+	_, err = file.Checksum(filepath.Join(root, "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestGenerateManifest(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	filetest.WriteTree(t, root, map[string]string{
+		"b":         "b",
+		"sub/a":     "a",
+		"build/out": "ignored",
+	})
+	require.NoError(t, os.Symlink("b", filepath.Join(root, "link")))
+
+	m, err := file.GenerateManifest(root, file.WithExcludes{"build/"}, file.WithWorkers(2))
+	require.NoError(t, err)
+
+	assert.Equal(t, file.AlgorithmSHA256, m.Algorithm)
+	assert.Equal(t, []file.ManifestEntry{
+		{Path: "b", Digest: sha256B},
+		{Path: "sub/a", Digest: sha256A},
+	}, m.Entries)
+
+	var buf bytes.Buffer
+
+	n, err := m.WriteTo(&buf)
+	require.NoError(t, err)
+
+	expected := sha256B + "  b\n" + sha256A + "  sub/a\n"
+
+	assert.Equal(t, expected, buf.String())
+	assert.Equal(t, int64(len(expected)), n)
+
+	sha256sum, err := exec.LookPath("sha256sum")
+	if err != nil {
+		return
+	}
+
+	manifest := filepath.Join(t.TempDir(), "SHA256SUMS")
+	require.NoError(t, m.Write(manifest))
+
+	cmd := exec.Command(sha256sum, "--check", "--quiet", manifest)
+	cmd.Dir = root
+
+	out, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(out))
+}
+
+func TestParseManifest(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Input    string
+		Expected file.Manifest
+	}{
+		"empty": {
+			Expected: file.Manifest{Algorithm: file.AlgorithmSHA256},
+		},
+		"text and binary": {
+			Input: sha256B + "  ./b\n" + sha256A + " *a\n\n",
+			Expected: file.Manifest{
+				Algorithm: file.AlgorithmSHA256,
+				Entries: []file.ManifestEntry{
+					{Path: "a", Digest: sha256A},
+					{Path: "b", Digest: sha256B},
+				},
+			},
+		},
+		"escaped": {
+			Input: `\` + sha256A + `  new\nline\\name` + "\n",
+			Expected: file.Manifest{
+				Algorithm: file.AlgorithmSHA256,
+				Entries: []file.ManifestEntry{
+					{Path: "new\nline\\name", Digest: sha256A},
+				},
+			},
+		},
+		"sha512": {
+			Input: strings.ToUpper(sha512Hello) + "  hello\r\n",
+			Expected: file.Manifest{
+				Algorithm: file.AlgorithmSHA512,
+				Entries: []file.ManifestEntry{
+					{Path: "hello", Digest: sha512Hello},
+				},
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := file.ParseManifest(strings.NewReader(tc.Input))
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.Expected, m)
+
+			var buf bytes.Buffer
+
+			_, err = m.WriteTo(&buf)
+			require.NoError(t, err)
+
+			roundTrip, err := file.ParseManifest(&buf)
+			require.NoError(t, err)
+
+			assert.Equal(t, m, roundTrip)
+		})
+	}
+}
+
+func TestParseManifestInvalid(t *testing.T) {
+	t.Parallel()
+
+	for name, input := range map[string]string{
+		"missing path":     sha256A + "\n",
+		"single space":     sha256A + " a\n",
+		"invalid digest":   strings.Repeat("z", 64) + "  a\n",
+		"unknown length":   "abcd  a\n",
+		"mixed algorithms": sha256A + "  a\n" + sha512Hello + "  hello\n",
+		"parent path":      sha256A + "  ../../etc/shadow\n",
+		"absolute path":    sha256A + "  /etc/shadow\n",
+	} {
+		input := input
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := file.ParseManifest(strings.NewReader(input))
+			assert.ErrorIs(t, err, file.ErrInvalidManifest)
+		})
+	}
+}
+
+func TestChecksumTree(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	filetest.WriteTree(t, root, map[string]string{
+		"a":     "a",
+		"sub/b": "b",
+	})
+
+	first, err := file.ChecksumTree(root)
+	require.NoError(t, err)
+
+	second, err := file.ChecksumTree(root, file.WithWorkers(4))
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second, "digest depends on workers")
+
+	filetest.WriteTree(t, root, map[string]string{"sub/b": "c"})
+
+	modified, err := file.ChecksumTree(root)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first, modified)
+
+	require.NoError(t, os.Rename(filepath.Join(root, "sub", "b"), filepath.Join(root, "sub", "c")))
+
+	renamed, err := file.ChecksumTree(root)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, modified, renamed)
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	filetest.WriteTree(t, root, map[string]string{
+		"a":     "a",
+		"b":     "b",
+		"c":     "c",
+		"extra": "extra",
+	})
+
+	m := file.Manifest{
+		Algorithm: file.AlgorithmSHA256,
+		Entries: []file.ManifestEntry{
+			{Path: "a", Digest: sha256A},
+			{Path: "b", Digest: sha256A},
+			{Path: "missing", Digest: sha256A},
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		Options  []file.ChecksumOption
+		Expected file.VerifyReport
+	}{
+		"default": {
+			Expected: file.VerifyReport{
+				Matched: []string{"a"},
+				Mismatched: []file.Mismatch{
+					{Path: "b", Expected: sha256A, Actual: sha256B},
+				},
+				Missing: []string{"missing"},
+			},
+		},
+		"strict": {
+			Options: []file.ChecksumOption{file.WithStrict(true)},
+			Expected: file.VerifyReport{
+				Matched: []string{"a"},
+				Mismatched: []file.Mismatch{
+					{Path: "b", Expected: sha256A, Actual: sha256B},
+				},
+				Missing: []string{"missing"},
+				Extra:   []string{"c", "extra"},
+			},
+		},
+		"strict with excludes": {
+			Options: []file.ChecksumOption{file.WithStrict(true), file.WithExcludes{"extra"}},
+			Expected: file.VerifyReport{
+				Matched: []string{"a"},
+				Mismatched: []file.Mismatch{
+					{Path: "b", Expected: sha256A, Actual: sha256B},
+				},
+				Missing: []string{"missing"},
+				Extra:   []string{"c"},
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			report, err := file.Verify(root, m, tc.Options...)
+			require.ErrorIs(t, err, file.ErrChecksumMismatch)
+
+			assert.Equal(t, tc.Expected, report)
+			assert.False(t, report.OK())
+			assert.Contains(t, report.String(), "b: FAILED")
+		})
+	}
+}
+
+func TestVerifyIrregular(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	filetest.WriteTree(t, root, map[string]string{"dir/a": "a"})
+	require.NoError(t, os.Symlink("dne", filepath.Join(root, "dangling")))
+
+	m := file.Manifest{
+		Algorithm: file.AlgorithmSHA256,
+		Entries: []file.ManifestEntry{
+			{Path: "dangling", Digest: sha256A},
+			{Path: "dir", Digest: sha256A},
+			{Path: "dir/a", Digest: sha256A},
+		},
+	}
+
+	report, err := file.Verify(root, m)
+	require.ErrorIs(t, err, file.ErrChecksumMismatch)
+
+	assert.Equal(t, file.VerifyReport{
+		Matched:    []string{"dir/a"},
+		Mismatched: []file.Mismatch{{Path: "dir", Expected: sha256A}},
+		Missing:    []string{"dangling"},
+	}, report)
+	assert.Contains(t, report.String(), "dir: FAILED (not a regular file)")
+
+	for _, p := range []string{"../a", "/etc/shadow"} {
+		_, err := file.Verify(filepath.Join(root, "dir"), file.Manifest{
+			Algorithm: file.AlgorithmSHA256,
+			Entries:   []file.ManifestEntry{{Path: p, Digest: sha256A}},
+		})
+		assert.ErrorIs(t, err, file.ErrInvalidManifest, p)
+	}
+}
+
+func TestVerifyGenerated(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	filetest.WriteTree(t, root, map[string]string{
+		"a":     "a",
+		"sub/b": "b",
+	})
+
+	m, err := file.GenerateManifest(root, file.WithAlgorithm(file.AlgorithmSHA512))
+	require.NoError(t, err)
+
+	manifest := filepath.Join(t.TempDir(), "SHA512SUMS")
+	require.NoError(t, m.Write(manifest))
+
+	loaded, err := file.LoadManifest(manifest)
+	require.NoError(t, err)
+
+	assert.Equal(t, m, loaded)
+
+	report, err := file.Verify(root, loaded, file.WithStrict(true))
+	require.NoError(t, err)
+
+	assert.True(t, report.OK())
+	assert.Equal(t, []string{"a", "sub/b"}, report.Matched)
+}
@@ -0,0 +1,556 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+)
+
+// SymlinkPolicy describes how symbolic links are copied.
+type SymlinkPolicy string
+
+const (
+	// SymlinkPreserve copies symbolic links as links to the same,
+	// unmodified, destination.
+	SymlinkPreserve SymlinkPolicy = "preserve"
+	// SymlinkFollow copies the targets of symbolic links in their
+	// place. Dangling links are preserved.
+	SymlinkFollow SymlinkPolicy = "follow"
+	// SymlinkSkip does not copy symbolic links.
+	SymlinkSkip SymlinkPolicy = "skip"
+)
+
+// Copy copies the file, symbolic link or directory tree at src to
+// dst preserving permissions and, unless disabled, modification
+// times. Existing files are replaced atomically with WriteAtomic.
+// Unlike "cp -r", directories are copied to dst itself rather than
+// into it when it already exists. Entities other than regular
+// files, directories and symbolic links are skipped.
+func Copy(src, dst string, opts ...CopyOption) error {
+	cfg := copyConfig{PreserveTimes: true}
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	c := copier{cfg: cfg}
+
+	return c.run(src, dst)
+}
+
+// Sync updates dst to mirror src copying only entities which are
+// new or changed similarly to "rsync -a". Regular files are changed
+// when their size or modification time differ or, when configured
+// with "WithCompareContent", their contents differ. Entities below
+// dst which do not exist below src are deleted when configured
+// with "WithDelete". Excluded entities are neither copied nor
+// deleted.
+func Sync(src, dst string, opts ...SyncOption) (SyncReport, error) {
+	cfg := syncConfig{copyConfig: copyConfig{PreserveTimes: true}}
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	c := copier{cfg: cfg.copyConfig, sync: &cfg}
+
+	err := c.run(src, dst)
+
+	return c.report, err
+}
+
+// SyncReport lists the slash separated paths, relative to the
+// synced roots, of the entities changed by Sync.
+type SyncReport struct {
+	// Copied lists the regular files and symbolic
+	// links which were created or replaced.
+	Copied []string
+	// Deleted lists the entities which were deleted.
+	Deleted []string
+	// Unchanged lists the regular files and symbolic
+	// links which were already up to date.
+	Unchanged []string
+}
+
+// copier copies directory trees as configured for Copy or Sync.
+type copier struct {
+	cfg copyConfig
+	// sync is set when syncing
+	sync   *syncConfig
+	report SyncReport
+	// dirs are the copied directories whose times are
+	// set once their contents have been copied
+	dirs []Entry
+}
+
+func (c *copier) run(src, dst string) error {
+	root, err := c.root(src)
+	if err != nil {
+		return err
+	}
+
+	ents := []Entry{root}
+
+	if root.Type == EntTypeDir {
+		found, err := FindEntries(src,
+			WithMinDepth(1),
+			WithExcludes(c.cfg.Excludes),
+			WithGitIgnore(c.cfg.GitIgnore),
+			WithFollowSymlinks(c.cfg.Symlinks == SymlinkFollow),
+			WithSortBy(SortByPath),
+		)
+		if err != nil {
+			return fmt.Errorf("copying %q: %w", src, err)
+		}
+
+		ents = append(ents, found...)
+	}
+
+	copied := make(map[string]struct{}, len(ents))
+
+	for _, ent := range ents {
+		ok, err := c.copy(ent, c.dst(dst, ent.Rel))
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			copied[ent.Rel] = struct{}{}
+		}
+	}
+
+	if c.sync != nil && c.sync.Delete && root.Type == EntTypeDir {
+		if err := c.delete(dst, copied); err != nil {
+			return err
+		}
+	}
+
+	// directories are modified by copying their contents so
+	// their modes and times are set deepest first once done
+	for _, ent := range slices.Backward(c.dirs) {
+		name := c.dst(dst, ent.Rel)
+
+		if err := os.Chmod(name, ent.Info.Mode().Perm()); err != nil {
+			return fmt.Errorf("setting mode of %q: %w", name, err)
+		}
+
+		if !c.cfg.PreserveTimes {
+			continue
+		}
+
+		if err := os.Chtimes(name, ent.Info.ModTime(), ent.Info.ModTime()); err != nil {
+			return fmt.Errorf("setting times of %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// root describes src as found by FindEntries.
+func (c *copier) root(src string) (Entry, error) {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return Entry{}, fmt.Errorf("copying %q: %w", src, err)
+	}
+
+	res := Entry{Path: src, Rel: ".", Info: info, Type: typeOf(info.Mode())}
+
+	if res.Type != EntTypeSymlink {
+		return res, nil
+	}
+
+	if res.Target, err = os.Readlink(src); err != nil {
+		return Entry{}, fmt.Errorf("reading link %q: %w", src, err)
+	}
+
+	if c.cfg.Symlinks != SymlinkFollow {
+		return res, nil
+	}
+
+	if info, err := os.Stat(src); err == nil {
+		res.Info, res.Type = info, typeOf(info.Mode())
+	}
+
+	return res, nil
+}
+
+// dst returns the OS path within dst of the slash separated path rel.
+func (c *copier) dst(dst, rel string) string {
+	return filepath.Join(dst, filepath.FromSlash(rel))
+}
+
+// copy copies ent to dst reporting whether it was
+// copied or already up to date rather than skipped.
+func (c *copier) copy(ent Entry, dst string) (bool, error) {
+	switch ent.Type {
+	case EntTypeDir:
+		return true, c.copyDir(ent, dst)
+	case EntTypeFile:
+		return true, c.copyFile(ent, dst)
+	case EntTypeSymlink:
+		if c.cfg.Symlinks == SymlinkSkip {
+			return false, nil
+		}
+
+		return true, c.copySymlink(ent, dst)
+	default:
+		return false, nil
+	}
+}
+
+func (c *copier) copyDir(ent Entry, dst string) error {
+	if err := c.replaceType(dst, fs.ModeDir); err != nil {
+		return err
+	}
+
+	if err := os.Mkdir(dst, 0o700); errors.Is(err, fs.ErrExist) {
+		if info, err := os.Stat(dst); err != nil || !info.IsDir() {
+			return fmt.Errorf("creating directory %q: %w", dst, fs.ErrExist)
+		}
+	} else if err != nil {
+		return fmt.Errorf("creating directory %q: %w", dst, err)
+	}
+
+	// directories remain writable until their contents have
+	// been copied, e.g. when copying read-only module caches
+	if err := os.Chmod(dst, ent.Info.Mode().Perm()|0o700); err != nil {
+		return fmt.Errorf("setting mode of %q: %w", dst, err)
+	}
+
+	c.dirs = append(c.dirs, ent)
+
+	return nil
+}
+
+func (c *copier) copyFile(ent Entry, dst string) error {
+	if err := c.replaceType(dst, 0); err != nil {
+		return err
+	}
+
+	upToDate, err := c.upToDate(ent, dst)
+	if err != nil {
+		return err
+	}
+
+	if upToDate {
+		c.report.Unchanged = append(c.report.Unchanged, ent.Rel)
+
+		return c.setAttrs(ent, dst)
+	}
+
+	src, err := os.Open(ent.Path)
+	if err != nil {
+		return fmt.Errorf("opening %q: %w", ent.Path, err)
+	}
+
+	defer src.Close()
+
+	if err := writeAtomic(dst, ent.Info.Mode().Perm(), true, func(f *os.File) error {
+		_, err := io.Copy(f, src)
+
+		return err
+	}); err != nil {
+		return err
+	}
+
+	c.report.Copied = append(c.report.Copied, ent.Rel)
+
+	if c.cfg.PreserveTimes {
+		if err := os.Chtimes(dst, ent.Info.ModTime(), ent.Info.ModTime()); err != nil {
+			return fmt.Errorf("setting times of %q: %w", dst, err)
+		}
+	}
+
+	return nil
+}
+
+// upToDate reports whether, when syncing, the regular
+// file ent is unchanged from the regular file at dst.
+func (c *copier) upToDate(ent Entry, dst string) (bool, error) {
+	if c.sync == nil {
+		return false, nil
+	}
+
+	info, err := os.Lstat(dst)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("describing %q: %w", dst, err)
+	}
+
+	if !info.Mode().IsRegular() || info.Size() != ent.Info.Size() {
+		return false, nil
+	}
+
+	if !c.sync.CompareContent {
+		return info.ModTime().Equal(ent.Info.ModTime()), nil
+	}
+
+	return sameContent(ent.Path, dst)
+}
+
+// setAttrs sets the mode and times of the up to date file dst.
+func (c *copier) setAttrs(ent Entry, dst string) error {
+	if err := os.Chmod(dst, ent.Info.Mode().Perm()); err != nil {
+		return fmt.Errorf("setting mode of %q: %w", dst, err)
+	}
+
+	if !c.cfg.PreserveTimes {
+		return nil
+	}
+
+	if err := os.Chtimes(dst, ent.Info.ModTime(), ent.Info.ModTime()); err != nil {
+		return fmt.Errorf("setting times of %q: %w", dst, err)
+	}
+
+	return nil
+}
+
+func (c *copier) copySymlink(ent Entry, dst string) error {
+	if target, err := os.Readlink(dst); err == nil && c.sync != nil && target == ent.Target {
+		c.report.Unchanged = append(c.report.Unchanged, ent.Rel)
+
+		return nil
+	}
+
+	if err := c.replaceType(dst, fs.ModeSymlink); err != nil {
+		return err
+	}
+
+	// links cannot be replaced atomically so are
+	// created alongside and renamed into place
+	var tmp string
+
+	err := withTempName(dst, func(name string) error {
+		tmp = name
+
+		return os.Symlink(ent.Target, tmp)
+	})
+	if err != nil {
+		return fmt.Errorf("creating link %q: %w", dst, err)
+	}
+
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+
+		return fmt.Errorf("replacing %q: %w", dst, err)
+	}
+
+	c.report.Copied = append(c.report.Copied, ent.Rel)
+
+	return nil
+}
+
+// replaceType removes dst when syncing if it exists and is of
+// a different type than the given file mode type bits.
+func (c *copier) replaceType(dst string, typ fs.FileMode) error {
+	if c.sync == nil {
+		return nil
+	}
+
+	info, err := os.Lstat(dst)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("describing %q: %w", dst, err)
+	}
+
+	if info.Mode().Type() == typ || (typ == fs.ModeSymlink && !info.IsDir()) {
+		return nil
+	}
+
+	if err := os.RemoveAll(dst); err != nil {
+		return fmt.Errorf("removing %q: %w", dst, err)
+	}
+
+	return nil
+}
+
+// delete removes the entities below dst which were not copied.
+func (c *copier) delete(dst string, copied map[string]struct{}) error {
+	ents, err := FindEntries(dst,
+		WithMinDepth(1),
+		WithExcludes(c.cfg.Excludes),
+		WithGitIgnore(c.cfg.GitIgnore),
+		WithSortBy(SortByPath),
+	)
+	if err != nil {
+		return fmt.Errorf("finding extraneous entities in %q: %w", dst, err)
+	}
+
+	deleted := make(map[string]struct{})
+
+	for _, ent := range ents {
+		if _, ok := copied[ent.Rel]; ok || within(deleted, ent.Rel) {
+			continue
+		}
+
+		if err := os.RemoveAll(ent.Path); err != nil {
+			return fmt.Errorf("removing %q: %w", ent.Path, err)
+		}
+
+		deleted[ent.Rel] = struct{}{}
+		c.report.Deleted = append(c.report.Deleted, ent.Rel)
+	}
+
+	return nil
+}
+
+// within reports whether any directory containing
+// the slash separated path rel is in dirs.
+func within(dirs map[string]struct{}, rel string) bool {
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		if _, ok := dirs[dir]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sameContent reports whether the named files have equal contents.
+func sameContent(a, b string) (bool, error) {
+	fa, err := os.Open(a)
+	if err != nil {
+		return false, fmt.Errorf("opening %q: %w", a, err)
+	}
+
+	defer fa.Close()
+
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, fmt.Errorf("opening %q: %w", b, err)
+	}
+
+	defer fb.Close()
+
+	bufA, bufB := make([]byte, 32*1024), make([]byte, 32*1024)
+
+	for {
+		na, errA := io.ReadFull(fa, bufA)
+		nb, errB := io.ReadFull(fb, bufB)
+
+		if !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false, nil
+		}
+
+		doneA, doneB := isEOF(errA), isEOF(errB)
+
+		switch {
+		case errA != nil && !doneA:
+			return false, fmt.Errorf("reading %q: %w", a, errA)
+		case errB != nil && !doneB:
+			return false, fmt.Errorf("reading %q: %w", b, errB)
+		case doneA || doneB:
+			return doneA == doneB, nil
+		}
+	}
+}
+
+func isEOF(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+type copyConfig struct {
+	Excludes      []string
+	GitIgnore     bool
+	PreserveTimes bool
+	Symlinks      SymlinkPolicy
+}
+
+func (c *copyConfig) Option(opts ...CopyOption) {
+	for _, opt := range opts {
+		opt.ConfigureCopy(c)
+	}
+}
+
+func (c *copyConfig) Default() {
+	if c.Symlinks == "" {
+		c.Symlinks = SymlinkPreserve
+	}
+}
+
+type CopyOption interface {
+	ConfigureCopy(*copyConfig)
+}
+
+type syncConfig struct {
+	copyConfig
+	CompareContent bool
+	Delete         bool
+}
+
+func (c *syncConfig) Option(opts ...SyncOption) {
+	for _, opt := range opts {
+		opt.ConfigureSync(c)
+	}
+}
+
+type SyncOption interface {
+	ConfigureSync(*syncConfig)
+}
+
+// WithSymlinkPolicy sets how symbolic links are copied.
+// By default links are preserved.
+type WithSymlinkPolicy SymlinkPolicy
+
+func (p WithSymlinkPolicy) ConfigureCopy(c *copyConfig) {
+	c.Symlinks = SymlinkPolicy(p)
+}
+
+func (p WithSymlinkPolicy) ConfigureSync(c *syncConfig) {
+	p.ConfigureCopy(&c.copyConfig)
+}
+
+// WithPreserveTimes copies the modification times of
+// entities when true which is the default.
+type WithPreserveTimes bool
+
+func (p WithPreserveTimes) ConfigureCopy(c *copyConfig) {
+	c.PreserveTimes = bool(p)
+}
+
+func (p WithPreserveTimes) ConfigureSync(c *syncConfig) {
+	p.ConfigureCopy(&c.copyConfig)
+}
+
+// WithCompareContent compares the contents of regular files
+// of equal size rather than their modification times when
+// syncing.
+type WithCompareContent bool
+
+func (cc WithCompareContent) ConfigureSync(c *syncConfig) {
+	c.CompareContent = bool(cc)
+}
+
+// WithDelete deletes entities from the destination
+// which do not exist in the source when syncing.
+type WithDelete bool
+
+func (d WithDelete) ConfigureSync(c *syncConfig) {
+	c.Delete = bool(d)
+}
+
+func (e WithExcludes) ConfigureCopy(c *copyConfig) {
+	c.Excludes = append(c.Excludes, e...)
+}
+
+func (e WithExcludes) ConfigureSync(c *syncConfig) {
+	e.ConfigureCopy(&c.copyConfig)
+}
+
+func (g WithGitIgnore) ConfigureCopy(c *copyConfig) {
+	c.GitIgnore = bool(g)
+}
+
+func (g WithGitIgnore) ConfigureSync(c *syncConfig) {
+	g.ConfigureCopy(&c.copyConfig)
+}
@@ -0,0 +1,303 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mt-sre/go-ci/file"
+	"github.com/mt-sre/go-ci/internal/filetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopy(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+
+	filetest.WriteTree(t, src, map[string]string{
+		"a":         "a",
+		"bin/run":   "#!/bin/sh",
+		"build/out": "excluded",
+		"target/b":  "b",
+	})
+	require.NoError(t, os.Chmod(filepath.Join(src, "bin", "run"), 0o755))
+	require.NoError(t, os.Symlink("target", filepath.Join(src, "link")))
+
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	require.NoError(t, os.Chtimes(filepath.Join(src, "a"), mtime, mtime))
+	require.NoError(t, os.Chtimes(filepath.Join(src, "bin"), mtime, mtime))
+
+	for name, tc := range map[string]struct {
+		Options  []file.CopyOption
+		Expected map[string]string
+	}{
+		"preserve symlinks": {
+			Options: []file.CopyOption{file.WithExcludes{"build/"}},
+			Expected: map[string]string{
+				"a": "a", "bin/": "", "bin/run": "#!/bin/sh",
+				"link": "-> target", "target/": "", "target/b": "b",
+			},
+		},
+		"follow symlinks": {
+			Options: []file.CopyOption{
+				file.WithExcludes{"build/"},
+				file.WithSymlinkPolicy(file.SymlinkFollow),
+			},
+			Expected: map[string]string{
+				"a": "a", "bin/": "", "bin/run": "#!/bin/sh",
+				"link/": "", "link/b": "b", "target/": "", "target/b": "b",
+			},
+		},
+		"skip symlinks": {
+			Options: []file.CopyOption{file.WithSymlinkPolicy(file.SymlinkSkip)},
+			Expected: map[string]string{
+				"a": "a", "bin/": "", "bin/run": "#!/bin/sh",
+				"build/": "", "build/out": "excluded", "target/": "", "target/b": "b",
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dst := filepath.Join(t.TempDir(), "dst")
+
+			require.NoError(t, file.Copy(src, dst, tc.Options...))
+
+			assert.Equal(t, tc.Expected, filetest.ReadTree(t, dst))
+
+			info, err := os.Stat(filepath.Join(dst, "bin", "run"))
+			require.NoError(t, err)
+
+			assert.Equal(t, fs.FileMode(0o755), info.Mode().Perm())
+
+			for _, name := range []string{"a", "bin"} {
+				info, err := os.Stat(filepath.Join(dst, name))
+				require.NoError(t, err)
+
+				assert.True(t, mtime.Equal(info.ModTime()), "modification time of %q not preserved", name)
+			}
+		})
+	}
+}
+
+func TestCopyFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	filetest.WriteTree(t, dir, map[string]string{"src": "new", "dst": "old"})
+
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "src"), mtime, mtime))
+
+	require.NoError(t, file.Copy(filepath.Join(dir, "src"), filepath.Join(dir, "dst"), file.WithPreserveTimes(false)))
+
+	data, err := os.ReadFile(filepath.Join(dir, "dst"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "new", string(data))
+
+	info, err := os.Stat(filepath.Join(dir, "dst"))
+	require.NoError(t, err)
+
+	assert.False(t, mtime.Equal(info.ModTime()))
+
+	assert.Error(t, file.Copy(filepath.Join(dir, "missing"), filepath.Join(dir, "dst")))
+}
+
+func TestCopyReadOnlyDirs(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+
+	t.Parallel()
+
+	src, dst := t.TempDir(), filepath.Join(t.TempDir(), "dst")
+
+	filetest.WriteTree(t, src, map[string]string{"ro/sub/f": "f"})
+
+	for _, dir := range []string{"ro/sub", "ro"} {
+		require.NoError(t, os.Chmod(filepath.Join(src, dir), 0o555))
+	}
+
+	// read-only directories cannot be removed by TempDir
+	t.Cleanup(func() {
+		for _, root := range []string{src, dst} {
+			_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+				if err == nil && d.IsDir() {
+					_ = os.Chmod(p, 0o755)
+				}
+
+				return nil
+			})
+		}
+	})
+
+	require.NoError(t, file.Copy(src, dst, file.WithPreserveTimes(false)))
+
+	assert.Equal(t, map[string]string{
+		"ro/":      "",
+		"ro/sub/":  "",
+		"ro/sub/f": "f",
+	}, filetest.ReadTree(t, dst))
+
+	for _, dir := range []string{"ro", "ro/sub"} {
+		info, err := os.Stat(filepath.Join(dst, dir))
+		require.NoError(t, err)
+
+		assert.Equal(t, fs.FileMode(0o555), info.Mode().Perm(), dir)
+	}
+
+	require.NoError(t, os.Chmod(filepath.Join(src, "ro", "sub"), 0o755))
+	filetest.WriteTree(t, src, map[string]string{"ro/sub/f": "changed"})
+	require.NoError(t, os.Chmod(filepath.Join(src, "ro", "sub"), 0o555))
+
+	_, err := file.Sync(src, dst, file.WithCompareContent(true))
+	require.NoError(t, err)
+
+	assert.Equal(t, "changed", filetest.ReadTree(t, dst)["ro/sub/f"])
+}
+
+func TestCopySymlinkStale(t *testing.T) {
+	t.Parallel()
+
+	src, dst := t.TempDir(), t.TempDir()
+
+	require.NoError(t, os.Symlink("a", filepath.Join(src, "link")))
+
+	// left behind by an interrupted copy
+	require.NoError(t, os.Symlink("stale", filepath.Join(dst, "link.tmp-link")))
+
+	require.NoError(t, file.Copy(src, dst))
+
+	require.NoError(t, os.Remove(filepath.Join(src, "link")))
+	require.NoError(t, os.Symlink("b", filepath.Join(src, "link")))
+
+	_, err := file.Sync(src, dst)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"link":          "-> b",
+		"link.tmp-link": "-> stale",
+	}, filetest.ReadTree(t, dst))
+}
+
+// syncTrees returns a source tree and a destination tree, copied
+// from an earlier state of the source, which have diverged.
+func syncTrees(t *testing.T) (string, string) {
+	t.Helper()
+
+	src, dst := t.TempDir(), filepath.Join(t.TempDir(), "dst")
+
+	filetest.WriteTree(t, src, map[string]string{
+		"changed":        "old",
+		"dir-was-file/a": "a",
+		"keep.log":       "keep",
+		"same":           "same",
+	})
+	require.NoError(t, os.Symlink("same", filepath.Join(src, "link")))
+
+	require.NoError(t, file.Copy(src, dst))
+
+	filetest.WriteTree(t, src, map[string]string{
+		"changed":  "NEW",
+		"new/file": "new",
+	})
+
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(src, "changed"), future, future))
+
+	require.NoError(t, os.RemoveAll(filepath.Join(dst, "dir-was-file")))
+	filetest.WriteTree(t, dst, map[string]string{
+		"dir-was-file": "file",
+		"extra/file":   "extra",
+		"ignored.log":  "ignored",
+	})
+
+	// same size and time but different contents
+	info, err := os.Stat(filepath.Join(src, "same"))
+	require.NoError(t, err)
+
+	filetest.WriteTree(t, dst, map[string]string{"same": "emas"})
+	require.NoError(t, os.Chtimes(filepath.Join(dst, "same"), info.ModTime(), info.ModTime()))
+
+	return src, dst
+}
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Options  []file.SyncOption
+		Expected file.SyncReport
+		Tree     map[string]string
+	}{
+		"default": {
+			Expected: file.SyncReport{
+				Copied:    []string{"changed", "dir-was-file/a", "new/file"},
+				Unchanged: []string{"keep.log", "link", "same"},
+			},
+			Tree: map[string]string{
+				"changed": "NEW", "dir-was-file/": "", "dir-was-file/a": "a",
+				"extra/": "", "extra/file": "extra", "ignored.log": "ignored", "keep.log": "keep",
+				"link": "-> same", "new/": "", "new/file": "new", "same": "emas",
+			},
+		},
+		"compare content": {
+			Options: []file.SyncOption{file.WithCompareContent(true)},
+			Expected: file.SyncReport{
+				Copied:    []string{"changed", "dir-was-file/a", "new/file", "same"},
+				Unchanged: []string{"keep.log", "link"},
+			},
+			Tree: map[string]string{
+				"changed": "NEW", "dir-was-file/": "", "dir-was-file/a": "a",
+				"extra/": "", "extra/file": "extra", "ignored.log": "ignored", "keep.log": "keep",
+				"link": "-> same", "new/": "", "new/file": "new", "same": "same",
+			},
+		},
+		"delete": {
+			Options: []file.SyncOption{file.WithDelete(true), file.WithExcludes{"ignored.log"}},
+			Expected: file.SyncReport{
+				Copied:    []string{"changed", "dir-was-file/a", "new/file"},
+				Deleted:   []string{"extra"},
+				Unchanged: []string{"keep.log", "link", "same"},
+			},
+			Tree: map[string]string{
+				"changed": "NEW", "dir-was-file/": "", "dir-was-file/a": "a",
+				"ignored.log": "ignored", "keep.log": "keep",
+				"link": "-> same", "new/": "", "new/file": "new", "same": "emas",
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			src, dst := syncTrees(t)
+
+			report, err := file.Sync(src, dst, tc.Options...)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.Expected, report)
+			assert.Equal(t, tc.Tree, filetest.ReadTree(t, dst))
+
+			report, err = file.Sync(src, dst, tc.Options...)
+			require.NoError(t, err)
+
+			assert.Empty(t, report.Copied, "second sync copied files")
+			assert.Empty(t, report.Deleted, "second sync deleted files")
+		})
+	}
+}
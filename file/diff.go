@@ -0,0 +1,217 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// isBinary reports whether data appears to be binary rather than
+// text which, like git, is the case when it contains a NUL byte
+// within its first 8000 bytes.
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
+
+// edit is a line of an edit script transforming one text
+// into another whose op is ' ', '-' or '+' for lines which
+// are kept, deleted or inserted respectively.
+type edit struct {
+	op   byte
+	line string
+}
+
+// maxEdits limits the edit distance found by diffLines above
+// which texts are treated as entirely replaced.
+const maxEdits = 1000
+
+// diffLines returns the shortest edit script transforming a into b
+// using Myers' algorithm.
+func diffLines(a, b []string) []edit {
+	n, m := len(a), len(b)
+	off := n + m + 1
+	v := make([]int, 2*(n+m)+3)
+
+	// trace holds, for each edit distance d, the furthest
+	// reaching x of diagonals -d-1 to d+1 before round d
+	var trace [][]int
+
+	for d := 0; d <= n+m && d <= maxEdits; d++ {
+		trace = append(trace, slices.Clone(v[off-d-1:off+d+2]))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+
+			v[off+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+
+	res := make([]edit, 0, n+m)
+
+	for _, line := range a {
+		res = append(res, edit{op: '-', line: line})
+	}
+
+	for _, line := range b {
+		res = append(res, edit{op: '+', line: line})
+	}
+
+	return res
+}
+
+func backtrack(trace [][]int, a, b []string) []edit {
+	var (
+		res  []edit
+		x, y = len(a), len(b)
+	)
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		// diagonal k of round d is at index k+d+1
+		at := func(k int) int { return trace[d][k+d+1] }
+
+		k := x - y
+
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			res = append(res, edit{op: ' ', line: a[x-1]})
+			x, y = x-1, y-1
+		}
+
+		if d == 0 {
+			break
+		}
+
+		if x == prevX {
+			res = append(res, edit{op: '+', line: b[y-1]})
+		} else {
+			res = append(res, edit{op: '-', line: a[x-1]})
+		}
+
+		x, y = prevX, prevY
+	}
+
+	slices.Reverse(res)
+
+	return res
+}
+
+// splitLines splits text into lines retaining their line endings.
+func splitLines(text string) []string {
+	res := strings.SplitAfter(text, "\n")
+	if res[len(res)-1] == "" {
+		res = res[:len(res)-1]
+	}
+
+	return res
+}
+
+// unifiedDiff returns a unified diff, as produced by "diff -u",
+// transforming the text a of the file named from into the text b
+// of the file named to with the given number of context lines.
+// The diff is empty when the texts are equal.
+func unifiedDiff(from, to, a, b string, context int) string {
+	edits := diffLines(splitLines(a), splitLines(b))
+
+	var changed []int
+
+	for i, e := range edits {
+		if e.op != ' ' {
+			changed = append(changed, i)
+		}
+	}
+
+	if len(changed) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", from, to)
+
+	// lines[i] holds the number of lines of a
+	// and b preceding the edit at index i
+	lines := make([][2]int, len(edits)+1)
+
+	for i, e := range edits {
+		lines[i+1] = lines[i]
+
+		if e.op != '+' {
+			lines[i+1][0]++
+		}
+
+		if e.op != '-' {
+			lines[i+1][1]++
+		}
+	}
+
+	for len(changed) > 0 {
+		// changes separated by at most twice the
+		// context lines are merged into one hunk
+		last := 0
+		for last+1 < len(changed) && changed[last+1]-changed[last] <= 2*context+1 {
+			last++
+		}
+
+		start := max(changed[0]-context, 0)
+		end := min(changed[last]+context+1, len(edits))
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n",
+			hunkRange(lines[start][0], lines[end][0]-lines[start][0]),
+			hunkRange(lines[start][1], lines[end][1]-lines[start][1]),
+		)
+
+		for _, e := range edits[start:end] {
+			sb.WriteByte(e.op)
+			sb.WriteString(e.line)
+
+			if !strings.HasSuffix(e.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		changed = changed[last+1:]
+	}
+
+	return sb.String()
+}
+
+// hunkRange formats the range of count lines following
+// the given number of preceding lines in hunk headers.
+func hunkRange(preceding, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", preceding)
+	case 1:
+		return fmt.Sprintf("%d", preceding+1)
+	default:
+		return fmt.Sprintf("%d,%d", preceding+1, count)
+	}
+}
@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+)
+
+// Entry describes an entity matched by FindEntries.
+type Entry struct {
+	// Path is the path of the entity as returned by Find.
+	Path string
+	// Rel is the slash separated path relative to root.
+	Rel string
+	// Info describes the entity or, for followed
+	// symbolic links, the link's target.
+	Info fs.FileInfo
+	// Type is the type of the entity or, for followed
+	// symbolic links, the type of the link's target.
+	Type EntType
+	// Target is the destination of symbolic links
+	// when it can be read.
+	Target string
+}
+
+// FindEntries is like Find but returns entries describing
+// the matched entities rather than their paths.
+func FindEntries(root string, opts ...FindOption) ([]Entry, error) {
+	w, err := newOSWalker(root, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.collectEntries(true)
+}
+
+// FindFSEntries is like FindEntries but searches
+// from root within fsys as FindFS does.
+func FindFSEntries(fsys fs.FS, root string, opts ...FindOption) ([]Entry, error) {
+	return newFSWalker(fsys, root, opts...).collectEntries(true)
+}
+
+// SortKey selects the attribute matched entities are sorted by.
+type SortKey string
+
+const (
+	// SortByNone leaves entities in the order they are matched.
+	SortByNone SortKey = ""
+	// SortByPath sorts entities by path.
+	SortByPath SortKey = "path"
+	// SortByName sorts entities by their base name.
+	SortByName SortKey = "name"
+	// SortBySize sorts entities from smallest to largest.
+	SortBySize SortKey = "size"
+	// SortByModTime sorts entities from least to most
+	// recently modified.
+	SortByModTime SortKey = "mtime"
+)
+
+// needsInfo reports whether sorting by k requires file info.
+func (k SortKey) needsInfo() bool {
+	return k == SortBySize || k == SortByModTime
+}
+
+// sortEntries sorts ents by key falling back to
+// their paths to order otherwise equal entries.
+func sortEntries(ents []Entry, key SortKey) {
+	slices.SortFunc(ents, func(a, b Entry) int {
+		var res int
+
+		switch key {
+		case SortByName:
+			res = cmp.Compare(path.Base(a.Rel), path.Base(b.Rel))
+		case SortBySize:
+			res = cmp.Compare(a.Info.Size(), b.Info.Size())
+		case SortByModTime:
+			res = a.Info.ModTime().Compare(b.Info.ModTime())
+		}
+
+		return cmp.Or(res, cmp.Compare(a.Path, b.Path))
+	})
+}
+
+// typeOf returns the EntType of entities with the given file mode
+// or EntTypeNone for types which cannot be matched by EntType.
+func typeOf(mode fs.FileMode) EntType {
+	for _, t := range []EntType{
+		EntTypeDir,
+		EntTypeFile,
+		EntTypeSymlink,
+		EntTypeNamedPipe,
+		EntTypeSocket,
+		EntTypeDevice,
+		EntTypeCharDevice,
+	} {
+		if t.matches(mode) {
+			return t
+		}
+	}
+
+	return EntTypeNone
+}
+
+// toEntry describes e found at the given path including, when
+// loaded by the walker, its info and symbolic link target.
+func (w *walker) toEntry(e *entry, path string) Entry {
+	res := Entry{
+		Path: path,
+		Rel:  e.rel,
+		Type: typeOf(e.mode),
+	}
+
+	if !w.withInfo {
+		return res
+	}
+
+	res.Info = e.info
+
+	if e.followed || e.mode&fs.ModeSymlink != 0 {
+		if target, err := readLink(w.fsys, e.path); err == nil {
+			res.Target = target
+		}
+	}
+
+	return res
+}
+
+// readLink returns the destination of the named symbolic
+// link when supported by fsys.
+func readLink(fsys fs.FS, name string) (string, error) {
+	if fsys, ok := fsys.(readLinkFS); ok {
+		return fsys.ReadLink(name)
+	}
+
+	return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+}
+
+// readLinkFS is a filesystem able to read symbolic links.
+type readLinkFS interface {
+	fs.FS
+	ReadLink(name string) (string, error)
+}
+
+// collectEntries walks returning entries for every visited entity.
+// Entries are sorted as configured and, when withInfo is set or
+// required to sort, include file info and symbolic link targets.
+func (w *walker) collectEntries(withInfo bool) ([]Entry, error) {
+	var res []Entry
+
+	w.withInfo = withInfo || w.cfg.Sort.needsInfo()
+	w.visit = func(e *entry) error {
+		res = append(res, w.toEntry(e, w.path(e)))
+
+		return nil
+	}
+
+	err := w.run()
+
+	if w.cfg.Sort != SortByNone {
+		sortEntries(res, w.cfg.Sort)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("walking directories: %w", err)
+	}
+
+	if len(w.errs) > 0 {
+		return res, fmt.Errorf("walking directories: %w", errors.Join(w.errs...))
+	}
+
+	return res, nil
+}
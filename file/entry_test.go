@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/mt-sre/go-ci/file"
+	"github.com/mt-sre/go-ci/internal/filetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindEntries(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	filetest.WriteTree(t, root, map[string]string{
+		"b.txt":     "bb",
+		"sub/a.txt": "a",
+	})
+	require.NoError(t, os.Symlink("b.txt", filepath.Join(root, "link")))
+
+	ents, err := file.FindEntries(root, file.WithMinDepth(1), file.WithSorted(true))
+	require.NoError(t, err)
+	require.Len(t, ents, 4)
+
+	for i, expected := range []struct {
+		Rel    string
+		Type   file.EntType
+		Size   int64
+		Target string
+	}{
+		{Rel: "b.txt", Type: file.EntTypeFile, Size: 2},
+		{Rel: "link", Type: file.EntTypeSymlink, Target: "b.txt"},
+		{Rel: "sub", Type: file.EntTypeDir},
+		{Rel: "sub/a.txt", Type: file.EntTypeFile, Size: 1},
+	} {
+		ent := ents[i]
+
+		assert.Equal(t, filepath.Join(root, filepath.FromSlash(expected.Rel)), ent.Path)
+		assert.Equal(t, expected.Rel, ent.Rel)
+		assert.Equal(t, expected.Type, ent.Type, expected.Rel)
+		assert.Equal(t, expected.Target, ent.Target, expected.Rel)
+		require.NotNil(t, ent.Info, expected.Rel)
+
+		if expected.Type == file.EntTypeFile {
+			assert.Equal(t, expected.Size, ent.Info.Size(), expected.Rel)
+		}
+	}
+
+	followed, err := file.FindEntries(root, file.WithName("link"), file.WithFollowSymlinks(true))
+	require.NoError(t, err)
+	require.Len(t, followed, 1)
+
+	assert.Equal(t, file.EntTypeFile, followed[0].Type)
+	assert.Equal(t, "b.txt", followed[0].Target)
+	assert.Equal(t, int64(2), followed[0].Info.Size())
+}
+
+func TestFindSortBy(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	fsys := fstest.MapFS{
+		"z/a.txt": {Data: []byte("abc"), ModTime: now.Add(-time.Hour)},
+		"b.txt":   {Data: []byte("a"), ModTime: now},
+		"y/c.txt": {Data: []byte("ab"), ModTime: now.Add(-2 * time.Hour)},
+	}
+
+	for name, tc := range map[string]struct {
+		Key      file.SortKey
+		Expected []string
+	}{
+		"path": {
+			Key:      file.SortByPath,
+			Expected: []string{"b.txt", "y/c.txt", "z/a.txt"},
+		},
+		"name": {
+			Key:      file.SortByName,
+			Expected: []string{"z/a.txt", "b.txt", "y/c.txt"},
+		},
+		"size": {
+			Key:      file.SortBySize,
+			Expected: []string{"b.txt", "y/c.txt", "z/a.txt"},
+		},
+		"mtime": {
+			Key:      file.SortByModTime,
+			Expected: []string{"y/c.txt", "z/a.txt", "b.txt"},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			files, err := file.FindFS(fsys, ".",
+				file.WithEntType(file.EntTypeFile),
+				file.WithSortBy(tc.Key),
+				file.WithWorkers(4),
+			)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.Expected, files)
+
+			ents, err := file.FindFSEntries(fsys, ".", file.WithEntType(file.EntTypeFile), file.WithSortBy(tc.Key))
+			require.NoError(t, err)
+
+			rels := make([]string, 0, len(ents))
+			for _, ent := range ents {
+				rels = append(rels, ent.Rel)
+			}
+
+			assert.Equal(t, tc.Expected, rels)
+		})
+	}
+}
@@ -5,9 +5,14 @@
 package file
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
+	"iter"
+	"path"
 	"path/filepath"
+	"regexp"
+	"runtime"
 )
 
 // EntType describes the entity types which a filesystem may contain.
@@ -20,62 +25,202 @@ const (
 	EntTypeAll EntType = "all"
 	// EntTypeDir matches directory entities.
 	EntTypeDir EntType = "dir"
-	// EntTypeFile matches file entities.
+	// EntTypeFile matches regular file entities.
 	EntTypeFile EntType = "file"
+	// EntTypeSymlink matches symbolic link entities. Links which
+	// are followed match the type of their target instead.
+	EntTypeSymlink EntType = "symlink"
+	// EntTypeNamedPipe matches named pipe (FIFO) entities.
+	EntTypeNamedPipe EntType = "pipe"
+	// EntTypeSocket matches Unix domain socket entities.
+	EntTypeSocket EntType = "socket"
+	// EntTypeDevice matches block device entities.
+	EntTypeDevice EntType = "device"
+	// EntTypeCharDevice matches character device entities.
+	EntTypeCharDevice EntType = "chardevice"
 )
 
+// matches reports whether an entity of the given
+// file mode is of the described type.
+func (t EntType) matches(mode fs.FileMode) bool {
+	switch t {
+	case EntTypeAll:
+		return true
+	case EntTypeDir:
+		return mode.IsDir()
+	case EntTypeFile:
+		return mode.IsRegular()
+	case EntTypeSymlink:
+		return mode&fs.ModeSymlink != 0
+	case EntTypeNamedPipe:
+		return mode&fs.ModeNamedPipe != 0
+	case EntTypeSocket:
+		return mode&fs.ModeSocket != 0
+	case EntTypeDevice:
+		return mode&fs.ModeDevice != 0 && mode&fs.ModeCharDevice == 0
+	case EntTypeCharDevice:
+		return mode&fs.ModeCharDevice != 0
+	default:
+		return false
+	}
+}
+
+// ErrorPolicy describes how errors encountered while
+// walking a directory tree are handled.
+type ErrorPolicy string
+
+const (
+	// ErrorPolicyFail stops walking and returns the first error.
+	ErrorPolicyFail ErrorPolicy = "fail"
+	// ErrorPolicySkip skips entries which cannot be read and
+	// returns the errors joined alongside the matched entities.
+	ErrorPolicySkip ErrorPolicy = "skip"
+	// ErrorPolicyIgnore silently skips entries which cannot be read.
+	ErrorPolicyIgnore ErrorPolicy = "ignore"
+)
+
+// ErrSymlinkLoop is returned when following a symbolic
+// link would walk a directory containing the link.
+var ErrSymlinkLoop = errors.New("symbolic link loop")
+
 // Find functions similarly to GNU find searching recursively
 // from root for all entites which match the given options.
 // By default all entity types (file, directory) will be returned
-// including the root directory.
+// including the root directory. Symbolic links are not followed
+// unless configured with "WithFollowSymlinks".
+//
+// Directories are walked by a single worker, and entities matched
+// in lexical order, unless configured with "WithWorkers".
+//
+// Errors reading entries, e.g. directories without permission to
+// read them, stop walking and are returned unless another ErrorPolicy
+// is configured with "WithErrorPolicy". Entries removed while walking
+// are always skipped and an error is always returned when root
+// itself cannot be read.
 func Find(root string, opts ...FindOption) ([]string, error) {
-	var cfg findConfig
-
-	cfg.Option(opts...)
-	cfg.Default()
+	w, err := newOSWalker(root, opts...)
+	if err != nil {
+		return nil, err
+	}
 
-	var result []string
+	return w.collect()
+}
 
-	hasCorrectType := func(fs.DirEntry) bool { return true }
+// FindSeq is like Find but returns an iterator yielding matched
+// paths as they are found allowing callers to stop walking early.
+// Errors, including those skipped by ErrorPolicySkip, are yielded
+// with an empty path. Results are only yielded once walking has
+// finished when sorted with "WithSorted" or "WithSortBy".
+func FindSeq(root string, opts ...FindOption) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		w, err := newOSWalker(root, opts...)
+		if err != nil {
+			yield("", err)
 
-	switch cfg.EntType {
-	case EntTypeDir:
-		hasCorrectType = func(d fs.DirEntry) bool {
-			return d.IsDir()
-		}
-	case EntTypeFile:
-		hasCorrectType = func(d fs.DirEntry) bool {
-			return !d.IsDir()
+			return
 		}
+
+		w.seq()(yield)
 	}
+}
 
-	searchFunc := func(path string, d fs.DirEntry, _ error) error {
-		if !hasCorrectType(d) {
-			return nil
-		}
+func newOSWalker(root string, opts ...FindOption) (*walker, error) {
+	cfg := newFindConfig(opts...)
 
-		matches, err := filepath.Match(cfg.Name, filepath.Base(path))
+	w := &walker{
+		cfg:  &cfg,
+		fsys: newOSFS(root),
+		root: ".",
+		name: filepath.Base(root),
+		path: osPath(root),
+	}
+
+	if cfg.GitIgnore {
+		ign, err := loadGitIgnore(root)
 		if err != nil {
-			return fmt.Errorf("matching %q against %q: %w", path, cfg.Name, err)
+			return nil, fmt.Errorf("loading ignore files: %w", err)
 		}
 
-		if matches {
-			result = append(result, path)
+		w.ign = ign
+	}
+
+	return w, nil
+}
+
+// osPath returns the OS path of entities below root.
+func osPath(root string) func(e *entry) string {
+	return func(e *entry) string {
+		if e.rel == "." {
+			return root
 		}
 
-		return nil
+		return filepath.Join(root, filepath.FromSlash(e.rel))
+	}
+}
+
+// FindFS is like Find but searches from root within fsys,
+// e.g. an embed.FS or the contents of an archive, returning
+// slash separated paths within fsys as fs.WalkDir does.
+//
+// Symbolic links can only be followed, or matched by type,
+// when supported by fsys. Ignore rules are read from the
+// .gitignore files within fsys only.
+func FindFS(fsys fs.FS, root string, opts ...FindOption) ([]string, error) {
+	return newFSWalker(fsys, root, opts...).collect()
+}
+
+// FindFSSeq is like FindSeq but searches from root within fsys.
+func FindFSSeq(fsys fs.FS, root string, opts ...FindOption) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		newFSWalker(fsys, root, opts...).seq()(yield)
 	}
+}
 
-	if err := filepath.WalkDir(root, searchFunc); err != nil {
-		return nil, fmt.Errorf("walking directories: %w", err)
+func newFSWalker(fsys fs.FS, root string, opts ...FindOption) *walker {
+	cfg := newFindConfig(opts...)
+
+	return &walker{
+		cfg:  &cfg,
+		fsys: fsys,
+		root: root,
+		name: path.Base(root),
+		path: fsPath,
 	}
+}
 
-	return result, nil
+func fsPath(e *entry) string {
+	return e.path
 }
 
 type findConfig struct {
-	EntType EntType
-	Name    string
+	EntType     EntType
+	ErrorPolicy ErrorPolicy
+	Excludes    []string
+	Filters     []filter
+	Follow      bool
+	GitIgnore   bool
+	// MaxDepth is negative when walking is unbounded.
+	MaxDepth int
+	MinDepth int
+	Name     string
+	Paths    []string
+	// Predicates are given the path of entities as returned
+	Predicates []WithPredicate
+	Regexps    []*regexp.Regexp
+	Sort       SortKey
+	Workers    int
+}
+
+// filter reports whether an entity should be matched.
+type filter func(e *entry) (bool, error)
+
+func newFindConfig(opts ...FindOption) findConfig {
+	cfg := findConfig{MaxDepth: -1, Workers: 1}
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	return cfg
 }
 
 func (c *findConfig) Option(opts ...FindOption) {
@@ -89,9 +234,62 @@ func (c *findConfig) Default() {
 		c.EntType = EntTypeAll
 	}
 
+	if c.ErrorPolicy == "" {
+		c.ErrorPolicy = ErrorPolicyFail
+	}
+
 	if c.Name == "" {
 		c.Name = "*"
 	}
+
+	if c.Workers < 1 {
+		c.Workers = runtime.GOMAXPROCS(0)
+	}
+}
+
+// excluded reports whether the entity at the slash separated
+// path rel, relative to root, matches any exclusion pattern.
+func (c *findConfig) excluded(rel string, isDir bool) (bool, error) {
+	for _, pattern := range c.Excludes {
+		matches, err := matchExclude(pattern, rel, isDir)
+		if err != nil {
+			return false, fmt.Errorf("matching %q against %q: %w", rel, pattern, err)
+		}
+
+		if matches {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// included reports whether the slash separated path rel, relative
+// to root, matches any path pattern or regular expression. All
+// paths are included when neither are configured.
+func (c *findConfig) included(rel string) (bool, error) {
+	if len(c.Paths) == 0 && len(c.Regexps) == 0 {
+		return true, nil
+	}
+
+	for _, pattern := range c.Paths {
+		matches, err := MatchGlob(pattern, rel)
+		if err != nil {
+			return false, fmt.Errorf("matching %q against %q: %w", rel, pattern, err)
+		}
+
+		if matches {
+			return true, nil
+		}
+	}
+
+	for _, re := range c.Regexps {
+		if re.MatchString(rel) {
+			return true, nil
+		}
+	}
+
+	return false, nil
 }
 
 type FindOption interface {
@@ -106,6 +304,63 @@ func (t WithEntType) ConfigureFind(c *findConfig) {
 	c.EntType = EntType(t)
 }
 
+// WithErrorPolicy selects how errors encountered
+// while walking are handled.
+type WithErrorPolicy ErrorPolicy
+
+func (p WithErrorPolicy) ConfigureFind(c *findConfig) {
+	c.ErrorPolicy = ErrorPolicy(p)
+}
+
+// WithExcludes prunes entities whose path relative to root
+// matches any of the given patterns along with, for directories,
+// their contents. Patterns with a trailing slash only match
+// directories and patterns without any other slash match entities
+// by name at any depth, e.g. "vendor/" excludes every directory
+// named vendor while "/vendor" and "docs/*.md" only apply
+// relative to root.
+type WithExcludes []string
+
+func (e WithExcludes) ConfigureFind(c *findConfig) {
+	c.Excludes = append(c.Excludes, e...)
+}
+
+// WithFollowSymlinks follows symbolic links to directories,
+// walking their contents, and matches symbolic links by the
+// type and metadata of their targets. Links which would walk
+// a directory containing them are reported as ErrSymlinkLoop.
+type WithFollowSymlinks bool
+
+func (f WithFollowSymlinks) ConfigureFind(c *findConfig) {
+	c.Follow = bool(f)
+}
+
+// WithGitIgnore skips entities ignored by git according to the
+// .gitignore files at every level of the work tree containing root,
+// the repository's "info/exclude" file and the global excludes file.
+// The ".git" directory is always skipped.
+type WithGitIgnore bool
+
+func (g WithGitIgnore) ConfigureFind(c *findConfig) {
+	c.GitIgnore = bool(g)
+}
+
+// WithMaxDepth limits walking to entities at most the given
+// number of levels below root which is at depth 0.
+type WithMaxDepth int
+
+func (d WithMaxDepth) ConfigureFind(c *findConfig) {
+	c.MaxDepth = int(d)
+}
+
+// WithMinDepth only matches entities at least the given
+// number of levels below root which is at depth 0.
+type WithMinDepth int
+
+func (d WithMinDepth) ConfigureFind(c *findConfig) {
+	c.MinDepth = int(d)
+}
+
 // WithName supplies a glob pattern to filter the
 // matched entities to only those which match
 // the glob.
@@ -114,3 +369,52 @@ type WithName string
 func (n WithName) ConfigureFind(c *findConfig) {
 	c.Name = string(n)
 }
+
+// WithPaths filters the matched entities to only those whose
+// slash separated path relative to root matches any of the
+// given patterns. In addition to the syntax of filepath.Match
+// "**" matches zero or more directories, e.g. "cmd/**/main.go".
+type WithPaths []string
+
+func (p WithPaths) ConfigureFind(c *findConfig) {
+	c.Paths = append(c.Paths, p...)
+}
+
+// WithRegexp filters the matched entities to only those whose
+// slash separated path relative to root matches the regular
+// expression. Entities matching any of the path patterns or
+// regular expressions supplied are matched.
+type WithRegexp struct{ *regexp.Regexp }
+
+func (r WithRegexp) ConfigureFind(c *findConfig) {
+	c.Regexps = append(c.Regexps, r.Regexp)
+}
+
+// WithSortBy sorts the matched entities by the given key.
+type WithSortBy SortKey
+
+func (s WithSortBy) ConfigureFind(c *findConfig) {
+	c.Sort = SortKey(s)
+}
+
+// WithSorted sorts the matched entities by path. Unless walking
+// with a single worker results are otherwise returned in an
+// unspecified order.
+type WithSorted bool
+
+func (s WithSorted) ConfigureFind(c *findConfig) {
+	if s {
+		c.Sort = SortByPath
+	} else {
+		c.Sort = SortByNone
+	}
+}
+
+// WithWorkers walks directories, computes checksums or checks and
+// searches files using up to the given number of concurrent
+// workers, using runtime.GOMAXPROCS(0) workers when below 1.
+type WithWorkers int
+
+func (w WithWorkers) ConfigureFind(c *findConfig) {
+	c.Workers = int(w)
+}
@@ -5,9 +5,17 @@
 package file_test
 
 import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/mt-sre/go-ci/file"
+	"github.com/mt-sre/go-ci/internal/filetest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -89,3 +97,371 @@ func TestFind(t *testing.T) {
 		})
 	}
 }
+
+func TestFindMissingRoot(t *testing.T) {
+	t.Parallel()
+
+	for _, policy := range []file.ErrorPolicy{
+		file.ErrorPolicyFail,
+		file.ErrorPolicySkip,
+		file.ErrorPolicyIgnore,
+	} {
+		_, err := file.Find("./testdata/dne", file.WithEntType(file.EntTypeFile), file.WithErrorPolicy(policy))
+		assert.ErrorIs(t, err, fs.ErrNotExist, policy)
+	}
+}
+
+func TestFindErrorPolicy(t *testing.T) {
+	t.Parallel()
+
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+
+	root := t.TempDir()
+	locked := filepath.Join(root, "locked")
+
+	require.NoError(t, os.Mkdir(locked, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(locked, "hidden.txt"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), nil, 0o644))
+	require.NoError(t, os.Chmod(locked, 0o000))
+
+	t.Cleanup(func() { _ = os.Chmod(locked, 0o755) })
+
+	for name, tc := range map[string]struct {
+		Policy       file.ErrorPolicy
+		ExpectedEnts []string
+		ExpectError  bool
+	}{
+		"fail": {
+			Policy:      file.ErrorPolicyFail,
+			ExpectError: true,
+		},
+		"skip": {
+			Policy:       file.ErrorPolicySkip,
+			ExpectedEnts: []string{filepath.Join(root, "a.txt")},
+			ExpectError:  true,
+		},
+		"ignore": {
+			Policy:       file.ErrorPolicyIgnore,
+			ExpectedEnts: []string{filepath.Join(root, "a.txt")},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			files, err := file.Find(root, file.WithEntType(file.EntTypeFile), file.WithErrorPolicy(tc.Policy))
+			if tc.ExpectError {
+				assert.ErrorIs(t, err, fs.ErrPermission)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.ElementsMatch(t, tc.ExpectedEnts, files)
+		})
+	}
+}
+
+func TestFindVanished(t *testing.T) {
+	t.Parallel()
+
+	for _, policy := range []file.ErrorPolicy{
+		file.ErrorPolicyFail,
+		file.ErrorPolicySkip,
+		file.ErrorPolicyIgnore,
+	} {
+		policy := policy
+
+		t.Run(string(policy), func(t *testing.T) {
+			t.Parallel()
+
+			// directories are moved away while walking until one
+			// is matched but vanishes before its contents are read
+			for range 100 {
+				root, trash := t.TempDir(), t.TempDir()
+				dirs := make([]string, 50)
+
+				for i := range dirs {
+					dirs[i] = strconv.Itoa(i)
+					require.NoError(t, os.MkdirAll(filepath.Join(root, dirs[i], "sub"), 0o755))
+				}
+
+				moved := make(chan struct{})
+
+				go func() {
+					defer close(moved)
+
+					for _, dir := range dirs {
+						_ = os.Rename(filepath.Join(root, dir), filepath.Join(trash, dir))
+					}
+				}()
+
+				files, err := file.Find(root, file.WithErrorPolicy(policy))
+				<-moved
+
+				require.NoError(t, err)
+
+				for _, dir := range dirs {
+					if slices.Contains(files, filepath.Join(root, dir)) && !slices.Contains(files, filepath.Join(root, dir, "sub")) {
+						return
+					}
+				}
+			}
+
+			t.Skip("no directory vanished while walking")
+		})
+	}
+}
+
+func TestFindPatterns(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	filetest.WriteTree(t, root, map[string]string{
+		"main.go":                      "",
+		"cmd/main.go":                  "",
+		"cmd/tool/main.go":             "",
+		"cmd/tool/main_test.go":        "",
+		"internal/a/testdata/x.txt":    "",
+		"internal/a/b/testdata/y.txt":  "",
+		"vendor/lib/main.go":           "",
+		"web/node_modules/pkg/main.go": "",
+		"web/vendor":                   "",
+	})
+
+	for name, tc := range map[string]struct {
+		Options      []file.FindOption
+		ExpectedEnts []string
+	}{
+		"doublestar paths": {
+			Options: []file.FindOption{file.WithPaths{"cmd/**/main.go"}},
+			ExpectedEnts: []string{
+				"cmd/main.go",
+				"cmd/tool/main.go",
+			},
+		},
+		"multiple paths": {
+			Options: []file.FindOption{file.WithPaths{"internal/*/testdata", "*.go"}},
+			ExpectedEnts: []string{
+				"internal/a/testdata",
+				"main.go",
+			},
+		},
+		"regexp": {
+			Options: []file.FindOption{
+				file.WithEntType(file.EntTypeFile),
+				file.WithRegexp{Regexp: regexp.MustCompile(`_test\.go$`)},
+			},
+			ExpectedEnts: []string{
+				"cmd/tool/main_test.go",
+			},
+		},
+		"paths and regexp": {
+			Options: []file.FindOption{
+				file.WithPaths{"**/*.txt"},
+				file.WithRegexp{Regexp: regexp.MustCompile(`^cmd/.*_test\.go$`)},
+			},
+			ExpectedEnts: []string{
+				"cmd/tool/main_test.go",
+				"internal/a/testdata/x.txt",
+				"internal/a/b/testdata/y.txt",
+			},
+		},
+		"excluded directories": {
+			Options: []file.FindOption{
+				file.WithName("main.go"),
+				file.WithExcludes{"vendor/", "node_modules/"},
+			},
+			ExpectedEnts: []string{
+				"main.go",
+				"cmd/main.go",
+				"cmd/tool/main.go",
+			},
+		},
+		"directory only exclusions": {
+			Options: []file.FindOption{
+				file.WithEntType(file.EntTypeFile),
+				file.WithExcludes{"vendor/", "cmd/", "internal/"},
+			},
+			ExpectedEnts: []string{
+				"main.go",
+				"web/node_modules/pkg/main.go",
+				"web/vendor",
+			},
+		},
+		"anchored exclusions": {
+			Options: []file.FindOption{
+				file.WithEntType(file.EntTypeFile),
+				file.WithExcludes{"/vendor", "web/**", "internal/*/testdata", "**/*_test.go"},
+			},
+			ExpectedEnts: []string{
+				"main.go",
+				"cmd/main.go",
+				"cmd/tool/main.go",
+				"internal/a/b/testdata/y.txt",
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			files, err := file.Find(root, tc.Options...)
+			require.NoError(t, err)
+
+			expected := make([]string, 0, len(tc.ExpectedEnts))
+			for _, ent := range tc.ExpectedEnts {
+				expected = append(expected, filepath.Join(root, filepath.FromSlash(ent)))
+			}
+
+			assert.ElementsMatch(t, expected, files)
+		})
+	}
+}
+
+func TestFindInvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	_, err := file.Find("./testdata", file.WithExcludes{"["})
+	assert.Error(t, err)
+
+	_, err = file.Find("./testdata", file.WithPaths{"sub/["})
+	assert.Error(t, err)
+}
+
+func TestFindMetadata(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	old := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	filetest.WriteTree(t, root, map[string]string{
+		"a.txt":          "hello",
+		"empty.txt":      "",
+		"bin/tool":       "#!/bin/sh\n",
+		"sub/deep/c.txt": "deep",
+	})
+
+	require.NoError(t, os.Mkdir(filepath.Join(root, "emptydir"), 0o755))
+	require.NoError(t, os.Chmod(filepath.Join(root, "bin", "tool"), 0o755))
+	require.NoError(t, os.Chtimes(filepath.Join(root, "a.txt"), old, old))
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(root, "link-a")))
+	require.NoError(t, os.Symlink("sub", filepath.Join(root, "link-sub")))
+	require.NoError(t, os.Symlink("missing", filepath.Join(root, "dangling")))
+
+	for name, tc := range map[string]struct {
+		Options      []file.FindOption
+		ExpectedEnts []string
+	}{
+		"max depth": {
+			Options:      []file.FindOption{file.WithMaxDepth(1), file.WithEntType(file.EntTypeDir)},
+			ExpectedEnts: []string{"", "bin", "emptydir", "sub"},
+		},
+		"min depth": {
+			Options:      []file.FindOption{file.WithMinDepth(2), file.WithEntType(file.EntTypeFile)},
+			ExpectedEnts: []string{"bin/tool", "sub/deep/c.txt"},
+		},
+		"zero max depth": {
+			Options:      []file.FindOption{file.WithMaxDepth(0)},
+			ExpectedEnts: []string{""},
+		},
+		"symlinks": {
+			Options:      []file.FindOption{file.WithEntType(file.EntTypeSymlink)},
+			ExpectedEnts: []string{"dangling", "link-a", "link-sub"},
+		},
+		"regular files exclude symlinks": {
+			Options:      []file.FindOption{file.WithEntType(file.EntTypeFile), file.WithMaxDepth(1)},
+			ExpectedEnts: []string{"a.txt", "empty.txt"},
+		},
+		"followed symlinks": {
+			Options: []file.FindOption{file.WithFollowSymlinks(true), file.WithEntType(file.EntTypeFile)},
+			ExpectedEnts: []string{
+				"a.txt",
+				"bin/tool",
+				"empty.txt",
+				"link-a",
+				"link-sub/deep/c.txt",
+				"sub/deep/c.txt",
+			},
+		},
+		"dangling symlinks when following": {
+			Options:      []file.FindOption{file.WithFollowSymlinks(true), file.WithEntType(file.EntTypeSymlink)},
+			ExpectedEnts: []string{"dangling"},
+		},
+		"size": {
+			Options:      []file.FindOption{file.WithEntType(file.EntTypeFile), file.WithMinSize(4), file.WithMaxSize(5)},
+			ExpectedEnts: []string{"a.txt", "sub/deep/c.txt"},
+		},
+		"modified before": {
+			Options:      []file.FindOption{file.WithModifiedBefore(old.Add(time.Hour))},
+			ExpectedEnts: []string{"a.txt"},
+		},
+		"modified after": {
+			Options:      []file.FindOption{file.WithEntType(file.EntTypeFile), file.WithModifiedAfter(old.Add(time.Hour))},
+			ExpectedEnts: []string{"bin/tool", "empty.txt", "sub/deep/c.txt"},
+		},
+		"all permission bits": {
+			Options:      []file.FindOption{file.WithEntType(file.EntTypeFile), file.WithPermAll(0o111)},
+			ExpectedEnts: []string{"bin/tool"},
+		},
+		"any permission bits": {
+			Options:      []file.FindOption{file.WithEntType(file.EntTypeFile), file.WithPermAny(0o100)},
+			ExpectedEnts: []string{"bin/tool"},
+		},
+		"empty": {
+			Options:      []file.FindOption{file.WithEmpty(true)},
+			ExpectedEnts: []string{"empty.txt", "emptydir"},
+		},
+		"non-empty": {
+			Options:      []file.FindOption{file.WithEmpty(false), file.WithEntType(file.EntTypeFile)},
+			ExpectedEnts: []string{"a.txt", "bin/tool", "sub/deep/c.txt"},
+		},
+		"predicate": {
+			Options: []file.FindOption{file.WithPredicate(func(path string, info fs.FileInfo) bool {
+				return info.Mode().IsRegular() && filepath.Ext(path) == ".txt" && info.Size() > 0
+			})},
+			ExpectedEnts: []string{"a.txt", "sub/deep/c.txt"},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			files, err := file.Find(root, tc.Options...)
+			require.NoError(t, err)
+
+			expected := make([]string, 0, len(tc.ExpectedEnts))
+			for _, ent := range tc.ExpectedEnts {
+				expected = append(expected, filepath.Join(root, filepath.FromSlash(ent)))
+			}
+
+			assert.ElementsMatch(t, expected, files)
+		})
+	}
+}
+
+func TestFindSymlinkLoop(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	filetest.WriteTree(t, root, map[string]string{"sub/a.txt": ""})
+	require.NoError(t, os.Symlink("..", filepath.Join(root, "sub", "loop")))
+
+	_, err := file.Find(root, file.WithFollowSymlinks(true))
+	require.ErrorIs(t, err, file.ErrSymlinkLoop)
+
+	files, err := file.Find(root,
+		file.WithFollowSymlinks(true),
+		file.WithEntType(file.EntTypeFile),
+		file.WithErrorPolicy(file.ErrorPolicySkip),
+	)
+	require.ErrorIs(t, err, file.ErrSymlinkLoop)
+
+	assert.Equal(t, []string{filepath.Join(root, "sub", "a.txt")}, files)
+}
@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// WithEmpty filters the matched entities to only empty regular
+// files and directories when true, or only non-empty regular
+// files and directories when false.
+type WithEmpty bool
+
+func (e WithEmpty) ConfigureFind(c *findConfig) {
+	c.Filters = append(c.Filters, func(ent *entry) (bool, error) {
+		var empty bool
+
+		switch {
+		case ent.mode.IsRegular():
+			info, err := ent.Info()
+			if err != nil {
+				return false, err
+			}
+
+			empty = info.Size() == 0
+		case ent.mode.IsDir():
+			var err error
+
+			if empty, err = isEmptyDir(ent.fsys, ent.path); err != nil {
+				return false, err
+			}
+		default:
+			return false, nil
+		}
+
+		return empty == bool(e), nil
+	})
+}
+
+// WithMaxSize filters the matched entities to only
+// those at most the given number of bytes in size.
+type WithMaxSize int64
+
+func (s WithMaxSize) ConfigureFind(c *findConfig) {
+	c.Filters = append(c.Filters, infoFilter(func(info fs.FileInfo) bool {
+		return info.Size() <= int64(s)
+	}))
+}
+
+// WithMinSize filters the matched entities to only
+// those at least the given number of bytes in size.
+type WithMinSize int64
+
+func (s WithMinSize) ConfigureFind(c *findConfig) {
+	c.Filters = append(c.Filters, infoFilter(func(info fs.FileInfo) bool {
+		return info.Size() >= int64(s)
+	}))
+}
+
+// WithModifiedAfter filters the matched entities to only
+// those last modified after the given time.
+type WithModifiedAfter time.Time
+
+func (m WithModifiedAfter) ConfigureFind(c *findConfig) {
+	c.Filters = append(c.Filters, infoFilter(func(info fs.FileInfo) bool {
+		return info.ModTime().After(time.Time(m))
+	}))
+}
+
+// WithModifiedBefore filters the matched entities to only
+// those last modified before the given time.
+type WithModifiedBefore time.Time
+
+func (m WithModifiedBefore) ConfigureFind(c *findConfig) {
+	c.Filters = append(c.Filters, infoFilter(func(info fs.FileInfo) bool {
+		return info.ModTime().Before(time.Time(m))
+	}))
+}
+
+// WithPermAll filters the matched entities to only those with
+// all of the given permission bits set similarly to GNU find's
+// "-perm -mode", e.g. WithPermAll(0o111) matches entities
+// executable by everyone.
+type WithPermAll fs.FileMode
+
+func (p WithPermAll) ConfigureFind(c *findConfig) {
+	c.Filters = append(c.Filters, infoFilter(func(info fs.FileInfo) bool {
+		perm := fs.FileMode(p).Perm()
+
+		return info.Mode().Perm()&perm == perm
+	}))
+}
+
+// WithPermAny filters the matched entities to only those with
+// any of the given permission bits set similarly to GNU find's
+// "-perm /mode", e.g. WithPermAny(0o022) matches entities
+// writable by their group or others.
+type WithPermAny fs.FileMode
+
+func (p WithPermAny) ConfigureFind(c *findConfig) {
+	c.Filters = append(c.Filters, infoFilter(func(info fs.FileInfo) bool {
+		return info.Mode().Perm()&fs.FileMode(p) != 0
+	}))
+}
+
+// WithPredicate filters the matched entities to only those
+// for which the given function returns true. The path is
+// given as it would be returned and, for followed symbolic
+// links, info describes the link's target.
+type WithPredicate func(path string, info fs.FileInfo) bool
+
+func (p WithPredicate) ConfigureFind(c *findConfig) {
+	c.Predicates = append(c.Predicates, p)
+}
+
+// isEmptyDir reports whether the named directory is empty reading
+// at most one entry unless unsupported by the directory's file.
+func isEmptyDir(fsys fs.FS, name string) (bool, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return false, err
+	}
+
+	defer f.Close()
+
+	dir, ok := f.(fs.ReadDirFile)
+	if !ok {
+		ents, err := fs.ReadDir(fsys, name)
+
+		return len(ents) == 0, err
+	}
+
+	if _, err := dir.ReadDir(1); errors.Is(err, io.EOF) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return false, nil
+}
+
+func infoFilter(f func(fs.FileInfo) bool) filter {
+	return func(e *entry) (bool, error) {
+		info, err := e.Info()
+		if err != nil {
+			return false, err
+		}
+
+		return f(info), nil
+	}
+}
@@ -0,0 +1,232 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mt-sre/go-ci/file"
+	"github.com/mt-sre/go-ci/internal/filetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		".gitignore":              {Data: []byte("*.gen.go\n")},
+		"main.go":                 {},
+		"main.gen.go":             {},
+		"cmd/tool/main.go":        {},
+		"vendor/lib/lib.go":       {},
+		"internal/pkg/testdata/x": {Data: []byte("x")},
+	}
+
+	for name, tc := range map[string]struct {
+		Root         string
+		Options      []file.FindOption
+		ExpectedEnts []string
+	}{
+		"defaults": {
+			Root: "cmd",
+			ExpectedEnts: []string{
+				"cmd",
+				"cmd/tool",
+				"cmd/tool/main.go",
+			},
+		},
+		"go files": {
+			Root: ".",
+			Options: []file.FindOption{
+				file.WithEntType(file.EntTypeFile),
+				file.WithName("*.go"),
+				file.WithExcludes{"vendor/"},
+			},
+			ExpectedEnts: []string{
+				"cmd/tool/main.go",
+				"main.gen.go",
+				"main.go",
+			},
+		},
+		"git ignore": {
+			Root: ".",
+			Options: []file.FindOption{
+				file.WithEntType(file.EntTypeFile),
+				file.WithPaths{"**/*.go"},
+				file.WithGitIgnore(true),
+			},
+			ExpectedEnts: []string{
+				"cmd/tool/main.go",
+				"main.go",
+				"vendor/lib/lib.go",
+			},
+		},
+		"depth": {
+			Root:         "internal",
+			Options:      []file.FindOption{file.WithMinDepth(1), file.WithMaxDepth(2)},
+			ExpectedEnts: []string{"internal/pkg", "internal/pkg/testdata"},
+		},
+		"size": {
+			Root:         ".",
+			Options:      []file.FindOption{file.WithMinSize(1), file.WithEntType(file.EntTypeFile)},
+			ExpectedEnts: []string{".gitignore", "internal/pkg/testdata/x"},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			files, err := file.FindFS(fsys, tc.Root, tc.Options...)
+			require.NoError(t, err)
+
+			assert.ElementsMatch(t, tc.ExpectedEnts, files)
+		})
+	}
+}
+
+func TestFindFSZip(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+
+	for _, name := range []string{"bin/tool", "docs/README.md", "LICENSE"} {
+		_, err := zw.Create(name)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, zw.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files, err := file.FindFS(zr, ".", file.WithEntType(file.EntTypeFile), file.WithPaths{"bin/*", "docs/**"})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"bin/tool", "docs/README.md"}, files)
+}
+
+// failingFS fails to read the directories in dirs with their error.
+type failingFS struct {
+	fstest.MapFS
+	dirs map[string]error
+}
+
+func (f failingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err, ok := f.dirs[name]; ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	return f.MapFS.ReadDir(name)
+}
+
+func TestFindFSErrorPolicy(t *testing.T) {
+	t.Parallel()
+
+	fsys := failingFS{
+		MapFS: fstest.MapFS{
+			"a.txt":            {},
+			"locked/b.txt":     {},
+			"vanished/c.txt":   {},
+			"readable/d/e.txt": {},
+		},
+		dirs: map[string]error{
+			"locked":   fs.ErrPermission,
+			"vanished": fs.ErrNotExist,
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		Policy       file.ErrorPolicy
+		ExpectedEnts []string
+		ExpectError  bool
+	}{
+		"fail": {
+			Policy:      file.ErrorPolicyFail,
+			ExpectError: true,
+		},
+		"skip": {
+			Policy:       file.ErrorPolicySkip,
+			ExpectedEnts: []string{"a.txt", "readable/d/e.txt"},
+			ExpectError:  true,
+		},
+		"ignore": {
+			Policy:       file.ErrorPolicyIgnore,
+			ExpectedEnts: []string{"a.txt", "readable/d/e.txt"},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			files, err := file.FindFS(fsys, ".", file.WithEntType(file.EntTypeFile), file.WithErrorPolicy(tc.Policy))
+			if tc.ExpectError {
+				require.ErrorIs(t, err, fs.ErrPermission)
+				assert.NotErrorIs(t, err, fs.ErrNotExist, "vanished directories are skipped")
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.ElementsMatch(t, tc.ExpectedEnts, files)
+		})
+	}
+}
+
+func TestFindPredicatePath(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	filetest.WriteTree(t, root, map[string]string{"a/b/c.go": ""})
+
+	for name, tc := range map[string]struct {
+		Find     func(opts ...file.FindOption) ([]string, error)
+		Expected []string
+	}{
+		"os": {
+			Find: func(opts ...file.FindOption) ([]string, error) {
+				return file.Find(root, opts...)
+			},
+			Expected: []string{filepath.Join(root, "a", "b", "c.go")},
+		},
+		"fs": {
+			Find: func(opts ...file.FindOption) ([]string, error) {
+				return file.FindFS(os.DirFS(root), "a", opts...)
+			},
+			Expected: []string{"a/b/c.go"},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var seen []string
+
+			files, err := tc.Find(
+				file.WithEntType(file.EntTypeFile),
+				file.WithPredicate(func(path string, _ fs.FileInfo) bool {
+					seen = append(seen, path)
+
+					return true
+				}),
+			)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.Expected, files)
+			assert.Equal(t, tc.Expected, seen)
+		})
+	}
+}
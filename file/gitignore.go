@@ -0,0 +1,370 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// gitIgnore evaluates the rules of the .gitignore files, repository
+// exclude file and global excludes file applying to a work tree.
+type gitIgnore struct {
+	// prefix is the slash separated path of the walked
+	// root relative to the top of the work tree
+	prefix string
+	// rules are ordered from lowest to highest precedence
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	// base is the slash separated directory, relative to the
+	// top of the work tree, containing the defining file
+	base     string
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// loadGitIgnore loads the rules applying to root from the global
+// excludes file, the repository's exclude file and any .gitignore
+// files from the top of the work tree down to, but excluding, root.
+// Work trees are identified by a ".git" entry and root is used
+// when none is found.
+func loadGitIgnore(root string) (*gitIgnore, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %q: %w", root, err)
+	}
+
+	top, gitDir := findWorkTree(abs)
+
+	prefix, err := filepath.Rel(top, abs)
+	if err != nil {
+		return nil, fmt.Errorf("relativizing %q: %w", abs, err)
+	}
+
+	ign := &gitIgnore{prefix: filepath.ToSlash(prefix)}
+	if ign.prefix == "." {
+		ign.prefix = ""
+	}
+
+	sources := []string{globalExcludesFile(gitDir)}
+	if gitDir != "" {
+		sources = append(sources, filepath.Join(gitDir, "info", "exclude"))
+	}
+
+	for _, src := range sources {
+		if err := ign.loadFile(src, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if ign.prefix == "" {
+		return ign, nil
+	}
+
+	dir, base := top, ""
+
+	for _, elem := range strings.Split(ign.prefix, "/") {
+		if err := ign.loadFile(filepath.Join(dir, ".gitignore"), base); err != nil {
+			return nil, err
+		}
+
+		dir, base = filepath.Join(dir, elem), path.Join(base, elem)
+	}
+
+	return ign, nil
+}
+
+// findWorkTree returns the closest ancestor of dir containing a
+// ".git" entry along with the git directory it refers to.
+func findWorkTree(dir string) (string, string) {
+	for cur := dir; ; cur = filepath.Dir(cur) {
+		dotGit := filepath.Join(cur, ".git")
+
+		if info, err := os.Stat(dotGit); err == nil {
+			if info.IsDir() {
+				return cur, dotGit
+			}
+
+			// linked work trees and submodules use a
+			// file of the form "gitdir: <path>"
+			data, err := os.ReadFile(dotGit)
+			if err == nil {
+				if gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: "); ok {
+					if !filepath.IsAbs(gitDir) {
+						gitDir = filepath.Join(cur, gitDir)
+					}
+
+					return cur, gitDir
+				}
+			}
+
+			return cur, ""
+		}
+
+		if filepath.Dir(cur) == cur {
+			return dir, ""
+		}
+	}
+}
+
+// globalExcludesFile returns the path of the excludes file given by
+// "core.excludesFile" in the user's or repository's git config and
+// otherwise git's default of "$XDG_CONFIG_HOME/git/ignore".
+func globalExcludesFile(gitDir string) string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	home, _ := os.UserHomeDir()
+
+	if configHome == "" && home != "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	var (
+		res     string
+		configs []string
+	)
+
+	// candidates below unset directories are skipped rather
+	// than being resolved relative to the working directory
+	if configHome != "" {
+		res = filepath.Join(configHome, "git", "ignore")
+		configs = append(configs, filepath.Join(configHome, "git", "config"))
+	}
+
+	if home != "" {
+		configs = append(configs, filepath.Join(home, ".gitconfig"))
+	}
+
+	if gitDir != "" {
+		configs = append(configs, filepath.Join(gitDir, "config"))
+	}
+
+	for _, config := range configs {
+		value, ok := readExcludesFile(config)
+		if !ok {
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(value, "~/"); ok {
+			if home == "" {
+				continue
+			}
+
+			value = filepath.Join(home, rest)
+		}
+
+		res = value
+	}
+
+	return res
+}
+
+// readExcludesFile reads the value of "core.excludesFile"
+// from the git config file at the given path.
+func readExcludesFile(config string) (string, bool) {
+	f, err := os.Open(config)
+	if err != nil {
+		return "", false
+	}
+
+	defer f.Close()
+
+	var (
+		section string
+		value   string
+		found   bool
+	)
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "[") {
+			section = strings.ToLower(strings.Trim(line, "[] \t"))
+
+			continue
+		}
+
+		key, val, ok := strings.Cut(line, "=")
+		if !ok || section != "core" || !strings.EqualFold(strings.TrimSpace(key), "excludesfile") {
+			continue
+		}
+
+		value, found = strings.Trim(strings.TrimSpace(val), `"`), true
+	}
+
+	return value, found
+}
+
+// loadFile appends the rules in the OS file at the given
+// path, if any, defined relative to the slash separated
+// directory base.
+func (g *gitIgnore) loadFile(path, base string) error {
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("opening %q: %w", path, err)
+	}
+
+	defer f.Close()
+
+	return g.read(f, path, base)
+}
+
+// loadDir returns the rules of g extended by those of the .gitignore
+// file, if any, within the directory name of fsys found at the slash
+// separated path rel below root. g itself is not modified so may be
+// shared by the walks of sibling directories.
+func (g *gitIgnore) loadDir(fsys fs.FS, name, rel string) (*gitIgnore, error) {
+	name = path.Join(name, ".gitignore")
+
+	f, err := fsys.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return g, nil
+	} else if err != nil {
+		return g, fmt.Errorf("opening %q: %w", name, err)
+	}
+
+	defer f.Close()
+
+	base := path.Join(g.prefix, rel)
+	if base == "." {
+		base = ""
+	}
+
+	res := &gitIgnore{
+		prefix: g.prefix,
+		rules:  slices.Clip(g.rules),
+	}
+
+	return res, res.read(f, name, base)
+}
+
+func (g *gitIgnore) read(r io.Reader, name, base string) error {
+	rules, err := parseIgnoreRules(r, base)
+	if err != nil {
+		return fmt.Errorf("reading %q: %w", name, err)
+	}
+
+	g.rules = append(g.rules, rules...)
+
+	return nil
+}
+
+// ignored reports whether the entity at the slash separated
+// path rel below root is ignored. The last matching rule wins.
+func (g *gitIgnore) ignored(rel string, isDir bool) bool {
+	rel = path.Join(g.prefix, rel)
+
+	var res bool
+
+	for _, rule := range g.rules {
+		if rule.matches(rel, isDir) {
+			res = !rule.negate
+		}
+	}
+
+	return res
+}
+
+func parseIgnoreRules(r io.Reader, base string) ([]ignoreRule, error) {
+	var res []ignoreRule
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text(), base); ok {
+			res = append(res, rule)
+		}
+	}
+
+	return res, scanner.Err()
+}
+
+func parseIgnoreRule(line, base string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+
+	// trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+
+	if rest, ok := strings.CutPrefix(line, "!"); ok {
+		rule.negate, line = true, rest
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if rest, ok := strings.CutSuffix(line, "/"); ok {
+		rule.dirOnly, line = true, rest
+	}
+
+	rule.anchored = strings.Contains(line, "/")
+	rule.pattern = strings.TrimPrefix(line, "/")
+
+	if rule.pattern == "" {
+		return ignoreRule{}, false
+	}
+
+	if _, err := MatchGlob(rule.pattern, ""); err != nil {
+		return ignoreRule{}, false
+	}
+
+	return rule, true
+}
+
+func (r ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	if r.base != "" {
+		var ok bool
+
+		if rel, ok = strings.CutPrefix(rel, r.base+"/"); !ok {
+			return false
+		}
+	}
+
+	if !r.anchored {
+		matches, _ := path.Match(r.pattern, path.Base(rel))
+
+		return matches
+	}
+
+	// "dir/**" matches everything within dir but not dir itself
+	if prefix, ok := strings.CutSuffix(r.pattern, "/**"); ok {
+		if matches, _ := MatchGlob(prefix, rel); matches {
+			return false
+		}
+	}
+
+	matches, _ := MatchGlob(r.pattern, rel)
+
+	return matches
+}
@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mt-sre/go-ci/file"
+	"github.com/mt-sre/go-ci/internal/filetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFindGitIgnore modifies the environment
+// so must not be run in parallel.
+func TestFindGitIgnore(t *testing.T) {
+	home := t.TempDir()
+
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+
+	filetest.WriteTree(t, home, map[string]string{
+		".gitconfig":    "[user]\n\tname = test\n[core]\n\texcludesFile = ~/global-ignore\n",
+		"global-ignore": "global.txt\n",
+	})
+
+	top := t.TempDir()
+
+	filetest.WriteTree(t, top, map[string]string{
+		".git/HEAD":         "ref: refs/heads/main\n",
+		".git/info/exclude": "secret.txt\n",
+		".gitignore": "# build output\n/build\n*.log\n!keep.log\nnode_modules/\ndocs/**\ntmp/\n" +
+			"\\#hash\ntrailing   \n",
+		"main.go":                   "",
+		"a.log":                     "",
+		"keep.log":                  "",
+		"#hash":                     "",
+		"trailing":                  "",
+		"tmp":                       "",
+		"secret.txt":                "",
+		"global.txt":                "",
+		"build/out.bin":             "",
+		"docs/readme.md":            "",
+		"node_modules/pkg/index.js": "",
+		"sub/.gitignore":            "*.gen.go\n!b.log\n",
+		"sub/main.go":               "",
+		"sub/x.gen.go":              "",
+		"sub/b.log":                 "",
+		"sub/c.log":                 "",
+		"sub/build/x.go":            "",
+		"sub/secret.txt":            "",
+	})
+
+	for name, tc := range map[string]struct {
+		Root         string
+		EntType      file.EntType
+		ExpectedEnts []string
+	}{
+		"files from top": {
+			Root:    top,
+			EntType: file.EntTypeFile,
+			ExpectedEnts: []string{
+				".gitignore",
+				"keep.log",
+				"main.go",
+				"tmp",
+				"sub/.gitignore",
+				"sub/b.log",
+				"sub/build/x.go",
+				"sub/main.go",
+			},
+		},
+		"directories from top": {
+			Root:    top,
+			EntType: file.EntTypeDir,
+			ExpectedEnts: []string{
+				"",
+				"docs",
+				"sub",
+				"sub/build",
+			},
+		},
+		"files from subdirectory": {
+			Root:    filepath.Join(top, "sub"),
+			EntType: file.EntTypeFile,
+			ExpectedEnts: []string{
+				"sub/.gitignore",
+				"sub/b.log",
+				"sub/build/x.go",
+				"sub/main.go",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			files, err := file.Find(tc.Root, file.WithEntType(tc.EntType), file.WithGitIgnore(true))
+			require.NoError(t, err)
+
+			expected := make([]string, 0, len(tc.ExpectedEnts))
+			for _, ent := range tc.ExpectedEnts {
+				expected = append(expected, filepath.Join(top, filepath.FromSlash(ent)))
+			}
+
+			assert.ElementsMatch(t, expected, files)
+		})
+	}
+}
+
+// TestFindGitIgnoreWithoutHome modifies the environment and
+// working directory so must not be run in parallel.
+func TestFindGitIgnoreWithoutHome(t *testing.T) {
+	t.Setenv("HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	cwd := t.TempDir()
+
+	// would be read as the global excludes file
+	// if resolved relative to the working directory
+	filetest.WriteTree(t, cwd, map[string]string{
+		"git/ignore": "*.txt\n",
+		"git/config": "[core]\n\texcludesFile = git/ignore\n",
+	})
+
+	require.NoError(t, os.Chdir(cwd))
+	t.Cleanup(func() { require.NoError(t, os.Chdir(wd)) })
+
+	top := t.TempDir()
+
+	filetest.WriteTree(t, top, map[string]string{
+		".git/HEAD": "ref: refs/heads/main\n",
+		"a.txt":     "",
+	})
+
+	files, err := file.Find(top, file.WithEntType(file.EntTypeFile), file.WithGitIgnore(true))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{filepath.Join(top, "a.txt")}, files)
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchGlob reports whether the slash separated name matches
+// pattern. Patterns follow the syntax of path.Match with the
+// addition of "**" segments matching zero or more directories,
+// e.g. "cmd/**/main.go" matches both "cmd/main.go" and
+// "cmd/tool/v2/main.go".
+func MatchGlob(pattern, name string) (bool, error) {
+	segments := strings.Split(pattern, "/")
+
+	// validate the full pattern as matching may stop early
+	for _, seg := range segments {
+		if _, err := path.Match(seg, ""); err != nil {
+			return false, err
+		}
+	}
+
+	return matchSegments(segments, strings.Split(name, "/")), nil
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+
+			if len(pattern) == 0 {
+				return true
+			}
+
+			for i := range name {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// matchExclude reports whether the entity at the slash separated
+// path rel matches the exclusion pattern. Patterns with a trailing
+// slash only match directories and patterns containing no other
+// slash match the base name of entities at any depth.
+func matchExclude(pattern, rel string, isDir bool) (bool, error) {
+	if strings.HasSuffix(pattern, "/") {
+		if !isDir {
+			return false, nil
+		}
+
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+
+	if !strings.Contains(pattern, "/") {
+		return path.Match(pattern, path.Base(rel))
+	}
+
+	return MatchGlob(strings.TrimPrefix(pattern, "/"), rel)
+}
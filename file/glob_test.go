@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file_test
+
+import (
+	"path"
+	"testing"
+
+	"github.com/mt-sre/go-ci/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchGlob(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Pattern  string
+		Name     string
+		Expected bool
+	}{
+		"literal": {
+			Pattern:  "cmd/main.go",
+			Name:     "cmd/main.go",
+			Expected: true,
+		},
+		"single star does not cross directories": {
+			Pattern: "cmd/*.go",
+			Name:    "cmd/tool/main.go",
+		},
+		"double star matches no directories": {
+			Pattern:  "cmd/**/main.go",
+			Name:     "cmd/main.go",
+			Expected: true,
+		},
+		"double star matches nested directories": {
+			Pattern:  "cmd/**/main.go",
+			Name:     "cmd/tool/v2/main.go",
+			Expected: true,
+		},
+		"leading double star": {
+			Pattern:  "**/testdata",
+			Name:     "internal/pkg/testdata",
+			Expected: true,
+		},
+		"trailing double star": {
+			Pattern:  "internal/**",
+			Name:     "internal/pkg/file.go",
+			Expected: true,
+		},
+		"single star segment": {
+			Pattern:  "internal/*/testdata",
+			Name:     "internal/pkg/testdata",
+			Expected: true,
+		},
+		"single star segment does not match nested": {
+			Pattern: "internal/*/testdata",
+			Name:    "internal/a/b/testdata",
+		},
+		"mismatched suffix": {
+			Pattern: "cmd/**/main.go",
+			Name:    "cmd/tool/main_test.go",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			matches, err := file.MatchGlob(tc.Pattern, tc.Name)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.Expected, matches)
+		})
+	}
+}
+
+func TestMatchGlobInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := file.MatchGlob("a/b/[", "x")
+	assert.ErrorIs(t, err, path.ErrBadPattern)
+}
@@ -0,0 +1,232 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Match is a line of a file matching a search pattern.
+type Match struct {
+	Path string
+	// Line is the one based line number of the match.
+	Line int
+	// Column is the one based byte offset of
+	// the first match within the line.
+	Column int
+	// Text is the matching line without its line ending.
+	Text string
+	// Before and After hold the lines surrounding
+	// the match when searching with "WithContextLines".
+	Before []string
+	After  []string
+}
+
+func (m Match) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", m.Path, m.Line, m.Column, m.Text)
+}
+
+// Search returns the lines of the regular files below root
+// matching the given pattern ordered by path and line. The
+// pattern is a regular expression, as understood by the regexp
+// package, unless searching "WithLiteral". Files are selected
+// by the FindOptions supplied using "WithFindOptions" and are searched
+// concurrently while binary files are always skipped.
+func Search(root, pattern string, opts ...SearchOption) ([]Match, error) {
+	var cfg searchConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	re, err := cfg.compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	findOpts := append([]FindOption{WithWorkers(cfg.Workers)}, cfg.Find...)
+
+	paths, err := Find(root, append(findOpts, WithEntType(EntTypeFile), WithSorted(true))...)
+	if err != nil {
+		return nil, fmt.Errorf("searching %q: %w", root, err)
+	}
+
+	var (
+		matches = make([][]Match, len(paths))
+		errs    = make([]error, len(paths))
+		sem     = make(chan struct{}, cfg.Workers)
+		wg      sync.WaitGroup
+	)
+
+	for i, p := range paths {
+		sem <- struct{}{}
+
+		wg.Add(1)
+
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			matches[i], errs[i] = cfg.search(p, re)
+		}()
+	}
+
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	var res []Match
+
+	for _, m := range matches {
+		res = append(res, m...)
+	}
+
+	return res, nil
+}
+
+// compile returns the regular expression matching pattern.
+func (c *searchConfig) compile(pattern string) (*regexp.Regexp, error) {
+	if c.Literal {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+
+	if c.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("compiling pattern: %w", err)
+	}
+
+	return re, nil
+}
+
+// search returns the lines of the file at path matching re.
+func (c *searchConfig) search(path string, re *regexp.Regexp) ([]Match, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("searching %q: %w", path, err)
+	}
+
+	if isBinary(data) {
+		return nil, nil
+	}
+
+	lines := strings.Split(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	var res []Match
+
+	for i, line := range lines {
+		if c.MaxCount > 0 && len(res) == c.MaxCount {
+			break
+		}
+
+		loc := re.FindStringIndex(line)
+		if loc == nil {
+			continue
+		}
+
+		m := Match{Path: path, Line: i + 1, Column: loc[0] + 1, Text: line}
+
+		if c.ContextLines > 0 {
+			m.Before = slices.Clone(lines[max(i-c.ContextLines, 0):i])
+			m.After = slices.Clone(lines[i+1 : min(i+1+c.ContextLines, len(lines))])
+		}
+
+		res = append(res, m)
+	}
+
+	return res, nil
+}
+
+type searchConfig struct {
+	ContextLines int
+	Find         []FindOption
+	IgnoreCase   bool
+	Literal      bool
+	MaxCount     int
+	Workers      int
+}
+
+func (c *searchConfig) Option(opts ...SearchOption) {
+	for _, opt := range opts {
+		opt.ConfigureSearch(c)
+	}
+}
+
+func (c *searchConfig) Default() {
+	if c.Workers < 1 {
+		c.Workers = runtime.GOMAXPROCS(0)
+	}
+}
+
+type SearchOption interface {
+	ConfigureSearch(*searchConfig)
+}
+
+// WithFindOptions selects the files which are searched, e.g.
+// "WithName("*.go")", in addition to any other FindOptions.
+type WithFindOptions []FindOption
+
+func (f WithFindOptions) ConfigureSearch(c *searchConfig) {
+	c.Find = append(c.Find, f...)
+}
+
+// WithIgnoreCase matches patterns case insensitively.
+type WithIgnoreCase bool
+
+func (i WithIgnoreCase) ConfigureSearch(c *searchConfig) {
+	c.IgnoreCase = bool(i)
+}
+
+// WithLiteral matches patterns as literal strings
+// rather than as regular expressions.
+type WithLiteral bool
+
+func (l WithLiteral) ConfigureSearch(c *searchConfig) {
+	c.Literal = bool(l)
+}
+
+// WithMaxCount stops searching each file after the given
+// number of matching lines. By default every line is matched.
+type WithMaxCount int
+
+func (m WithMaxCount) ConfigureSearch(c *searchConfig) {
+	c.MaxCount = int(m)
+}
+
+func (l WithContextLines) ConfigureSearch(c *searchConfig) {
+	c.ContextLines = int(l)
+}
+
+func (e WithExcludes) ConfigureSearch(c *searchConfig) {
+	c.Find = append(c.Find, e)
+}
+
+func (g WithGitIgnore) ConfigureSearch(c *searchConfig) {
+	c.Find = append(c.Find, g)
+}
+
+func (w WithWorkers) ConfigureSearch(c *searchConfig) {
+	c.Workers = int(w)
+}
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mt-sre/go-ci/file"
+	"github.com/mt-sre/go-ci/internal/filetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	filetest.WriteTree(t, root, map[string]string{
+		"a.go":        "package a\n\nimport \"io/ioutil\"\n\nvar _ = ioutil.ReadAll\n",
+		"b.go":        "package b\r\n\r\n// uses IOUTIL.ReadFile\r\n",
+		"c.txt":       "ioutil.\n",
+		"bin":         "\x00ioutil",
+		"vendor/x.go": "ioutil\n",
+		"empty.go":    "",
+	})
+
+	path := func(name string) string {
+		return filepath.Join(root, name)
+	}
+
+	for name, tc := range map[string]struct {
+		Pattern  string
+		Options  []file.SearchOption
+		Expected []file.Match
+	}{
+		"regexp": {
+			Pattern: `ioutil\.Read\w+`,
+			Options: []file.SearchOption{file.WithExcludes{"vendor/"}},
+			Expected: []file.Match{
+				{Path: path("a.go"), Line: 5, Column: 9, Text: "var _ = ioutil.ReadAll"},
+			},
+		},
+		"literal": {
+			Pattern: "ioutil.",
+			Options: []file.SearchOption{file.WithLiteral(true), file.WithExcludes{"vendor/"}},
+			Expected: []file.Match{
+				{Path: path("a.go"), Line: 5, Column: 9, Text: "var _ = ioutil.ReadAll"},
+				{Path: path("c.txt"), Line: 1, Column: 1, Text: "ioutil."},
+			},
+		},
+		"ignore case": {
+			Pattern: "ioutil.readfile",
+			Options: []file.SearchOption{
+				file.WithIgnoreCase(true),
+				file.WithFindOptions{file.WithName("*.go")},
+			},
+			Expected: []file.Match{
+				{Path: path("b.go"), Line: 3, Column: 9, Text: "// uses IOUTIL.ReadFile"},
+			},
+		},
+		"context lines": {
+			Pattern: "^import",
+			Options: []file.SearchOption{file.WithContextLines(2)},
+			Expected: []file.Match{
+				{
+					Path: path("a.go"), Line: 3, Column: 1, Text: `import "io/ioutil"`,
+					Before: []string{"package a", ""},
+					After:  []string{"", "var _ = ioutil.ReadAll"},
+				},
+			},
+		},
+		"max count": {
+			Pattern: "ioutil",
+			Options: []file.SearchOption{
+				file.WithMaxCount(1),
+				file.WithFindOptions{file.WithPaths{"*.go"}},
+			},
+			Expected: []file.Match{
+				{Path: path("a.go"), Line: 3, Column: 12, Text: `import "io/ioutil"`},
+			},
+		},
+		"no matches": {
+			Pattern: "os.ReadFile",
+			Options: []file.SearchOption{file.WithLiteral(true)},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			matches, err := file.Search(root, tc.Pattern, append(tc.Options, file.WithWorkers(2))...)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.Expected, matches)
+		})
+	}
+
+	_, err := file.Search(root, "(")
+	assert.Error(t, err)
+}
@@ -0,0 +1,363 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// ErrTreeChanged is returned when a directory tree
+// was expected to remain unchanged.
+var ErrTreeChanged = errors.New("directory tree changed")
+
+// Snapshot records the state of the entities below a directory.
+type Snapshot struct {
+	Root string
+	// Entries are sorted by path.
+	Entries []SnapshotEntry
+	// context is the number of context lines of diffs
+	context int
+}
+
+// SnapshotEntry records the state of a single entity.
+type SnapshotEntry struct {
+	// Path is the slash separated path relative to the root.
+	Path string
+	Type EntType
+	// Mode holds the permission bits of the entity.
+	Mode fs.FileMode
+	// Size is the size of regular files.
+	Size int64
+	// Digest is the SHA-256 checksum of regular files.
+	Digest string
+	// Target is the destination of symbolic links.
+	Target string
+
+	// text holds the contents of small text files
+	text *string
+}
+
+// TakeSnapshot records the paths, permissions and checksums of the
+// entities below root. The contents of text files no larger than
+// the configured "WithMaxDiffSize" are retained to produce diffs.
+func TakeSnapshot(root string, opts ...SnapshotOption) (*Snapshot, error) {
+	cfg := snapshotConfig{ContextLines: 3, MaxDiffSize: 1 << 20}
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	ents, err := FindEntries(root,
+		WithMinDepth(1),
+		WithExcludes(cfg.Excludes),
+		WithGitIgnore(cfg.GitIgnore),
+		WithSortBy(SortByPath),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("taking snapshot of %q: %w", root, err)
+	}
+
+	res := &Snapshot{
+		Root:    root,
+		Entries: make([]SnapshotEntry, 0, len(ents)),
+		context: cfg.ContextLines,
+	}
+
+	for _, ent := range ents {
+		snap := SnapshotEntry{
+			Path:   ent.Rel,
+			Type:   ent.Type,
+			Mode:   ent.Info.Mode().Perm(),
+			Target: ent.Target,
+		}
+
+		if ent.Type == EntTypeFile {
+			snap.Size = ent.Info.Size()
+
+			if err := snap.read(ent.Path, cfg.MaxDiffSize); err != nil {
+				return nil, err
+			}
+		}
+
+		res.Entries = append(res.Entries, snap)
+	}
+
+	return res, nil
+}
+
+// read computes the digest of the regular file at the given
+// path retaining its contents when text no larger than maxText.
+func (e *SnapshotEntry) read(path string, maxText int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening %q: %w", path, err)
+	}
+
+	defer f.Close()
+
+	h := sha256.New()
+
+	if e.Size > maxText {
+		if _, err := io.Copy(h, f); err != nil {
+			return fmt.Errorf("reading %q: %w", path, err)
+		}
+	} else {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return fmt.Errorf("reading %q: %w", path, err)
+		}
+
+		h.Write(data)
+
+		if !isBinary(data) {
+			text := string(data)
+			e.text = &text
+		}
+	}
+
+	e.Digest = hex.EncodeToString(h.Sum(nil))
+
+	return nil
+}
+
+// ChangeKind describes how an entity changed between snapshots.
+type ChangeKind string
+
+const (
+	// ChangeAdded is an entity which did not exist before.
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved is an entity which no longer exists.
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeModified is an entity whose type,
+	// permissions, contents or target changed.
+	ChangeModified ChangeKind = "modified"
+)
+
+// Change describes an entity which changed between snapshots.
+type Change struct {
+	Path string
+	Kind ChangeKind
+	// Before is nil for added entities.
+	Before *SnapshotEntry
+	// After is nil for removed entities.
+	After *SnapshotEntry
+	// Diff is a unified diff of the contents of text files
+	// and is empty when either contents were not retained.
+	Diff string
+}
+
+// TreeDiff lists the changes between snapshots sorted by path.
+type TreeDiff struct {
+	Changes []Change
+}
+
+// Empty reports whether there are no changes.
+func (d TreeDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// Count returns the number of changes of the given kind.
+func (d TreeDiff) Count(kind ChangeKind) int {
+	var res int
+
+	for _, c := range d.Changes {
+		if c.Kind == kind {
+			res++
+		}
+	}
+
+	return res
+}
+
+// Summary returns the number of changes of each kind.
+func (d TreeDiff) Summary() string {
+	return fmt.Sprintf("%d added, %d removed, %d modified",
+		d.Count(ChangeAdded), d.Count(ChangeRemoved), d.Count(ChangeModified))
+}
+
+func (d TreeDiff) String() string {
+	var sb strings.Builder
+
+	for _, c := range d.Changes {
+		fmt.Fprintf(&sb, "%s: %s\n", c.Kind, c.Path)
+	}
+
+	for _, c := range d.Changes {
+		sb.WriteString(c.Diff)
+	}
+
+	return sb.String()
+}
+
+// Diff returns the changes from s to the later snapshot after.
+func (s *Snapshot) Diff(after *Snapshot) TreeDiff {
+	var (
+		res  TreeDiff
+		i, j int
+	)
+
+	for i < len(s.Entries) || j < len(after.Entries) {
+		var (
+			before = entryAt(s.Entries, i)
+			now    = entryAt(after.Entries, j)
+		)
+
+		switch {
+		case now == nil || (before != nil && before.Path < now.Path):
+			res.Changes = append(res.Changes, s.change(ChangeRemoved, before, nil))
+			i++
+		case before == nil || now.Path < before.Path:
+			res.Changes = append(res.Changes, s.change(ChangeAdded, nil, now))
+			j++
+		default:
+			if before.modified(now) {
+				res.Changes = append(res.Changes, s.change(ChangeModified, before, now))
+			}
+
+			i, j = i+1, j+1
+		}
+	}
+
+	return res
+}
+
+func entryAt(ents []SnapshotEntry, i int) *SnapshotEntry {
+	if i >= len(ents) {
+		return nil
+	}
+
+	return &ents[i]
+}
+
+func (e *SnapshotEntry) modified(other *SnapshotEntry) bool {
+	return e.Type != other.Type ||
+		e.Mode != other.Mode ||
+		e.Digest != other.Digest ||
+		e.Target != other.Target
+}
+
+// change describes the change of an entity from before to after
+// either of which is nil for added and removed entities.
+func (s *Snapshot) change(kind ChangeKind, before, after *SnapshotEntry) Change {
+	res := Change{Kind: kind, Before: before, After: after}
+
+	var (
+		from, to = "/dev/null", "/dev/null"
+		a, b     string
+	)
+
+	if before != nil {
+		res.Path, from = before.Path, "a/"+before.Path
+
+		if before.Type == EntTypeFile {
+			if before.text == nil {
+				return res
+			}
+
+			a = *before.text
+		}
+	}
+
+	if after != nil {
+		res.Path, to = after.Path, "b/"+after.Path
+
+		if after.Type == EntTypeFile {
+			if after.text == nil {
+				return res
+			}
+
+			b = *after.text
+		}
+	}
+
+	// only files have contents to diff
+	if (before == nil || before.Type != EntTypeFile) && (after == nil || after.Type != EntTypeFile) {
+		return res
+	}
+
+	res.Diff = unifiedDiff(from, to, a, b, s.context)
+
+	return res
+}
+
+// DiffAfter snapshots root before and after calling run returning
+// the changes made and an error wrapping ErrTreeChanged if any
+// were. Commands may be run by passing the Run method of an
+// exec.Cmd, e.g. to check generated files are up to date.
+func DiffAfter(root string, run func() error, opts ...SnapshotOption) (TreeDiff, error) {
+	before, err := TakeSnapshot(root, opts...)
+	if err != nil {
+		return TreeDiff{}, err
+	}
+
+	if err := run(); err != nil {
+		return TreeDiff{}, fmt.Errorf("running: %w", err)
+	}
+
+	after, err := TakeSnapshot(root, opts...)
+	if err != nil {
+		return TreeDiff{}, err
+	}
+
+	res := before.Diff(after)
+	if !res.Empty() {
+		return res, fmt.Errorf("%q: %w: %s", root, ErrTreeChanged, res.Summary())
+	}
+
+	return res, nil
+}
+
+type snapshotConfig struct {
+	ContextLines int
+	Excludes     []string
+	GitIgnore    bool
+	MaxDiffSize  int64
+}
+
+func (c *snapshotConfig) Option(opts ...SnapshotOption) {
+	for _, opt := range opts {
+		opt.ConfigureSnapshot(c)
+	}
+}
+
+func (c *snapshotConfig) Default() {
+	c.ContextLines = max(c.ContextLines, 0)
+}
+
+type SnapshotOption interface {
+	ConfigureSnapshot(*snapshotConfig)
+}
+
+// WithContextLines sets the number of unchanged lines surrounding
+// changes in diffs, by default 3, or the number of lines surrounding
+// matches found by Search, by default none.
+type WithContextLines int
+
+func (l WithContextLines) ConfigureSnapshot(c *snapshotConfig) {
+	c.ContextLines = int(l)
+}
+
+// WithMaxDiffSize sets the size in bytes of the largest text
+// files whose contents are retained by snapshots to produce
+// diffs. By default files up to 1 MiB are retained.
+type WithMaxDiffSize int64
+
+func (s WithMaxDiffSize) ConfigureSnapshot(c *snapshotConfig) {
+	c.MaxDiffSize = int64(s)
+}
+
+func (e WithExcludes) ConfigureSnapshot(c *snapshotConfig) {
+	c.Excludes = append(c.Excludes, e...)
+}
+
+func (g WithGitIgnore) ConfigureSnapshot(c *snapshotConfig) {
+	c.GitIgnore = bool(g)
+}
@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mt-sre/go-ci/file"
+	"github.com/mt-sre/go-ci/internal/filetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotDiff(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	filetest.WriteTree(t, root, map[string]string{
+		"binary":    "\x00\x01",
+		"exec":      "#!/bin/sh\n",
+		"gen/a.go":  "package gen\n",
+		"gen/old":   "removed\n",
+		"link-dest": "",
+		"text":      "x\ny",
+		"vendor/x":  "excluded",
+	})
+	require.NoError(t, os.Symlink("text", filepath.Join(root, "link")))
+
+	opts := []file.SnapshotOption{file.WithExcludes{"vendor/"}}
+
+	before, err := file.TakeSnapshot(root, opts...)
+	require.NoError(t, err)
+
+	filetest.WriteTree(t, root, map[string]string{
+		"binary":   "\x00\x02",
+		"gen/a.go": "package gen\n\nconst A = 1\n",
+		"gen/b.go": "package gen\n",
+		"text":     "x\nz\n",
+		"vendor/x": "changed",
+	})
+	require.NoError(t, os.Remove(filepath.Join(root, "gen", "old")))
+	require.NoError(t, os.Chmod(filepath.Join(root, "exec"), 0o755))
+	require.NoError(t, os.Remove(filepath.Join(root, "link")))
+	require.NoError(t, os.Symlink("link-dest", filepath.Join(root, "link")))
+
+	after, err := file.TakeSnapshot(root, opts...)
+	require.NoError(t, err)
+
+	diff := before.Diff(after)
+
+	var changes []string
+	for _, c := range diff.Changes {
+		changes = append(changes, fmt.Sprintf("%s %s", c.Kind, c.Path))
+	}
+
+	assert.Equal(t, []string{
+		"modified binary",
+		"modified exec",
+		"modified gen/a.go",
+		"added gen/b.go",
+		"removed gen/old",
+		"modified link",
+		"modified text",
+	}, changes)
+	assert.Equal(t, "1 added, 1 removed, 5 modified", diff.Summary())
+
+	diffs := make(map[string]string)
+	for _, c := range diff.Changes {
+		diffs[c.Path] = c.Diff
+	}
+
+	assert.Equal(t, map[string]string{
+		"binary": "",
+		"exec":   "",
+		"gen/a.go": "--- a/gen/a.go\n+++ b/gen/a.go\n" +
+			"@@ -1 +1,3 @@\n package gen\n+\n+const A = 1\n",
+		"gen/b.go": "--- /dev/null\n+++ b/gen/b.go\n" +
+			"@@ -0,0 +1 @@\n+package gen\n",
+		"gen/old": "--- a/gen/old\n+++ /dev/null\n" +
+			"@@ -1 +0,0 @@\n-removed\n",
+		"link": "",
+		"text": "--- a/text\n+++ b/text\n" +
+			"@@ -1,2 +1,2 @@\n x\n-y\n\\ No newline at end of file\n+z\n",
+	}, diffs)
+
+	assert.True(t, before.Diff(before).Empty())
+}
+
+func TestSnapshotDiffHunks(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprint(i))
+	}
+
+	for name, tc := range map[string]struct {
+		Options  []file.SnapshotOption
+		Expected string
+	}{
+		"default context": {
+			Expected: "--- a/f\n+++ b/f\n" +
+				"@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n" +
+				"@@ -15,6 +15,6 @@\n 15\n 16\n 17\n-18\n+eighteen\n 19\n 20\n",
+		},
+		"no context": {
+			Options: []file.SnapshotOption{file.WithContextLines(0)},
+			Expected: "--- a/f\n+++ b/f\n" +
+				"@@ -2 +2 @@\n-2\n+two\n" +
+				"@@ -18 +18 @@\n-18\n+eighteen\n",
+		},
+		"merged hunks": {
+			Options: []file.SnapshotOption{file.WithContextLines(8)},
+			Expected: "--- a/f\n+++ b/f\n" +
+				"@@ -1,20 +1,20 @@\n 1\n-2\n+two\n" +
+				" " + strings.Join(lines[2:17], "\n ") + "\n" +
+				"-18\n+eighteen\n 19\n 20\n",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root := t.TempDir()
+
+			filetest.WriteTree(t, root, map[string]string{"f": strings.Join(lines, "\n") + "\n"})
+
+			before, err := file.TakeSnapshot(root, tc.Options...)
+			require.NoError(t, err)
+
+			modified := strings.NewReplacer("\n2\n", "\ntwo\n", "\n18\n", "\neighteen\n").
+				Replace(strings.Join(lines, "\n") + "\n")
+			filetest.WriteTree(t, root, map[string]string{"f": modified})
+
+			after, err := file.TakeSnapshot(root, tc.Options...)
+			require.NoError(t, err)
+
+			diff := before.Diff(after)
+			require.Len(t, diff.Changes, 1)
+
+			assert.Equal(t, tc.Expected, diff.Changes[0].Diff)
+		})
+	}
+}
+
+func TestDiffAfter(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	filetest.WriteTree(t, root, map[string]string{"generated.txt": "v1\n"})
+
+	generate := func(data string) func() error {
+		return func() error {
+			return os.WriteFile(filepath.Join(root, "generated.txt"), []byte(data), 0o644)
+		}
+	}
+
+	diff, err := file.DiffAfter(root, generate("v1\n"))
+	require.NoError(t, err)
+
+	assert.True(t, diff.Empty())
+
+	diff, err = file.DiffAfter(root, generate("v2\n"))
+	require.ErrorIs(t, err, file.ErrTreeChanged)
+
+	assert.Equal(t, "modified: generated.txt\n"+
+		"--- a/generated.txt\n+++ b/generated.txt\n@@ -1 +1 @@\n-v1\n+v2\n", diff.String())
+
+	errRun := errors.New("generator failed")
+
+	_, err = file.DiffAfter(root, func() error { return errRun })
+	assert.ErrorIs(t, err, errRun)
+}
@@ -0,0 +1,434 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrTextProblems is returned when files have
+// text problems which were not fixed.
+var ErrTextProblems = errors.New("text problems found")
+
+// TextIssue describes a problem with the text of a file.
+type TextIssue string
+
+const (
+	// IssueTrailingWhitespace is a line ending in spaces or tabs.
+	IssueTrailingWhitespace TextIssue = "trailing whitespace"
+	// IssueMissingFinalNewline is a non-empty file
+	// whose last line is not terminated.
+	IssueMissingFinalNewline TextIssue = "missing final newline"
+	// IssueTrailingBlankLines is a file ending in blank lines.
+	IssueTrailingBlankLines TextIssue = "trailing blank lines"
+	// IssueCRLF is a file with lines terminated by "\r\n".
+	IssueCRLF TextIssue = "CRLF line endings"
+	// IssueBOM is a file starting with a UTF-8 byte order mark.
+	IssueBOM TextIssue = "byte order mark"
+	// IssueInvalidUTF8 is a line which is not valid UTF-8.
+	IssueInvalidUTF8 TextIssue = "invalid UTF-8"
+	// IssueConflictMarker is a line holding a merge conflict marker.
+	IssueConflictMarker TextIssue = "merge conflict marker"
+	// IssueTooLarge is a file larger than the configured maximum size.
+	IssueTooLarge TextIssue = "file too large"
+)
+
+// fixable reports whether the issue is fixed by "WithFix".
+func (i TextIssue) fixable() bool {
+	switch i {
+	case IssueTrailingWhitespace, IssueMissingFinalNewline, IssueTrailingBlankLines, IssueCRLF, IssueBOM:
+		return true
+	default:
+		return false
+	}
+}
+
+// TextProblem describes an issue found in a file.
+type TextProblem struct {
+	Path  string
+	Issue TextIssue
+	// Line is the one based line number of the issue
+	// or zero for issues with the file as a whole.
+	Line   int
+	Detail string
+	// Fixed is set when the file was rewritten to fix the issue.
+	Fixed bool
+}
+
+func (p TextProblem) String() string {
+	var b strings.Builder
+
+	b.WriteString(p.Path)
+
+	if p.Line > 0 {
+		fmt.Fprintf(&b, ":%d", p.Line)
+	}
+
+	fmt.Fprintf(&b, ": %s", p.Issue)
+
+	if p.Detail != "" {
+		fmt.Fprintf(&b, " (%s)", p.Detail)
+	}
+
+	if p.Fixed {
+		b.WriteString(" [fixed]")
+	}
+
+	return b.String()
+}
+
+// TextReport details the result of checking files.
+type TextReport struct {
+	// Problems are ordered by path, as given, and line.
+	Problems []TextProblem
+	// Fixed lists the files which were rewritten.
+	Fixed []string
+}
+
+// OK reports whether every problem found was fixed.
+func (r TextReport) OK() bool {
+	for _, p := range r.Problems {
+		if !p.Fixed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String lists problems in the form "<path>:<line>: <issue>".
+func (r TextReport) String() string {
+	var b strings.Builder
+
+	for _, p := range r.Problems {
+		b.WriteString(p.String())
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// CheckText checks the files at the given paths, e.g. as returned
+// by Find, for trailing whitespace, missing final newlines, trailing
+// blank lines, CRLF line endings, byte order marks, invalid UTF-8,
+// merge conflict markers and files larger than the configured
+// "WithMaxFileSize". Only the size of binary files is checked.
+// A report and an error wrapping ErrTextProblems are returned when
+// problems remain after applying any fixes enabled by "WithFix".
+func CheckText(paths []string, opts ...TextOption) (TextReport, error) {
+	cfg := textConfig{MaxFileSize: 500 << 10}
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	var (
+		problems = make([][]TextProblem, len(paths))
+		fixed    = make([]bool, len(paths))
+		errs     = make([]error, len(paths))
+		sem      = make(chan struct{}, cfg.Workers)
+		wg       sync.WaitGroup
+	)
+
+	for i, p := range paths {
+		sem <- struct{}{}
+
+		wg.Add(1)
+
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			problems[i], fixed[i], errs[i] = cfg.check(p)
+		}()
+	}
+
+	wg.Wait()
+
+	var res TextReport
+
+	for i, p := range paths {
+		res.Problems = append(res.Problems, problems[i]...)
+
+		if fixed[i] {
+			res.Fixed = append(res.Fixed, p)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return res, err
+	}
+
+	if !res.OK() {
+		return res, fmt.Errorf("%w: %s", ErrTextProblems, res.summary())
+	}
+
+	return res, nil
+}
+
+// summary returns the number of problems left unfixed.
+func (r TextReport) summary() string {
+	files := make(map[string]struct{})
+
+	var n int
+
+	for _, p := range r.Problems {
+		if !p.Fixed {
+			files[p.Path] = struct{}{}
+			n++
+		}
+	}
+
+	return fmt.Sprintf("%d problems in %d files", n, len(files))
+}
+
+// check reports the problems of the file at path, fixing them
+// when configured to, and whether the file was rewritten.
+func (c *textConfig) check(path string) ([]TextProblem, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("checking %q: %w", path, err)
+	}
+
+	if c.MaxFileSize >= 0 && info.Size() > c.MaxFileSize {
+		return []TextProblem{{
+			Path:   path,
+			Issue:  IssueTooLarge,
+			Detail: fmt.Sprintf("%d bytes exceeds %d bytes", info.Size(), c.MaxFileSize),
+		}}, false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("checking %q: %w", path, err)
+	}
+
+	if isBinary(data) {
+		return nil, false, nil
+	}
+
+	markdown := slices.Contains(c.MarkdownExts, strings.ToLower(filepath.Ext(path)))
+
+	res, fixed := checkText(data, markdown)
+	if len(res) == 0 {
+		return nil, false, nil
+	}
+
+	for i := range res {
+		res[i].Path = path
+	}
+
+	if !c.Fix || bytes.Equal(fixed, data) {
+		return res, false, nil
+	}
+
+	if err := WriteFileAtomic(path, fixed); err != nil {
+		return res, false, fmt.Errorf("fixing %q: %w", path, err)
+	}
+
+	for i := range res {
+		res[i].Fixed = res[i].Issue.fixable()
+	}
+
+	return res, true, nil
+}
+
+var bom = []byte("\xef\xbb\xbf")
+
+// checkText returns the problems of data along with data with
+// the fixable problems fixed. Lines of Markdown may end in two
+// spaces marking hard line breaks.
+func checkText(data []byte, markdown bool) ([]TextProblem, []byte) {
+	var (
+		res   []TextProblem
+		fixed = make([]byte, 0, len(data))
+		text  = data
+		crlf  int
+		first int
+		// separators are the lines of "=======" within a
+		// conflict which are only markers if it is closed
+		separators []int
+		inConflict bool
+	)
+
+	if bytes.HasPrefix(text, bom) {
+		res = append(res, TextProblem{Issue: IssueBOM, Line: 1})
+		text = text[len(bom):]
+	}
+
+	lines := bytes.SplitAfter(text, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	for i, line := range lines {
+		num := i + 1
+
+		line = bytes.TrimSuffix(line, []byte("\n"))
+
+		if bytes.HasSuffix(line, []byte("\r")) {
+			line = line[:len(line)-1]
+
+			if crlf++; first == 0 {
+				first = num
+			}
+		}
+
+		if trimmed := bytes.TrimRight(line, " \t"); len(trimmed) < len(line) {
+			if markdown && len(trimmed) > 0 && bytes.HasSuffix(line, []byte("  ")) {
+				trimmed = append(trimmed[:len(trimmed):len(trimmed)], "  "...)
+			}
+
+			if len(trimmed) < len(line) {
+				res = append(res, TextProblem{Issue: IssueTrailingWhitespace, Line: num})
+				line = trimmed
+			}
+		}
+
+		if !utf8.Valid(line) {
+			res = append(res, TextProblem{Issue: IssueInvalidUTF8, Line: num})
+		}
+
+		switch {
+		case isConflictMarker(line):
+			res = append(res, TextProblem{Issue: IssueConflictMarker, Line: num})
+
+			if bytes.HasPrefix(line, []byte("<<<<<<< ")) {
+				inConflict, separators = true, nil
+			} else if inConflict && bytes.HasPrefix(line, []byte(">>>>>>> ")) {
+				for _, sep := range separators {
+					res = append(res, TextProblem{Issue: IssueConflictMarker, Line: sep})
+				}
+
+				inConflict, separators = false, nil
+			}
+		case inConflict && string(line) == "=======":
+			separators = append(separators, num)
+		}
+
+		fixed = append(fixed, line...)
+		fixed = append(fixed, '\n')
+	}
+
+	if crlf > 0 {
+		res = append(res, TextProblem{
+			Issue: IssueCRLF, Line: first,
+			Detail: fmt.Sprintf("%d of %d lines", crlf, len(lines)),
+		})
+	}
+
+	if len(text) > 0 && text[len(text)-1] != '\n' {
+		res = append(res, TextProblem{Issue: IssueMissingFinalNewline, Line: len(lines)})
+	}
+
+	trimmed := bytes.TrimRight(fixed, "\n")
+
+	if blank := len(fixed) - len(trimmed); blank > 1 || (blank == 1 && len(trimmed) == 0) {
+		num := 1
+		if len(trimmed) > 0 {
+			num = bytes.Count(trimmed, []byte("\n")) + 2
+			trimmed = append(trimmed, '\n')
+		}
+
+		res = append(res, TextProblem{Issue: IssueTrailingBlankLines, Line: num})
+		fixed = trimmed
+	}
+
+	slices.SortStableFunc(res, func(a, b TextProblem) int {
+		return cmp.Compare(a.Line, b.Line)
+	})
+
+	return res, fixed
+}
+
+// isConflictMarker reports whether line holds a marker left by
+// git when merging conflicting changes other than "=======" which
+// is only a marker between the start and end of a conflict as it
+// also underlines Markdown and reStructuredText headings.
+func isConflictMarker(line []byte) bool {
+	for _, marker := range []string{"<<<<<<< ", ">>>>>>> ", "||||||| "} {
+		if bytes.HasPrefix(line, []byte(marker)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type textConfig struct {
+	Fix          bool
+	MarkdownExts []string
+	MaxFileSize  int64
+	Workers      int
+}
+
+func (c *textConfig) Option(opts ...TextOption) {
+	for _, opt := range opts {
+		opt.ConfigureText(c)
+	}
+}
+
+func (c *textConfig) Default() {
+	if c.MarkdownExts == nil {
+		c.MarkdownExts = []string{".markdown", ".md"}
+	}
+
+	if c.Workers < 1 {
+		c.Workers = runtime.GOMAXPROCS(0)
+	}
+}
+
+type TextOption interface {
+	ConfigureText(*textConfig)
+}
+
+// WithFix rewrites files to remove trailing whitespace, other than
+// Markdown hard line breaks, trailing blank lines and byte order
+// marks, convert CRLF line endings to LF and add missing final
+// newlines. Other problems are reported but must be fixed by hand.
+type WithFix bool
+
+func (f WithFix) ConfigureText(c *textConfig) {
+	c.Fix = bool(f)
+}
+
+// WithMarkdownExts sets the extensions, e.g. ".md", of Markdown
+// files whose lines may end in two spaces marking hard line breaks
+// which are kept when fixing trailing whitespace. By default ".md"
+// and ".markdown" are used and an empty, non-nil list disables the
+// exception.
+type WithMarkdownExts []string
+
+func (e WithMarkdownExts) ConfigureText(c *textConfig) {
+	c.MarkdownExts = make([]string, 0, len(e))
+
+	for _, ext := range e {
+		c.MarkdownExts = append(c.MarkdownExts, strings.ToLower(ext))
+	}
+}
+
+// WithMaxFileSize sets the size in bytes of the largest files which
+// are accepted. By default files up to 500 KiB are accepted and a
+// negative size accepts files of any size.
+type WithMaxFileSize int64
+
+func (s WithMaxFileSize) ConfigureText(c *textConfig) {
+	c.MaxFileSize = int64(s)
+}
+
+func (w WithWorkers) ConfigureText(c *textConfig) {
+	c.Workers = int(w)
+}
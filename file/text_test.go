@@ -0,0 +1,214 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file_test
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mt-sre/go-ci/file"
+	"github.com/mt-sre/go-ci/internal/filetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckText(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Name     string
+		Data     string
+		Options  []file.TextOption
+		Expected []string
+		Fixed    string
+	}{
+		"clean": {
+			Data:  "a\n\tb\n",
+			Fixed: "a\n\tb\n",
+		},
+		"empty": {
+			Data:  "",
+			Fixed: "",
+		},
+		"trailing whitespace": {
+			Data:     "a \nb\t\n  \nc\n",
+			Expected: []string{"1: trailing whitespace", "2: trailing whitespace", "3: trailing whitespace"},
+			Fixed:    "a\nb\n\nc\n",
+		},
+		"markdown line breaks": {
+			Name:     "README.MD",
+			Data:     "a  \nb \t  \n  \nc \n",
+			Expected: []string{"2: trailing whitespace", "3: trailing whitespace", "4: trailing whitespace"},
+			Fixed:    "a  \nb  \n\nc\n",
+		},
+		"markdown line breaks disabled": {
+			Name:     "README.md",
+			Data:     "a  \n",
+			Options:  []file.TextOption{file.WithMarkdownExts{}},
+			Expected: []string{"1: trailing whitespace"},
+			Fixed:    "a\n",
+		},
+		"markdown extensions": {
+			Name:    "notes.txt",
+			Data:    "a  \n",
+			Options: []file.TextOption{file.WithMarkdownExts{".TXT"}},
+			Fixed:   "a  \n",
+		},
+		"missing final newline": {
+			Data:     "a\nb",
+			Expected: []string{"2: missing final newline"},
+			Fixed:    "a\nb\n",
+		},
+		"trailing blank lines": {
+			Data:     "a\n\n \n",
+			Expected: []string{"2: trailing blank lines", "3: trailing whitespace"},
+			Fixed:    "a\n",
+		},
+		"only blank lines": {
+			Data:     "\n",
+			Expected: []string{"1: trailing blank lines"},
+			Fixed:    "",
+		},
+		"crlf": {
+			Data:     "a\nb\r\nc\r\n",
+			Expected: []string{"2: CRLF line endings (2 of 3 lines)"},
+			Fixed:    "a\nb\nc\n",
+		},
+		"bom": {
+			Data:     "\xef\xbb\xbfa\n",
+			Expected: []string{"1: byte order mark"},
+			Fixed:    "a\n",
+		},
+		"invalid utf-8": {
+			Data:     "a\n\xff\n",
+			Expected: []string{"2: invalid UTF-8"},
+			Fixed:    "a\n\xff\n",
+		},
+		"conflict markers": {
+			Data:     "<<<<<<< HEAD\na\n=======\nb\n>>>>>>> branch\n",
+			Expected: []string{"1: merge conflict marker", "3: merge conflict marker", "5: merge conflict marker"},
+			Fixed:    "<<<<<<< HEAD\na\n=======\nb\n>>>>>>> branch\n",
+		},
+		"heading underline": {
+			Data:  "Title\n=======\n",
+			Fixed: "Title\n=======\n",
+		},
+		"unterminated conflict": {
+			Data:     "<<<<<<< HEAD\nTitle\n=======\n",
+			Expected: []string{"1: merge conflict marker"},
+			Fixed:    "<<<<<<< HEAD\nTitle\n=======\n",
+		},
+		"binary": {
+			Data:  "\x00 \r\n",
+			Fixed: "\x00 \r\n",
+		},
+		"too large": {
+			Data:     "abc \n",
+			Options:  []file.TextOption{file.WithMaxFileSize(4)},
+			Expected: []string{" file too large (5 bytes exceeds 4 bytes)"},
+			Fixed:    "abc \n",
+		},
+		"unlimited size": {
+			Data:    "abc\n",
+			Options: []file.TextOption{file.WithMaxFileSize(-1)},
+			Fixed:   "abc\n",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), cmp.Or(tc.Name, "f"))
+
+			require.NoError(t, os.WriteFile(path, []byte(tc.Data), 0o600))
+
+			res, err := file.CheckText([]string{path}, tc.Options...)
+			if tc.Expected == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, file.ErrTextProblems)
+			}
+
+			assert.Equal(t, tc.Expected, problemLines(path, res))
+
+			res, err = file.CheckText([]string{path}, append(tc.Options, file.WithFix(true))...)
+
+			data, readErr := os.ReadFile(path)
+			require.NoError(t, readErr)
+
+			assert.Equal(t, tc.Fixed, string(data))
+
+			for _, p := range res.Problems {
+				if p.Fixed {
+					assert.Equal(t, []string{path}, res.Fixed)
+				}
+			}
+
+			if res.OK() {
+				require.NoError(t, err)
+
+				res, err = file.CheckText([]string{path}, tc.Options...)
+				require.NoError(t, err)
+
+				assert.Empty(t, res.Problems)
+			} else {
+				require.ErrorIs(t, err, file.ErrTextProblems)
+			}
+
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+
+			assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+		})
+	}
+}
+
+func TestCheckTextReport(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	filetest.WriteTree(t, root, map[string]string{
+		"a.go":         "package a \n",
+		"b.md":         "<<<<<<< HEAD\r\n",
+		"c.txt":        "ok\n",
+		"vendor/x.go":  "ignored ",
+		"testdata/bin": "\x00",
+	})
+
+	paths, err := file.Find(root,
+		file.WithEntType(file.EntTypeFile),
+		file.WithExcludes{"vendor/"},
+		file.WithSorted(true),
+	)
+	require.NoError(t, err)
+
+	res, err := file.CheckText(paths, file.WithFix(true), file.WithWorkers(2))
+	require.ErrorIs(t, err, file.ErrTextProblems)
+
+	assert.EqualError(t, err, "text problems found: 1 problems in 1 files")
+	assert.False(t, res.OK())
+	assert.Equal(t, []string{filepath.Join(root, "a.go"), filepath.Join(root, "b.md")}, res.Fixed)
+	assert.Equal(t, ""+
+		filepath.Join(root, "a.go")+":1: trailing whitespace [fixed]\n"+
+		filepath.Join(root, "b.md")+":1: merge conflict marker\n"+
+		filepath.Join(root, "b.md")+":1: CRLF line endings (1 of 1 lines) [fixed]\n", res.String())
+}
+
+// problemLines returns the problems of res without the given
+// path prefixing each.
+func problemLines(path string, res file.TextReport) []string {
+	var lines []string
+
+	for _, p := range res.Problems {
+		lines = append(lines, strings.TrimPrefix(strings.TrimPrefix(p.String(), path), ":"))
+	}
+
+	return lines
+}
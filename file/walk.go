@@ -0,0 +1,536 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+// errStop is returned by visit functions to stop walking early.
+var errStop = errors.New("stop walking")
+
+// walker walks the directory tree below root
+// visiting the entities matching its config.
+type walker struct {
+	cfg  *findConfig
+	fsys fs.FS
+	root string
+	// name is matched against the root entity rather
+	// than the base of root which may be "."
+	name string
+	// ign holds rules applying to root loaded from outside fsys
+	ign *gitIgnore
+	// path returns the path of entities as given to callers
+	path func(e *entry) string
+	// visit is called for each matched entity and report, when
+	// set, for errors skipped by ErrorPolicySkip. Calls are
+	// serialized and returning an error stops walking.
+	visit  func(e *entry) error
+	report func(err error) error
+	// withInfo loads the info of entities before visiting
+	withInfo bool
+
+	mu sync.Mutex
+	// errs collects skipped errors when report is not set
+	errs []error
+	// err is the first error which stopped walking
+	err     error
+	stopped atomic.Bool
+	// sem limits the directories walked concurrently
+	sem chan struct{}
+	wg  sync.WaitGroup
+}
+
+// entry is an entity encountered while walking.
+type entry struct {
+	fsys fs.FS
+	// path is the slash separated path within fsys
+	path string
+	name string
+	// rel is the slash separated path relative to root
+	rel   string
+	depth int
+	d     fs.DirEntry
+	// mode holds the type bits of the entity or,
+	// for followed symbolic links, of their target
+	mode fs.FileMode
+	// info is loaded lazily unless a link was followed
+	info     fs.FileInfo
+	followed bool
+	// ign holds the ignore rules applying below the entity
+	ign *gitIgnore
+	// ancestors are the directories containing the entity
+	// which are tracked to detect symbolic link loops
+	ancestors []fs.FileInfo
+}
+
+// Info returns the entity's file info, or that of the
+// target for followed symbolic links.
+func (e *entry) Info() (fs.FileInfo, error) {
+	if e.info != nil {
+		return e.info, nil
+	}
+
+	info, err := e.d.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	e.info = info
+
+	return info, nil
+}
+
+// collect walks returning the path of every visited entity.
+func (w *walker) collect() ([]string, error) {
+	ents, err := w.collectEntries(false)
+	if ents == nil {
+		return nil, err
+	}
+
+	res := make([]string, 0, len(ents))
+	for _, ent := range ents {
+		res = append(res, ent.Path)
+	}
+
+	return res, err
+}
+
+// seq returns an iterator over the path of every visited
+// entity. Errors are yielded with an empty path.
+func (w *walker) seq() iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		// sorting requires every result before yielding any
+		if w.cfg.Sort != SortByNone {
+			res, err := w.collect()
+
+			for _, p := range res {
+				if !yield(p, nil) {
+					return
+				}
+			}
+
+			if err != nil {
+				yield("", err)
+			}
+
+			return
+		}
+
+		type result struct {
+			path string
+			err  error
+		}
+
+		var (
+			results = make(chan result)
+			done    = make(chan struct{})
+		)
+
+		send := func(r result) error {
+			select {
+			case results <- r:
+				return nil
+			case <-done:
+				return errStop
+			}
+		}
+
+		w.visit = func(e *entry) error {
+			return send(result{path: w.path(e)})
+		}
+		w.report = func(err error) error {
+			return send(result{err: err})
+		}
+
+		go func() {
+			defer close(results)
+
+			if err := w.run(); err != nil {
+				_ = send(result{err: fmt.Errorf("walking directories: %w", err)})
+			}
+		}()
+
+		// drain results until the walk has stopped
+		defer func() {
+			close(done)
+
+			for range results {
+			}
+		}()
+
+		for r := range results {
+			if !yield(r.path, r.err) {
+				return
+			}
+		}
+	}
+}
+
+// run walks from root returning the error, if any,
+// which stopped walking once all workers have finished.
+func (w *walker) run() error {
+	info, err := lstat(w.fsys, w.root)
+	if err != nil {
+		return err
+	}
+
+	w.sem = make(chan struct{}, w.cfg.Workers-1)
+
+	root := &entry{
+		fsys: w.fsys,
+		path: w.root,
+		name: w.name,
+		rel:  ".",
+		d:    fs.FileInfoToDirEntry(info),
+		mode: info.Mode().Type(),
+		info: info,
+	}
+
+	if w.cfg.GitIgnore {
+		root.ign = w.ign
+		if root.ign == nil {
+			root.ign = &gitIgnore{}
+		}
+
+		if root.ign, err = root.ign.loadDir(w.fsys, w.root, "."); err != nil {
+			return fmt.Errorf("loading ignore files: %w", err)
+		}
+	}
+
+	if w.cfg.Follow && info.Mode()&fs.ModeSymlink != 0 {
+		if err := w.follow(root); err != nil {
+			return err
+		}
+	}
+
+	w.walkAsync(root)
+	w.wg.Wait()
+
+	if errors.Is(w.err, errStop) {
+		return nil
+	}
+
+	return w.err
+}
+
+// walkAsync walks e recording any error which stops walking.
+func (w *walker) walkAsync(e *entry) {
+	if err := w.walk(e); err != nil {
+		w.stop(err)
+	}
+}
+
+// stop stops walking recording err if it is the first error.
+func (w *walker) stop(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err == nil {
+		w.err = err
+	}
+
+	w.stopped.Store(true)
+}
+
+// follow replaces the type and info of the symbolic link e
+// with those of its target. Dangling links are left as is.
+func (w *walker) follow(e *entry) error {
+	info, err := fs.Stat(w.fsys, e.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return w.handleErr(e.path, err)
+	}
+
+	e.info, e.mode, e.followed = info, info.Mode().Type(), true
+
+	return nil
+}
+
+func (w *walker) walk(e *entry) error {
+	if w.stopped.Load() {
+		return nil
+	}
+
+	if e.depth > 0 {
+		pruned, err := w.pruned(e)
+		if err != nil || pruned {
+			return err
+		}
+	}
+
+	matches, err := w.matches(e)
+	if err != nil {
+		return err
+	}
+
+	if matches && w.withInfo {
+		if _, err := e.Info(); err != nil {
+			return w.handleErr(e.path, err)
+		}
+	}
+
+	if matches {
+		if err := w.emit(e); err != nil {
+			return err
+		}
+	}
+
+	if !e.mode.IsDir() || (w.cfg.MaxDepth >= 0 && e.depth >= w.cfg.MaxDepth) {
+		return nil
+	}
+
+	ancestors := e.ancestors
+
+	if w.cfg.Follow {
+		info, err := e.Info()
+		if err != nil {
+			return w.handleErr(e.path, err)
+		}
+
+		// loops are only detected for file info
+		// implementations supported by os.SameFile
+		for _, ancestor := range ancestors {
+			if e.followed && os.SameFile(ancestor, info) {
+				return w.handleErr(e.path, fmt.Errorf("following %q: %w", e.path, ErrSymlinkLoop))
+			}
+		}
+
+		ancestors = append(ancestors[:len(ancestors):len(ancestors)], info)
+	}
+
+	// like filepath.WalkDir entries read before
+	// an error are walked before reporting it
+	ents, readErr := fs.ReadDir(w.fsys, e.path)
+
+	for _, d := range ents {
+		if w.stopped.Load() {
+			return nil
+		}
+
+		child := &entry{
+			fsys:      w.fsys,
+			path:      path.Join(e.path, d.Name()),
+			name:      d.Name(),
+			rel:       path.Join(e.rel, d.Name()),
+			depth:     e.depth + 1,
+			d:         d,
+			mode:      d.Type(),
+			ign:       e.ign,
+			ancestors: ancestors,
+		}
+
+		if w.cfg.Follow && d.Type()&fs.ModeSymlink != 0 {
+			if err := w.follow(child); err != nil {
+				return err
+			}
+		}
+
+		// directories are handed to idle workers
+		// and otherwise walked depth first in order
+		if child.mode.IsDir() {
+			select {
+			case w.sem <- struct{}{}:
+				w.wg.Add(1)
+
+				go func() {
+					defer func() {
+						<-w.sem
+						w.wg.Done()
+					}()
+
+					w.walkAsync(child)
+				}()
+
+				continue
+			default:
+			}
+		}
+
+		if err := w.walk(child); err != nil {
+			return err
+		}
+	}
+
+	if readErr != nil {
+		return w.handleErr(e.path, readErr)
+	}
+
+	return nil
+}
+
+// emit visits the matched entity e.
+func (w *walker) emit(e *entry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped.Load() {
+		return errStop
+	}
+
+	return w.visit(e)
+}
+
+// pruned reports whether e, and any entities below it,
+// are ignored by git or excluded. The .gitignore file of
+// directories which are not pruned is loaded.
+func (w *walker) pruned(e *entry) (bool, error) {
+	if e.ign != nil {
+		isDir := e.d.IsDir()
+
+		if isDir && e.name == ".git" {
+			return true, nil
+		}
+
+		if e.ign.ignored(e.rel, isDir) {
+			return true, nil
+		}
+
+		if isDir {
+			var err error
+
+			if e.ign, err = e.ign.loadDir(w.fsys, e.path, e.rel); err != nil {
+				return true, w.handleErr(e.path, err)
+			}
+		}
+	}
+
+	return w.cfg.excluded(e.rel, e.mode.IsDir())
+}
+
+// matches reports whether e satisfies every configured filter.
+func (w *walker) matches(e *entry) (bool, error) {
+	if e.depth < w.cfg.MinDepth || !w.cfg.EntType.matches(e.mode) {
+		return false, nil
+	}
+
+	matches, err := path.Match(w.cfg.Name, e.name)
+	if err != nil {
+		return false, fmt.Errorf("matching %q against %q: %w", e.path, w.cfg.Name, err)
+	}
+
+	if !matches {
+		return false, nil
+	}
+
+	if matches, err = w.cfg.included(e.rel); err != nil || !matches {
+		return false, err
+	}
+
+	for _, f := range w.cfg.Filters {
+		matches, err := f(e)
+		if err != nil {
+			return false, w.handleErr(e.path, err)
+		}
+
+		if !matches {
+			return false, nil
+		}
+	}
+
+	for _, p := range w.cfg.Predicates {
+		info, err := e.Info()
+		if err != nil {
+			return false, w.handleErr(e.path, err)
+		}
+
+		if !p(w.path(e), info) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// handleErr applies the configured ErrorPolicy to an error
+// encountered at path returning any error to stop walking.
+func (w *walker) handleErr(path string, err error) error {
+	if path == w.root {
+		return err
+	}
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	switch w.cfg.ErrorPolicy {
+	case ErrorPolicySkip:
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		if w.report == nil {
+			w.errs = append(w.errs, err)
+
+			return nil
+		}
+
+		return w.report(err)
+	case ErrorPolicyIgnore:
+		return nil
+	default:
+		return err
+	}
+}
+
+// lstat describes the named file without following symbolic
+// links when supported by fsys and otherwise follows them.
+func lstat(fsys fs.FS, name string) (fs.FileInfo, error) {
+	if fsys, ok := fsys.(lstatFS); ok {
+		return fsys.Lstat(name)
+	}
+
+	return fs.Stat(fsys, name)
+}
+
+// lstatFS is a filesystem able to describe
+// symbolic links without following them.
+type lstatFS interface {
+	fs.FS
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// osFS is a filesystem rooted at an OS directory
+// which, unlike os.DirFS alone, implements lstatFS.
+type osFS struct {
+	fs.FS
+	dir string
+}
+
+func newOSFS(dir string) osFS {
+	return osFS{FS: os.DirFS(dir), dir: dir}
+}
+
+func (f osFS) Lstat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: fs.ErrInvalid}
+	}
+
+	return os.Lstat(filepath.Join(f.dir, filepath.FromSlash(name)))
+}
+
+func (f osFS) ReadLink(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+
+	return os.Readlink(filepath.Join(f.dir, filepath.FromSlash(name)))
+}
+
+func (f osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(f.FS, name)
+}
+
+func (f osFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(f.FS, name)
+}
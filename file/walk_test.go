@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file_test
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mt-sre/go-ci/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func largeTree() fstest.MapFS {
+	fsys := fstest.MapFS{}
+
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 5; j++ {
+			for k := 0; k < 5; k++ {
+				fsys[fmt.Sprintf("d%02d/e%d/f%d.txt", i, j, k)] = &fstest.MapFile{}
+			}
+		}
+	}
+
+	return fsys
+}
+
+func TestFindWorkers(t *testing.T) {
+	t.Parallel()
+
+	fsys := largeTree()
+
+	expected, err := file.FindFS(fsys, ".", file.WithEntType(file.EntTypeFile))
+	require.NoError(t, err)
+	require.Len(t, expected, 500)
+	assert.True(t, slices.IsSorted(expected), "single workers walk in lexical order")
+
+	for _, workers := range []int{0, 2, 8} {
+		files, err := file.FindFS(fsys, ".",
+			file.WithEntType(file.EntTypeFile),
+			file.WithWorkers(workers),
+			file.WithSorted(true),
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, expected, files, workers)
+	}
+}
+
+func TestFindSeq(t *testing.T) {
+	t.Parallel()
+
+	fsys := largeTree()
+
+	for _, workers := range []int{1, 8} {
+		var files []string
+
+		for path, err := range file.FindFSSeq(fsys, ".", file.WithEntType(file.EntTypeFile), file.WithWorkers(workers)) {
+			require.NoError(t, err)
+
+			files = append(files, path)
+		}
+
+		assert.Len(t, files, 500, workers)
+
+		var first []string
+
+		for path, err := range file.FindFSSeq(fsys, ".", file.WithEntType(file.EntTypeFile), file.WithWorkers(workers)) {
+			require.NoError(t, err)
+
+			if first = append(first, path); len(first) == 3 {
+				break
+			}
+		}
+
+		assert.Len(t, first, 3, workers)
+	}
+}
+
+func TestFindSeqOS(t *testing.T) {
+	t.Parallel()
+
+	var files []string
+
+	for path, err := range file.FindSeq("./testdata", file.WithEntType(file.EntTypeFile), file.WithSorted(true)) {
+		require.NoError(t, err)
+
+		files = append(files, path)
+	}
+
+	assert.Equal(t, []string{
+		"testdata/a.txt",
+		"testdata/sub/b.txt",
+		"testdata/sub/c.notxt",
+	}, files)
+
+	for _, err := range file.FindSeq("./testdata/dne") {
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	}
+}
+
+func TestFindSeqErrors(t *testing.T) {
+	t.Parallel()
+
+	fsys := failingFS{
+		MapFS: fstest.MapFS{
+			"a.txt":        {},
+			"locked/b.txt": {},
+			"z.txt":        {},
+		},
+		dirs: map[string]error{"locked": fs.ErrPermission},
+	}
+
+	var (
+		files []string
+		errs  []error
+	)
+
+	for path, err := range file.FindFSSeq(fsys, ".", file.WithEntType(file.EntTypeFile), file.WithErrorPolicy(file.ErrorPolicySkip)) {
+		if err != nil {
+			assert.Empty(t, path)
+
+			errs = append(errs, err)
+
+			continue
+		}
+
+		files = append(files, path)
+	}
+
+	assert.Equal(t, []string{"a.txt", "z.txt"}, files)
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], fs.ErrPermission)
+}
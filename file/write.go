@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// WriteFileAtomic writes data to the named file atomically
+// as described by WriteAtomic.
+func WriteFileAtomic(name string, data []byte, opts ...WriteOption) error {
+	return WriteAtomic(name, func(w io.Writer) error {
+		_, err := io.Copy(w, bytes.NewReader(data))
+
+		return err
+	}, opts...)
+}
+
+// WriteAtomic replaces the named file with the data written by
+// the given function such that readers observe either the old or
+// the new contents but never a partial write. Data is written to
+// a temporary file in the same directory which is synced to disk
+// and renamed over the named file. The mode of an existing file is
+// preserved and symbolic links, including dangling ones, are
+// replaced at their destination. Nothing is written if the
+// function returns an error.
+func WriteAtomic(name string, write func(w io.Writer) error, opts ...WriteOption) error {
+	var cfg writeConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	name, err := resolveLink(name)
+	if err != nil {
+		return err
+	}
+
+	// new files are created with the configured permissions
+	// masked by the umask as they would be by os.WriteFile
+	perm, exact := cfg.Perm, false
+
+	info, err := os.Stat(name)
+	if err == nil {
+		perm, exact = info.Mode().Perm(), true
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("describing %q: %w", name, err)
+	}
+
+	return writeAtomic(name, perm, exact, func(f *os.File) error {
+		return write(f)
+	})
+}
+
+// resolveLink returns the path the named file is written to
+// after following symbolic links, including dangling ones.
+func resolveLink(name string) (string, error) {
+	orig := name
+
+	for range 255 {
+		info, err := os.Lstat(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			return name, nil
+		} else if err != nil {
+			return "", fmt.Errorf("describing %q: %w", name, err)
+		}
+
+		if info.Mode()&fs.ModeSymlink == 0 {
+			return name, nil
+		}
+
+		target, err := os.Readlink(name)
+		if err != nil {
+			return "", fmt.Errorf("reading link %q: %w", name, err)
+		}
+
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(name), target)
+		}
+
+		name = target
+	}
+
+	return "", fmt.Errorf("resolving %q: too many levels of symbolic links", orig)
+}
+
+// writeAtomic atomically replaces the named file with one of the
+// given permissions, masked by the umask unless exact is set,
+// containing the data written by the given function.
+func writeAtomic(name string, perm fs.FileMode, exact bool, write func(f *os.File) error) (err error) {
+	dir := filepath.Dir(name)
+
+	var tmp *os.File
+
+	err = withTempName(name, func(tmpName string) error {
+		tmp, err = os.OpenFile(tmpName, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("creating temporary file for %q: %w", name, err)
+	}
+
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err := write(tmp); err != nil {
+		return fmt.Errorf("writing %q: %w", name, err)
+	}
+
+	if exact {
+		if err := tmp.Chmod(perm); err != nil {
+			return fmt.Errorf("setting mode of %q: %w", name, err)
+		}
+	}
+
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("syncing %q: %w", name, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing %q: %w", name, err)
+	}
+
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("replacing %q: %w", name, err)
+	}
+
+	return syncDir(dir)
+}
+
+// withTempName calls create with unique names of temporary
+// files in the directory of name, as os.CreateTemp would use,
+// until it does not fail as the name already exists.
+func withTempName(name string, create func(tmp string) error) error {
+	dir, base := filepath.Split(name)
+
+	for range 10000 {
+		tmp := filepath.Join(dir, "."+base+".tmp-"+strconv.FormatUint(uint64(rand.Uint32()), 10))
+
+		if err := create(tmp); !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+	}
+
+	return &fs.PathError{Op: "createtemp", Path: filepath.Join(dir, "."+base+".tmp-*"), Err: fs.ErrExist}
+}
+
+// syncDir syncs the directory at the given path to disk
+// persisting the renaming of any entries.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("opening %q: %w", dir, err)
+	}
+
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("syncing %q: %w", dir, err)
+	}
+
+	return nil
+}
+
+type writeConfig struct {
+	Perm fs.FileMode
+}
+
+func (c *writeConfig) Option(opts ...WriteOption) {
+	for _, opt := range opts {
+		opt.ConfigureWrite(c)
+	}
+}
+
+func (c *writeConfig) Default() {
+	if c.Perm == 0 {
+		c.Perm = 0o644
+	}
+}
+
+type WriteOption interface {
+	ConfigureWrite(*writeConfig)
+}
+
+// WithPerm sets the permissions, masked by the umask as by
+// os.WriteFile, of files which do not already exist. By default
+// 0o644 is used.
+type WithPerm fs.FileMode
+
+func (p WithPerm) ConfigureWrite(c *writeConfig) {
+	c.Perm = fs.FileMode(p).Perm()
+}
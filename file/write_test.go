@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mt-sre/go-ci/file"
+	"github.com/mt-sre/go-ci/internal/filetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Existing     *fs.FileMode
+		Options      []file.WriteOption
+		ExpectedPerm fs.FileMode
+	}{
+		"new file": {
+			ExpectedPerm: 0o644,
+		},
+		"new file with perm": {
+			Options:      []file.WriteOption{file.WithPerm(0o600)},
+			ExpectedPerm: 0o600,
+		},
+		"new file masked by umask": {
+			Options:      []file.WriteOption{file.WithPerm(0o666)},
+			ExpectedPerm: 0o666,
+		},
+		"existing file preserves mode": {
+			Existing:     ptr(fs.FileMode(0o755)),
+			Options:      []file.WriteOption{file.WithPerm(0o600)},
+			ExpectedPerm: 0o755,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			name := filepath.Join(dir, "out")
+
+			expected := tc.ExpectedPerm
+
+			if tc.Existing != nil {
+				require.NoError(t, os.WriteFile(name, []byte("old contents"), 0o600))
+				require.NoError(t, os.Chmod(name, *tc.Existing))
+			} else {
+				expected = umasked(t, expected)
+			}
+
+			require.NoError(t, file.WriteFileAtomic(name, []byte("new"), tc.Options...))
+
+			data, err := os.ReadFile(name)
+			require.NoError(t, err)
+
+			assert.Equal(t, "new", string(data))
+
+			info, err := os.Stat(name)
+			require.NoError(t, err)
+
+			assert.Equal(t, expected, info.Mode().Perm())
+
+			ents, err := os.ReadDir(dir)
+			require.NoError(t, err)
+
+			assert.Len(t, ents, 1, "temporary files remain")
+		})
+	}
+}
+
+// umasked returns perm masked by the umask
+// as applied by os.WriteFile.
+func umasked(t *testing.T, perm fs.FileMode) fs.FileMode {
+	t.Helper()
+
+	name := filepath.Join(t.TempDir(), "umask")
+
+	require.NoError(t, os.WriteFile(name, nil, perm))
+
+	info, err := os.Stat(name)
+	require.NoError(t, err)
+
+	return info.Mode().Perm()
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestWriteAtomicFailure(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "out")
+
+	require.NoError(t, os.WriteFile(name, []byte("old"), 0o644))
+
+	errWrite := errors.New("write failed")
+
+	err := file.WriteAtomic(name, func(w io.Writer) error {
+		_, _ = io.WriteString(w, "partial")
+
+		return errWrite
+	})
+	require.ErrorIs(t, err, errWrite)
+
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+
+	assert.Equal(t, "old", string(data))
+
+	ents, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	assert.Len(t, ents, 1, "temporary files remain")
+}
+
+func TestWriteAtomicSymlink(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Files map[string]string
+	}{
+		"existing target": {
+			Files: map[string]string{"target": "old"},
+		},
+		"dangling": {},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+
+			filetest.WriteTree(t, dir, tc.Files)
+			require.NoError(t, os.Symlink("target", filepath.Join(dir, "link")))
+
+			require.NoError(t, file.WriteFileAtomic(filepath.Join(dir, "link"), []byte("new")))
+
+			assert.Equal(t, map[string]string{
+				"link":   "-> target",
+				"target": "new",
+			}, filetest.ReadTree(t, dir))
+		})
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/blang/semver/v4"
 	"github.com/mt-sre/go-ci/command"
@@ -139,8 +141,9 @@ type LatestTagOption interface {
 
 // LatestVersion returns the latest tag, "v" prefixed,
 // as determined by comparing all tags as semantic versions.
-// An error will be returned if there are no available tags
-// or any tag is not parseable as a semantic version.
+// Tags which are not parseable as semantic versions are
+// ignored and an error wrapping ErrNoTagsFound is returned
+// if there are no other tags.
 func LatestVersion(ctx context.Context, opts ...LatestVersionOption) (string, error) {
 	var cfg LatestVersionConfig
 
@@ -161,17 +164,26 @@ func LatestVersion(ctx context.Context, opts ...LatestVersionOption) (string, er
 		return "", ErrNoTagsFound
 	}
 
-	var versions []semver.Version
+	var (
+		versions []semver.Version
+		parseErr error
+	)
 
 	for _, t := range tags {
 		ver, err := semver.ParseTolerant(t)
 		if err != nil {
-			return "", fmt.Errorf("parsing version %q: %w", ver, err)
+			parseErr = fmt.Errorf("parsing version %q: %w", t, err)
+
+			continue
 		}
 
 		versions = append(versions, ver)
 	}
 
+	if len(versions) < 1 {
+		return "", fmt.Errorf("%w: %w", ErrNoTagsFound, parseErr)
+	}
+
 	semver.Sort(versions)
 
 	return "v" + versions[len(versions)-1].String(), nil
@@ -411,4 +423,112 @@ func (f StatusFormat) ToGitValue() string {
 	}
 }
 
+// ErrRemoteNotFound is returned when the requested
+// remote is not configured for the git repository.
+var ErrRemoteNotFound = errors.New("remote not found")
+
+// RemoteURL returns the URL of a remote, "origin" by default,
+// given a variadic slice of options. ErrRemoteNotFound is
+// returned if the remote does not exist.
+func RemoteURL(ctx context.Context, opts ...RemoteURLOption) (string, error) {
+	var cfg RemoteURLConfig
+
+	cfg.Option(opts...)
+	cfg.Default()
+
+	cmdOpts := []command.CommandOption{
+		command.WithContext{Context: ctx},
+		command.WithArgs{"remote", "get-url", cfg.Remote},
+	}
+
+	if cfg.WorkingDir != "" {
+		cmdOpts = append(cmdOpts, command.WithWorkingDirectory(cfg.WorkingDir))
+	}
+
+	getURL := git(cmdOpts...)
+	if err := getURL.Run(); err != nil {
+		return "", fmt.Errorf("starting to get remote url: %w", err)
+	}
+
+	// git exits with code 2 for unknown remotes
+	if getURL.ExitCode() == 2 {
+		return "", fmt.Errorf("getting url for remote %q: %w", cfg.Remote, ErrRemoteNotFound)
+	}
+
+	if !getURL.Success() {
+		return "", fmt.Errorf("getting remote url: %w", getURL.Error())
+	}
+
+	return strings.TrimSpace(getURL.Stdout()), nil
+}
+
+type RemoteURLConfig struct {
+	Remote     string
+	WorkingDir string
+}
+
+func (c *RemoteURLConfig) Option(opts ...RemoteURLOption) {
+	for _, opt := range opts {
+		opt.ConfigureRemoteURL(c)
+	}
+}
+
+func (c *RemoteURLConfig) Default() {
+	if c.Remote == "" {
+		c.Remote = "origin"
+	}
+}
+
+type RemoteURLOption interface {
+	ConfigureRemoteURL(*RemoteURLConfig)
+}
+
+// CommitTime returns the committer date of HEAD given a
+// variadic slice of options. An error is returned if the
+// commit time cannot be retrieved.
+func CommitTime(ctx context.Context, opts ...CommitTimeOption) (time.Time, error) {
+	var cfg CommitTimeConfig
+
+	cfg.Option(opts...)
+
+	cmdOpts := []command.CommandOption{
+		command.WithContext{Context: ctx},
+		command.WithArgs{"show", "--no-patch", "--format=%ct", "HEAD"},
+	}
+
+	if cfg.WorkingDir != "" {
+		cmdOpts = append(cmdOpts, command.WithWorkingDirectory(cfg.WorkingDir))
+	}
+
+	show := git(cmdOpts...)
+	if err := show.Run(); err != nil {
+		return time.Time{}, fmt.Errorf("starting to get commit time: %w", err)
+	}
+
+	if !show.Success() {
+		return time.Time{}, fmt.Errorf("getting commit time: %w", show.Error())
+	}
+
+	secs, err := strconv.ParseInt(strings.TrimSpace(show.Stdout()), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing commit time: %w", err)
+	}
+
+	return time.Unix(secs, 0).UTC(), nil
+}
+
+type CommitTimeConfig struct {
+	WorkingDir string
+}
+
+func (c *CommitTimeConfig) Option(opts ...CommitTimeOption) {
+	for _, opt := range opts {
+		opt.ConfigureCommitTime(c)
+	}
+}
+
+type CommitTimeOption interface {
+	ConfigureCommitTime(*CommitTimeConfig)
+}
+
 var git = command.NewCommandAlias("git")
@@ -8,6 +8,7 @@ import (
 	"context"
 	"os/exec"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -104,6 +105,34 @@ var _ = Describe("LatestVersion", func() {
 
 		Expect(res).To(Equal("v2.0.0"))
 	})
+
+	It("should ignore tags which are not versions", func() {
+		ctx := context.Background()
+		dir := GinkgoT().TempDir()
+
+		git := func(args ...string) {
+			cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+
+			out, err := cmd.CombinedOutput()
+			Expect(err).ToNot(HaveOccurred(), string(out))
+		}
+
+		git("init", "--quiet")
+		git("-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "--allow-empty", "-m", "initial")
+		git("tag", "nightly")
+
+		_, err := LatestVersion(ctx, WithWorkingDirectory(dir))
+		Expect(err).To(MatchError(ErrNoTagsFound))
+		Expect(err).To(MatchError(ContainSubstring(`"nightly"`)))
+
+		git("tag", "v1.1.0")
+		git("tag", "v1.0.0")
+
+		res, err := LatestVersion(ctx, WithWorkingDirectory(dir))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(res).To(Equal("v1.1.0"))
+	})
 })
 
 type statusTestCase struct {
@@ -195,3 +224,32 @@ var _ = DescribeTable("Diff",
 		},
 	),
 )
+
+var _ = Describe("RemoteURL", func() {
+	It("should return the origin url", func() {
+		ctx := context.Background()
+
+		res, err := RemoteURL(ctx, WithWorkingDirectory(_temp))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(res).To(Equal("https://example.com/org/repo.git"))
+	})
+
+	It("should return ErrRemoteNotFound for unknown remotes", func() {
+		ctx := context.Background()
+
+		_, err := RemoteURL(ctx, WithWorkingDirectory(_temp), WithRemote("dne"))
+		Expect(err).To(MatchError(ErrRemoteNotFound))
+	})
+})
+
+var _ = Describe("CommitTime", func() {
+	It("should return the time of the HEAD commit", func() {
+		ctx := context.Background()
+
+		res, err := CommitTime(ctx, WithWorkingDirectory(_temp))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(res).To(BeTemporally("~", time.Now(), time.Hour))
+	})
+})
@@ -18,6 +18,13 @@ func (w WithRevParseFormat) ConfigureRevParse(c *RevParseConfig) {
 	c.Format = RevParseFormat(w)
 }
 
+// WithRemote selects the named remote.
+type WithRemote string
+
+func (w WithRemote) ConfigureRemoteURL(c *RemoteURLConfig) {
+	c.Remote = string(w)
+}
+
 // WithSorted enables sorting.
 type WithSorted bool
 
@@ -65,3 +72,11 @@ func (w WithWorkingDirectory) ConfigureDiff(c *DiffConfig) {
 func (w WithWorkingDirectory) ConfigureStatus(c *StatusConfig) {
 	c.WorkingDir = string(w)
 }
+
+func (w WithWorkingDirectory) ConfigureRemoteURL(c *RemoteURLConfig) {
+	c.WorkingDir = string(w)
+}
+
+func (w WithWorkingDirectory) ConfigureCommitTime(c *CommitTimeConfig) {
+	c.WorkingDir = string(w)
+}
@@ -31,6 +31,8 @@ function setup() {
 
      git tag "v2.0.0"
 
+     git remote add origin "https://example.com/org/repo.git"
+
      git checkout -b "test"
 }
 
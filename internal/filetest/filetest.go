@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package filetest provides helpers for creating and
+// inspecting directory trees in tests.
+package filetest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mt-sre/go-ci/file"
+	"github.com/stretchr/testify/require"
+)
+
+// WriteTree writes files, keyed by slash separated
+// path, with the given contents below root.
+func WriteTree(t testing.TB, root string, files map[string]string) {
+	t.Helper()
+
+	for name, data := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(data), 0o644))
+	}
+}
+
+// ReadTree returns the contents of regular files and the
+// destinations of symbolic links below root by relative path,
+// with directories listed by their path and a trailing slash.
+func ReadTree(t testing.TB, root string) map[string]string {
+	t.Helper()
+
+	res := make(map[string]string)
+
+	ents, err := file.FindEntries(root, file.WithMinDepth(1))
+	require.NoError(t, err)
+
+	for _, ent := range ents {
+		switch ent.Type {
+		case file.EntTypeFile:
+			data, err := os.ReadFile(ent.Path)
+			require.NoError(t, err)
+
+			res[ent.Rel] = string(data)
+		case file.EntTypeSymlink:
+			res[ent.Rel] = "-> " + ent.Target
+		case file.EntTypeDir:
+			res[ent.Rel+"/"] = ""
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,275 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package reuse
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mt-sre/go-ci/file"
+)
+
+// Annotate adds the configured copyright notice and license
+// to the header of the file at path unless it already holds
+// them reporting whether the file was changed. When the file
+// holds only one of them the other is added to its existing
+// header. Headers are inserted after any shebang, XML declaration,
+// encoding declaration or editor modeline and separated from the
+// rest of the file by a blank line which keeps Go build
+// constraints valid. Binary files and files without a known
+// comment style are annotated using ".license" sidecar files.
+func Annotate(path string, opts ...AnnotateOption) (bool, error) {
+	var cfg annotateConfig
+
+	cfg.Option(opts...)
+
+	if err := cfg.Default(); err != nil {
+		return false, err
+	}
+
+	f, err := readFile(path, cfg.Styles)
+	if err != nil {
+		return false, err
+	}
+
+	if f.Header.Complete() {
+		return false, nil
+	}
+
+	var lines []string
+
+	if len(f.Header.Copyrights) == 0 {
+		notice := cfg.Copyright
+		if cfg.Year > 0 {
+			notice = fmt.Sprintf("%d %s", cfg.Year, notice)
+		}
+
+		lines = append(lines, tagCopyright+": "+notice)
+	}
+
+	if len(f.Header.Licenses) == 0 {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+
+		lines = append(lines, tagLicense+": "+cfg.License)
+	}
+
+	if !f.commented {
+		return true, annotateSidecar(path, f.Sidecar, lines)
+	}
+
+	data := string(f.data)
+
+	if len(f.Header.Copyrights) == 0 && len(f.Header.Licenses) == 0 {
+		data = insertHeader(data, f.style, lines)
+	} else {
+		data = extendHeader(data, f.style, lines[0], len(f.Header.Licenses) == 0)
+	}
+
+	if err := file.WriteFileAtomic(path, []byte(data)); err != nil {
+		return false, fmt.Errorf("annotating %q: %w", path, err)
+	}
+
+	return true, nil
+}
+
+// annotateSidecar appends lines to the given sidecar file
+// or, if empty, creates the sidecar file of path.
+func annotateSidecar(path, sidecar string, lines []string) error {
+	var data string
+
+	if sidecar == "" {
+		sidecar = path + ".license"
+	} else {
+		existing, err := os.ReadFile(sidecar)
+		if err != nil {
+			return fmt.Errorf("reading %q: %w", sidecar, err)
+		}
+
+		if data = strings.TrimRight(string(existing), "\r\n"); data != "" {
+			data += "\n\n"
+		}
+	}
+
+	data += strings.Join(lines, "\n") + "\n"
+
+	if err := file.WriteFileAtomic(sidecar, []byte(data)); err != nil {
+		return fmt.Errorf("annotating %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// insertHeader inserts lines commented in the given style into
+// text after any shebang, XML declaration, encoding declaration
+// or editor modeline keeping the line endings of text.
+func insertHeader(text string, style CommentStyle, lines []string) string {
+	eol := lineEnding(text)
+
+	var prefix string
+
+	// encoding declarations are only honoured on the first two lines
+	for i := range 2 {
+		first, rest, ok := strings.Cut(text, "\n")
+
+		isDecl := i == 0 && (strings.HasPrefix(first, "#!") || strings.HasPrefix(first, "<?xml"))
+		if !isDecl && !isPragma(first, style) {
+			break
+		}
+
+		if !ok {
+			first = strings.TrimSuffix(first, "\r") + eol
+		} else {
+			first += "\n"
+		}
+
+		prefix, text = prefix+first, rest
+	}
+
+	header := style.comment(lines, eol)
+
+	if text != "" && !strings.HasPrefix(text, "\n") && !strings.HasPrefix(text, "\r\n") {
+		header += eol
+	}
+
+	return prefix + header + text
+}
+
+// pragmaRe matches encoding declarations, e.g. "-*- coding: utf-8 -*-",
+// and editor modelines, e.g. "vim: set ft=python:".
+var pragmaRe = regexp.MustCompile(`-\*-.*-\*-|coding[:=]|\bvim?:`)
+
+// isPragma reports whether line is a comment in the given
+// style holding an encoding declaration or editor modeline.
+func isPragma(line string, style CommentStyle) bool {
+	trimmed := strings.TrimSpace(line)
+
+	return style.Line != "" && strings.HasPrefix(trimmed, style.Line) && pragmaRe.MatchString(trimmed)
+}
+
+// extendHeader adds the given tag to the existing header of text
+// commented in the given style. The tag is added after the last
+// tag or copyright notice of the header if after is set and
+// otherwise before the first.
+func extendHeader(text string, style CommentStyle, tag string, after bool) string {
+	var anchor headerLine
+
+	for _, line := range commentLines(text, style) {
+		trimmed := strings.TrimSpace(line.text)
+		if !isCopyrightNotice(trimmed) && !strings.HasPrefix(trimmed, "SPDX-") {
+			continue
+		}
+
+		if anchor.num == 0 || after {
+			anchor = line
+		}
+	}
+
+	var (
+		eol   = lineEnding(text)
+		lines = strings.SplitAfter(text, "\n")
+		raw   = strings.TrimRight(lines[anchor.num-1], "\r\n")
+		// the tag is aligned with the text of the anchor line
+		prefix = raw[:strings.Index(raw, strings.TrimSpace(anchor.text))]
+		idx    = anchor.num - 1
+		added  string
+	)
+
+	if after {
+		idx++
+
+		if !strings.HasSuffix(lines[anchor.num-1], "\n") {
+			lines[anchor.num-1] += eol
+		}
+	}
+
+	switch {
+	case style.Line != "":
+		added = prefix + tag + eol
+	case after && strings.Contains(raw, style.End), !after && strings.Contains(raw, style.Start):
+		// the anchor line ends or starts the comment block
+		added = style.comment([]string{tag}, eol)
+	default:
+		added = strings.Replace(prefix, style.Start, strings.Repeat(" ", len(style.Start)), 1) + tag + eol
+	}
+
+	return strings.Join(lines[:idx], "") + added + strings.Join(lines[idx:], "")
+}
+
+// lineEnding returns the line ending used by text.
+func lineEnding(text string) string {
+	if strings.Contains(text, "\r\n") {
+		return "\r\n"
+	}
+
+	return "\n"
+}
+
+type annotateConfig struct {
+	Copyright string
+	License   string
+	Styles    map[string]CommentStyle
+	Year      int
+}
+
+func (c *annotateConfig) Option(opts ...AnnotateOption) {
+	for _, opt := range opts {
+		opt.ConfigureAnnotate(c)
+	}
+}
+
+func (c *annotateConfig) Default() error {
+	if c.Copyright == "" {
+		return errors.New("copyright holder must be set")
+	}
+
+	if err := validateExpression(c.License); err != nil {
+		return err
+	}
+
+	if c.Year == 0 {
+		c.Year = time.Now().Year()
+	}
+
+	return nil
+}
+
+type AnnotateOption interface {
+	ConfigureAnnotate(*annotateConfig)
+}
+
+// WithCopyright sets the holder of the copyright notice added
+// to files, e.g. "Red Hat, Inc. <sd-mt-sre@redhat.com>".
+type WithCopyright string
+
+func (h WithCopyright) ConfigureAnnotate(c *annotateConfig) {
+	c.Copyright = string(h)
+}
+
+// WithLicense sets the SPDX license expression
+// added to files, e.g. "Apache-2.0".
+type WithLicense string
+
+func (l WithLicense) ConfigureAnnotate(c *annotateConfig) {
+	c.License = string(l)
+}
+
+// WithYear sets the year of the copyright notice added to
+// files. By default the current year is used and a negative
+// year omits the year from the notice.
+type WithYear int
+
+func (y WithYear) ConfigureAnnotate(c *annotateConfig) {
+	c.Year = int(y)
+}
+
+func (s WithCommentStyles) ConfigureAnnotate(c *annotateConfig) {
+	c.Styles = mergeStyles(c.Styles, s)
+}
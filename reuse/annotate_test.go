@@ -0,0 +1,205 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package reuse_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mt-sre/go-ci/internal/filetest"
+	"github.com/mt-sre/go-ci/reuse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotate(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Name     string
+		Data     string
+		Sidecar  string
+		Options  []reuse.AnnotateOption
+		Changed  bool
+		Expected map[string]string
+	}{
+		"go": {
+			Name:    "main.go",
+			Data:    "package main\n",
+			Changed: true,
+			Expected: map[string]string{
+				"main.go": "// SPDX-FileCopyrightText: 2025 Example\n//\n// SPDX-License-Identifier: MIT\n\npackage main\n",
+			},
+		},
+		"build constraint": {
+			Name:    "main.go",
+			Data:    "//go:build linux\n\npackage main\n",
+			Changed: true,
+			Expected: map[string]string{
+				"main.go": "// SPDX-FileCopyrightText: 2025 Example\n//\n// SPDX-License-Identifier: MIT\n\n//go:build linux\n\npackage main\n",
+			},
+		},
+		"shebang": {
+			Name:    "run.sh",
+			Data:    "#!/bin/sh\r\necho\r\n",
+			Changed: true,
+			Expected: map[string]string{
+				"run.sh": "#!/bin/sh\r\n# SPDX-FileCopyrightText: 2025 Example\r\n#\r\n# SPDX-License-Identifier: MIT\r\n\r\necho\r\n",
+			},
+		},
+		"block comment": {
+			Name:    "README.md",
+			Data:    "",
+			Changed: true,
+			Expected: map[string]string{
+				"README.md": "<!--\nSPDX-FileCopyrightText: 2025 Example\n\nSPDX-License-Identifier: MIT\n-->\n",
+			},
+		},
+		"missing license": {
+			Name:    "main.go",
+			Data:    "// Copyright Example\n\npackage main\n",
+			Changed: true,
+			Expected: map[string]string{
+				"main.go": "// Copyright Example\n// SPDX-License-Identifier: MIT\n\npackage main\n",
+			},
+		},
+		"missing copyright": {
+			Name:    "run.sh",
+			Data:    "#!/bin/sh\n# SPDX-License-Identifier: MIT\necho",
+			Changed: true,
+			Expected: map[string]string{
+				"run.sh": "#!/bin/sh\n# SPDX-FileCopyrightText: 2025 Example\n# SPDX-License-Identifier: MIT\necho",
+			},
+		},
+		"missing license in block": {
+			Name:    "README.md",
+			Data:    "<!--\r\n  Copyright Example\r\n-->\r\n# Title\r\n",
+			Changed: true,
+			Expected: map[string]string{
+				"README.md": "<!--\r\n  Copyright Example\r\n  SPDX-License-Identifier: MIT\r\n-->\r\n# Title\r\n",
+			},
+		},
+		"missing license in single line block": {
+			Name:    "README.md",
+			Data:    "<!-- Copyright Example -->\n# Title\n",
+			Changed: true,
+			Expected: map[string]string{
+				"README.md": "<!-- Copyright Example -->\n<!--\nSPDX-License-Identifier: MIT\n-->\n# Title\n",
+			},
+		},
+		"encoding": {
+			Name:    "main.py",
+			Data:    "# -*- coding: latin-1 -*-\nimport os\n",
+			Changed: true,
+			Expected: map[string]string{
+				"main.py": "# -*- coding: latin-1 -*-\n# SPDX-FileCopyrightText: 2025 Example\n#\n# SPDX-License-Identifier: MIT\n\nimport os\n",
+			},
+		},
+		"shebang and encoding": {
+			Name:    "run.rb",
+			Data:    "#!/usr/bin/env ruby\n# encoding: utf-8\nputs 1\n",
+			Changed: true,
+			Expected: map[string]string{
+				"run.rb": "#!/usr/bin/env ruby\n# encoding: utf-8\n# SPDX-FileCopyrightText: 2025 Example\n#\n# SPDX-License-Identifier: MIT\n\nputs 1\n",
+			},
+		},
+		"modeline": {
+			Name:    "run.sh",
+			Data:    "# vim: set ft=sh:\n# other comment\necho\n",
+			Changed: true,
+			Expected: map[string]string{
+				"run.sh": "# vim: set ft=sh:\n# SPDX-FileCopyrightText: 2025 Example\n#\n# SPDX-License-Identifier: MIT\n\n# other comment\necho\n",
+			},
+		},
+		"complete": {
+			Name: "main.go",
+			Data: goHeader + "\npackage main\n",
+			Expected: map[string]string{
+				"main.go": goHeader + "\npackage main\n",
+			},
+		},
+		"without year": {
+			Name:    "Makefile",
+			Data:    "all:\n",
+			Options: []reuse.AnnotateOption{reuse.WithYear(-1)},
+			Changed: true,
+			Expected: map[string]string{
+				"Makefile": "# SPDX-FileCopyrightText: Example\n#\n# SPDX-License-Identifier: MIT\n\nall:\n",
+			},
+		},
+		"new sidecar": {
+			Name:    "data.json",
+			Data:    "{}\n",
+			Changed: true,
+			Expected: map[string]string{
+				"data.json":         "{}\n",
+				"data.json.license": "SPDX-FileCopyrightText: 2025 Example\n\nSPDX-License-Identifier: MIT\n",
+			},
+		},
+		"existing sidecar": {
+			Name:    "main.go",
+			Data:    "package main\n",
+			Sidecar: "SPDX-FileCopyrightText: Example\n",
+			Changed: true,
+			Expected: map[string]string{
+				"main.go":         "package main\n",
+				"main.go.license": "SPDX-FileCopyrightText: Example\n\nSPDX-License-Identifier: MIT\n",
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root := t.TempDir()
+			files := map[string]string{tc.Name: tc.Data}
+
+			if tc.Sidecar != "" {
+				files[tc.Name+".license"] = tc.Sidecar
+			}
+
+			filetest.WriteTree(t, root, files)
+
+			opts := append([]reuse.AnnotateOption{
+				reuse.WithCopyright("Example"),
+				reuse.WithLicense("MIT"),
+				reuse.WithYear(2025),
+			}, tc.Options...)
+
+			changed, err := reuse.Annotate(filepath.Join(root, tc.Name), opts...)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.Changed, changed)
+
+			for name, expected := range tc.Expected {
+				data, err := os.ReadFile(filepath.Join(root, name))
+				require.NoError(t, err)
+
+				assert.Equal(t, expected, string(data), name)
+			}
+
+			res, err := reuse.Check([]string{filepath.Join(root, tc.Name)})
+			require.NoError(t, err)
+
+			assert.True(t, res.Compliant())
+		})
+	}
+}
+
+func TestAnnotateInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "main.go")
+
+	filetest.WriteTree(t, filepath.Dir(path), map[string]string{"main.go": "package main\n"})
+
+	_, err := reuse.Annotate(path, reuse.WithLicense("MIT"))
+	assert.Error(t, err)
+
+	_, err = reuse.Annotate(path, reuse.WithCopyright("Example"), reuse.WithLicense("MIT OR"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,407 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package reuse
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mt-sre/go-ci/file"
+)
+
+// ErrNotCompliant is returned when files lack valid
+// copyright and licensing information.
+var ErrNotCompliant = errors.New("not REUSE compliant")
+
+// ProblemKind describes a problem with the
+// licensing information of a file.
+type ProblemKind string
+
+const (
+	// ProblemMissingCopyright is a file without a copyright notice.
+	ProblemMissingCopyright ProblemKind = "missing copyright"
+	// ProblemMissingLicense is a file without a license identifier.
+	ProblemMissingLicense ProblemKind = "missing license"
+	// ProblemMalformed is a file with a malformed SPDX tag.
+	ProblemMalformed ProblemKind = "malformed header"
+	// ProblemMissingLicenseText is a file whose license's text
+	// is not in the "LICENSES" directory.
+	ProblemMissingLicenseText ProblemKind = "missing license text"
+)
+
+// Problem is a problem with the licensing information of a file.
+type Problem struct {
+	Kind   ProblemKind
+	Detail string
+	// Line is the one based line number of malformed tags.
+	Line int
+}
+
+// FileReport describes the licensing information of a file.
+type FileReport struct {
+	Path   string
+	Header Header
+	// Sidecar is the path of the ".license" file holding
+	// the licensing information, if any.
+	Sidecar  string
+	Problems []Problem
+}
+
+// Report describes the licensing information of a set of files.
+type Report struct {
+	Files []FileReport
+	// UnusedLicenses are the identifiers of license texts
+	// in the "LICENSES" directory not used by any file.
+	UnusedLicenses []string
+}
+
+// Compliant reports whether every file holds valid copyright
+// and licensing information and every license text is used.
+func (r Report) Compliant() bool {
+	for _, f := range r.Files {
+		if len(f.Problems) > 0 {
+			return false
+		}
+	}
+
+	return len(r.UnusedLicenses) == 0
+}
+
+// String lists problems in the form "<path>:<line>: <kind>: <detail>".
+func (r Report) String() string {
+	var sb strings.Builder
+
+	for _, f := range r.Files {
+		name := f.Path
+		if f.Sidecar != "" {
+			name = f.Sidecar
+		}
+
+		for _, p := range f.Problems {
+			sb.WriteString(name)
+
+			if p.Line > 0 {
+				fmt.Fprintf(&sb, ":%d", p.Line)
+			}
+
+			fmt.Fprintf(&sb, ": %s", p.Kind)
+
+			if p.Detail != "" {
+				fmt.Fprintf(&sb, ": %s", p.Detail)
+			}
+
+			sb.WriteString("\n")
+		}
+	}
+
+	for _, id := range r.UnusedLicenses {
+		fmt.Fprintf(&sb, "unused license: %s\n", id)
+	}
+
+	return sb.String()
+}
+
+// Check reports the licensing information of the files at the
+// given paths, e.g. as returned by file.Find, returning an error
+// wrapping ErrNotCompliant when any file has problems. Licensing
+// information is read from the SPDX tags within the leading
+// comments of files or from their ".license" sidecar files.
+func Check(paths []string, opts ...CheckOption) (Report, error) {
+	var cfg checkConfig
+
+	cfg.Option(opts...)
+
+	res, err := cfg.check(paths)
+	if err != nil {
+		return res, err
+	}
+
+	if !res.Compliant() {
+		return res, fmt.Errorf("%w: %d of %d files have problems", ErrNotCompliant, res.failed(), len(res.Files))
+	}
+
+	return res, nil
+}
+
+// CheckTree checks the files below root as Check does and, like
+// "reuse lint", checks the texts of every used license, and only
+// those, are in the "LICENSES" directory of root. Files ignored
+// by git, license texts, ".license" sidecar files and files named
+// "LICENSE" or "COPYING" are not checked.
+func CheckTree(root string, opts ...CheckOption) (Report, error) {
+	var cfg checkConfig
+
+	cfg.Option(opts...)
+
+	paths, err := file.Find(root,
+		file.WithEntType(file.EntTypeFile),
+		file.WithGitIgnore(true),
+		file.WithExcludes(append([]string{
+			"/LICENSES/", "/.reuse/", "*.license",
+			"LICENSE", "LICENSE.*", "COPYING", "COPYING.*",
+		}, cfg.Excludes...)),
+		file.WithSorted(true),
+	)
+	if err != nil {
+		return Report{}, fmt.Errorf("finding files below %q: %w", root, err)
+	}
+
+	res, err := cfg.check(paths)
+	if err != nil {
+		return res, err
+	}
+
+	texts, err := licenseTexts(filepath.Join(root, "LICENSES"))
+	if err != nil {
+		return res, err
+	}
+
+	used := make(map[string]bool)
+
+	for i, f := range res.Files {
+		for _, id := range f.Header.LicenseIDs() {
+			used[id] = true
+
+			if !texts[id] {
+				res.Files[i].Problems = append(res.Files[i].Problems, Problem{
+					Kind:   ProblemMissingLicenseText,
+					Detail: fmt.Sprintf("LICENSES/%s.txt does not exist", id),
+				})
+			}
+		}
+	}
+
+	for id := range texts {
+		if !used[id] {
+			res.UnusedLicenses = append(res.UnusedLicenses, id)
+		}
+	}
+
+	slices.Sort(res.UnusedLicenses)
+
+	if !res.Compliant() {
+		return res, fmt.Errorf("%q: %w: %d of %d files have problems", root, ErrNotCompliant, res.failed(), len(res.Files))
+	}
+
+	return res, nil
+}
+
+// failed returns the number of files with problems.
+func (r Report) failed() int {
+	var res int
+
+	for _, f := range r.Files {
+		if len(f.Problems) > 0 {
+			res++
+		}
+	}
+
+	return res
+}
+
+// licenseTexts returns the set of license identifiers
+// whose texts are in the given directory.
+func licenseTexts(dir string) (map[string]bool, error) {
+	ents, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading %q: %w", dir, err)
+	}
+
+	res := make(map[string]bool, len(ents))
+
+	for _, ent := range ents {
+		if ent.Type().IsRegular() {
+			res[strings.TrimSuffix(ent.Name(), filepath.Ext(ent.Name()))] = true
+		}
+	}
+
+	return res, nil
+}
+
+func (c *checkConfig) check(paths []string) (Report, error) {
+	res := Report{Files: make([]FileReport, 0, len(paths))}
+
+	for _, p := range paths {
+		f, err := readFile(p, c.Styles)
+		if err != nil {
+			return res, err
+		}
+
+		if len(f.Header.Copyrights) == 0 {
+			f.Problems = append(f.Problems, Problem{Kind: ProblemMissingCopyright, Detail: f.hint()})
+		}
+
+		if len(f.Header.Licenses) == 0 {
+			f.Problems = append(f.Problems, Problem{Kind: ProblemMissingLicense, Detail: f.hint()})
+		}
+
+		res.Files = append(res.Files, f.FileReport)
+	}
+
+	return res, nil
+}
+
+// licensedFile is a file whose licensing information has been read.
+type licensedFile struct {
+	FileReport
+	data  []byte
+	style CommentStyle
+	// commented is set for files with a known comment style
+	// which are not binary and so may hold a header
+	commented bool
+}
+
+// hint suggests how missing information may be added.
+func (f *licensedFile) hint() string {
+	if f.commented || f.Sidecar != "" {
+		return ""
+	}
+
+	return fmt.Sprintf("no comment style is known for the file so %s.license must be added", filepath.Base(f.Path))
+}
+
+// readFile reads the licensing information of the file at path
+// from its ".license" sidecar file or otherwise its header.
+func readFile(path string, styles map[string]CommentStyle) (*licensedFile, error) {
+	res := &licensedFile{FileReport: FileReport{Path: path}}
+
+	sidecar := path + ".license"
+
+	data, err := os.ReadFile(sidecar)
+	if err == nil {
+		res.Sidecar = sidecar
+		res.Header, res.Problems = parseHeader(plainLines(string(data)))
+
+		return res, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading %q: %w", sidecar, err)
+	}
+
+	if res.data, err = os.ReadFile(path); err != nil {
+		return nil, fmt.Errorf("reading %q: %w", path, err)
+	}
+
+	res.style, res.commented = styleOf(path, styles)
+
+	// binary files must use sidecar files
+	if bytes.IndexByte(res.data[:min(len(res.data), 8000)], 0) >= 0 {
+		res.commented = false
+	}
+
+	if res.commented {
+		res.Header, res.Problems = parseHeader(commentLines(string(res.data), res.style))
+	}
+
+	return res, nil
+}
+
+// plainLines returns every line of text.
+func plainLines(text string) []headerLine {
+	var res []headerLine
+
+	for i, line := range strings.Split(text, "\n") {
+		res = append(res, headerLine{text: strings.TrimSuffix(line, "\r"), num: i + 1})
+	}
+
+	return res
+}
+
+// commentLines returns the text of the leading comments of text
+// in the given style skipping any shebang, XML declaration and
+// blank lines.
+func commentLines(text string, style CommentStyle) []headerLine {
+	var (
+		res     []headerLine
+		inBlock bool
+	)
+
+	for i, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case inBlock:
+			if before, _, ok := strings.Cut(trimmed, style.End); ok {
+				trimmed, inBlock = before, false
+			}
+
+			// lines of C-style block comments are often prefixed by "*"
+			if style.Start == "/*" {
+				trimmed = strings.TrimPrefix(trimmed, "*")
+			}
+		case i == 0 && (strings.HasPrefix(trimmed, "#!") || strings.HasPrefix(trimmed, "<?xml")):
+			continue
+		case trimmed == "":
+			continue
+		case style.Line != "" && strings.HasPrefix(trimmed, style.Line):
+			trimmed = strings.TrimPrefix(trimmed, style.Line)
+		case style.Line == "" && strings.HasPrefix(trimmed, style.Start):
+			trimmed = strings.TrimPrefix(trimmed, style.Start)
+
+			if before, _, ok := strings.Cut(trimmed, style.End); ok {
+				trimmed = before
+			} else {
+				inBlock = true
+			}
+		default:
+			return res
+		}
+
+		res = append(res, headerLine{text: trimmed, num: i + 1})
+	}
+
+	return res
+}
+
+type checkConfig struct {
+	Excludes []string
+	Styles   map[string]CommentStyle
+}
+
+func (c *checkConfig) Option(opts ...CheckOption) {
+	for _, opt := range opts {
+		opt.ConfigureCheck(c)
+	}
+}
+
+type CheckOption interface {
+	ConfigureCheck(*checkConfig)
+}
+
+// WithCommentStyles adds or overrides the comment styles of
+// files keyed by extension, e.g. ".go", or by name for files
+// without an extension, e.g. "Makefile".
+type WithCommentStyles map[string]CommentStyle
+
+func (s WithCommentStyles) ConfigureCheck(c *checkConfig) {
+	c.Styles = mergeStyles(c.Styles, s)
+}
+
+func mergeStyles(dst, src map[string]CommentStyle) map[string]CommentStyle {
+	if dst == nil {
+		dst = make(map[string]CommentStyle, len(src))
+	}
+
+	for k, v := range src {
+		dst[k] = v
+	}
+
+	return dst
+}
+
+// WithExcludes excludes files matching any of the given patterns,
+// as understood by file.WithExcludes, from being checked.
+type WithExcludes []string
+
+func (e WithExcludes) ConfigureCheck(c *checkConfig) {
+	c.Excludes = append(c.Excludes, e...)
+}
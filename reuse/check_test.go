@@ -0,0 +1,163 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package reuse_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mt-sre/go-ci/internal/filetest"
+	"github.com/mt-sre/go-ci/reuse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const goHeader = "// SPDX-FileCopyrightText: 2025 Example\n//\n// SPDX-License-Identifier: MIT\n"
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		Name     string
+		Data     string
+		Sidecar  string
+		Options  []reuse.CheckOption
+		Header   reuse.Header
+		Problems []reuse.Problem
+	}{
+		"go": {
+			Name:   "main.go",
+			Data:   goHeader + "\npackage main\n",
+			Header: reuse.Header{Copyrights: []string{"2025 Example"}, Licenses: []string{"MIT"}},
+		},
+		"build constraint": {
+			Name:   "main.go",
+			Data:   "//go:build linux\n\n" + goHeader + "\npackage main\n",
+			Header: reuse.Header{Copyrights: []string{"2025 Example"}, Licenses: []string{"MIT"}},
+		},
+		"shebang": {
+			Name:   "run.sh",
+			Data:   "#!/bin/sh\n# Copyright 2024 Example\n# SPDX-License-Identifier: MIT OR Apache-2.0\necho\n",
+			Header: reuse.Header{Copyrights: []string{"Copyright 2024 Example"}, Licenses: []string{"MIT OR Apache-2.0"}},
+		},
+		"block comment": {
+			Name:   "style.css",
+			Data:   "/*\n * SPDX-FileCopyrightText: Example\n * SPDX-License-Identifier: MIT\n */\nbody {}\n",
+			Header: reuse.Header{Copyrights: []string{"Example"}, Licenses: []string{"MIT"}},
+		},
+		"html comment": {
+			Name:   "README.md",
+			Data:   "<!-- SPDX-FileCopyrightText: Example -->\n<!-- SPDX-License-Identifier: MIT -->\n# Title\n",
+			Header: reuse.Header{Copyrights: []string{"Example"}, Licenses: []string{"MIT"}},
+		},
+		"tags after code": {
+			Name: "main.go",
+			Data: "package main\n\n" + goHeader,
+			Problems: []reuse.Problem{
+				{Kind: reuse.ProblemMissingCopyright},
+				{Kind: reuse.ProblemMissingLicense},
+			},
+		},
+		"malformed": {
+			Name:   "main.go",
+			Data:   "// SPDX-FileCopyrightText: Example\n// SPDX-License-Identifer: MIT\n// SPDX-License-Identifier: MIT AND\n",
+			Header: reuse.Header{Copyrights: []string{"Example"}},
+			Problems: []reuse.Problem{
+				{Kind: reuse.ProblemMalformed, Line: 2, Detail: `unknown tag "SPDX-License-Identifer"`},
+				{Kind: reuse.ProblemMalformed, Line: 3, Detail: `invalid license expression "MIT AND": incomplete`},
+				{Kind: reuse.ProblemMissingLicense},
+			},
+		},
+		"sidecar": {
+			Name:    "go.sum",
+			Data:    "example.com/x v1.0.0 h1:...\n",
+			Sidecar: "SPDX-FileCopyrightText: Example\n\nSPDX-License-Identifier: MIT\n",
+			Header:  reuse.Header{Copyrights: []string{"Example"}, Licenses: []string{"MIT"}},
+		},
+		"unknown style": {
+			Name: "data.json",
+			Data: "{}\n",
+			Problems: []reuse.Problem{
+				{Kind: reuse.ProblemMissingCopyright, Detail: "no comment style is known for the file so data.json.license must be added"},
+				{Kind: reuse.ProblemMissingLicense, Detail: "no comment style is known for the file so data.json.license must be added"},
+			},
+		},
+		"custom style": {
+			Name:    "data.jsonc",
+			Data:    "// SPDX-FileCopyrightText: Example\n// SPDX-License-Identifier: MIT\n{}\n",
+			Options: []reuse.CheckOption{reuse.WithCommentStyles{".jsonc": reuse.StyleC}},
+			Header:  reuse.Header{Copyrights: []string{"Example"}, Licenses: []string{"MIT"}},
+		},
+		"binary": {
+			Name: "image.svg",
+			Data: "\x00<!-- SPDX-FileCopyrightText: Example -->\n",
+			Problems: []reuse.Problem{
+				{Kind: reuse.ProblemMissingCopyright, Detail: "no comment style is known for the file so image.svg.license must be added"},
+				{Kind: reuse.ProblemMissingLicense, Detail: "no comment style is known for the file so image.svg.license must be added"},
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root := t.TempDir()
+			files := map[string]string{tc.Name: tc.Data}
+
+			if tc.Sidecar != "" {
+				files[tc.Name+".license"] = tc.Sidecar
+			}
+
+			filetest.WriteTree(t, root, files)
+
+			path := filepath.Join(root, tc.Name)
+
+			res, err := reuse.Check([]string{path}, tc.Options...)
+			if tc.Problems == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, reuse.ErrNotCompliant)
+			}
+
+			require.Len(t, res.Files, 1)
+
+			assert.Equal(t, tc.Header, res.Files[0].Header)
+			assert.Equal(t, tc.Problems, res.Files[0].Problems)
+		})
+	}
+}
+
+func TestCheckTree(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	filetest.WriteTree(t, root, map[string]string{
+		".gitignore":       "# SPDX-FileCopyrightText: Example\n# SPDX-License-Identifier: MIT\nbuild/\n",
+		"LICENSES/MIT.txt": "MIT License\n",
+		"LICENSES/ISC.txt": "ISC License\n",
+		"LICENSE":          "MIT License\n",
+		"build/out":        "ignored\n",
+		"main.go":          goHeader + "\npackage main\n",
+		"gpl.go":           "// SPDX-FileCopyrightText: Example\n// SPDX-License-Identifier: GPL-2.0-or-later\n",
+		"none.go":          "package main\n",
+		"vendor/x/x.go":    "package x\n",
+		"logo.png":         "\x89PNG\x00",
+		"logo.png.license": "SPDX-FileCopyrightText: Example\n\nSPDX-License-Identifier: MIT\n",
+	})
+
+	res, err := reuse.CheckTree(root, reuse.WithExcludes{"vendor/"})
+	require.ErrorIs(t, err, reuse.ErrNotCompliant)
+
+	assert.False(t, res.Compliant())
+	assert.Equal(t, []string{"ISC"}, res.UnusedLicenses)
+	assert.Len(t, res.Files, 5)
+	assert.Equal(t, ""+
+		filepath.Join(root, "gpl.go")+": missing license text: LICENSES/GPL-2.0-or-later.txt does not exist\n"+
+		filepath.Join(root, "none.go")+": missing copyright\n"+
+		filepath.Join(root, "none.go")+": missing license\n"+
+		"unused license: ISC\n", res.String())
+}
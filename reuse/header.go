@@ -0,0 +1,195 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package reuse
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	tagCopyright   = "SPDX-FileCopyrightText"
+	tagLicense     = "SPDX-License-Identifier"
+	tagContributor = "SPDX-FileContributor"
+)
+
+// Header holds the licensing information of a file.
+type Header struct {
+	// Copyrights are the copyright notices of the file.
+	Copyrights []string
+	// Licenses are the SPDX license expressions of the file.
+	Licenses []string
+}
+
+// Complete reports whether the header holds both
+// copyright and licensing information.
+func (h Header) Complete() bool {
+	return len(h.Copyrights) > 0 && len(h.Licenses) > 0
+}
+
+// LicenseIDs returns the license and exception identifiers
+// referenced by the header's license expressions.
+func (h Header) LicenseIDs() []string {
+	var res []string
+
+	for _, expr := range h.Licenses {
+		for _, tok := range tokenize(expr) {
+			switch strings.ToUpper(tok) {
+			case "AND", "OR", "WITH", "(", ")":
+				continue
+			}
+
+			res = append(res, strings.TrimSuffix(tok, "+"))
+		}
+	}
+
+	return res
+}
+
+// headerLine is a line of a file's header along
+// with its one based line number.
+type headerLine struct {
+	text string
+	num  int
+}
+
+// parseHeader extracts the licensing information from the
+// given header lines reporting any malformed tags.
+func parseHeader(lines []headerLine) (Header, []Problem) {
+	var (
+		res      Header
+		problems []Problem
+	)
+
+	for _, line := range lines {
+		text := strings.TrimSpace(line.text)
+
+		if isCopyrightNotice(text) {
+			res.Copyrights = append(res.Copyrights, text)
+
+			continue
+		}
+
+		if !strings.HasPrefix(text, "SPDX-") {
+			continue
+		}
+
+		tag, value, ok := strings.Cut(text, ":")
+		value = strings.TrimSpace(value)
+
+		switch {
+		case !ok:
+			problems = append(problems, Problem{
+				Kind: ProblemMalformed, Line: line.num,
+				Detail: fmt.Sprintf("expected %q followed by a colon", tag),
+			})
+		case tag == tagCopyright:
+			if value == "" {
+				problems = append(problems, Problem{
+					Kind: ProblemMalformed, Line: line.num, Detail: "empty copyright notice",
+				})
+
+				continue
+			}
+
+			res.Copyrights = append(res.Copyrights, value)
+		case tag == tagLicense:
+			if err := validateExpression(value); err != nil {
+				problems = append(problems, Problem{
+					Kind: ProblemMalformed, Line: line.num, Detail: err.Error(),
+				})
+
+				continue
+			}
+
+			res.Licenses = append(res.Licenses, value)
+		case tag == tagContributor:
+		default:
+			problems = append(problems, Problem{
+				Kind: ProblemMalformed, Line: line.num,
+				Detail: fmt.Sprintf("unknown tag %q", tag),
+			})
+		}
+	}
+
+	return res, problems
+}
+
+// isCopyrightNotice reports whether text is a copyright notice
+// not using the SPDX tag, e.g. "Copyright 2025 Red Hat, Inc.".
+func isCopyrightNotice(text string) bool {
+	for _, prefix := range []string{"Copyright ", "Copyright:", "©", "(C) ", "(c) "} {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var licenseIDRe = regexp.MustCompile(`^[A-Za-z0-9.-]+\+?$`)
+
+// validateExpression checks expr is a syntactically
+// valid SPDX license expression.
+func validateExpression(expr string) error {
+	toks := tokenize(expr)
+	if len(toks) == 0 {
+		return errors.New("empty license expression")
+	}
+
+	var (
+		depth int
+		// operand is set when an identifier or
+		// closing parenthesis was last seen
+		operand bool
+		// exception is set following "WITH"
+		exception bool
+	)
+
+	for _, tok := range toks {
+		switch strings.ToUpper(tok) {
+		case "(":
+			if operand {
+				return fmt.Errorf("invalid license expression %q: unexpected %q", expr, tok)
+			}
+
+			depth++
+		case ")":
+			if !operand || depth == 0 {
+				return fmt.Errorf("invalid license expression %q: unexpected %q", expr, tok)
+			}
+
+			depth--
+		case "AND", "OR", "WITH":
+			if !operand {
+				return fmt.Errorf("invalid license expression %q: unexpected %q", expr, tok)
+			}
+
+			operand, exception = false, strings.EqualFold(tok, "WITH")
+		default:
+			if operand || !licenseIDRe.MatchString(tok) || (exception && strings.HasSuffix(tok, "+")) {
+				return fmt.Errorf("invalid license expression %q: unexpected %q", expr, tok)
+			}
+
+			operand, exception = true, false
+		}
+	}
+
+	if !operand || depth != 0 {
+		return fmt.Errorf("invalid license expression %q: incomplete", expr)
+	}
+
+	return nil
+}
+
+// tokenize splits a license expression into identifiers,
+// operators and parentheses.
+func tokenize(expr string) []string {
+	expr = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr)
+
+	return strings.Fields(expr)
+}
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2025 Red Hat, Inc. <sd-mt-sre@redhat.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package reuse
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// CommentStyle describes the comment syntax of a file type.
+type CommentStyle struct {
+	// Line prefixes single line comments, e.g. "//".
+	Line string
+	// Start and End delimit multi-line comments used when
+	// Line is empty, e.g. "<!--" and "-->".
+	Start string
+	End   string
+}
+
+var (
+	// StyleC is used by C-like languages such as Go.
+	StyleC = CommentStyle{Line: "//"}
+	// StyleCSS is used by stylesheets.
+	StyleCSS = CommentStyle{Start: "/*", End: "*/"}
+	// StyleHTML is used by markup languages such as Markdown.
+	StyleHTML = CommentStyle{Start: "<!--", End: "-->"}
+	// StylePython is used by shells and most configuration formats.
+	StylePython = CommentStyle{Line: "#"}
+	// StyleSQL is used by SQL, Lua and Haskell.
+	StyleSQL = CommentStyle{Line: "--"}
+)
+
+// defaultStyles maps file extensions and, for
+// files without one, names to comment styles.
+var defaultStyles = map[string]CommentStyle{
+	".c": StyleC, ".cc": StyleC, ".cpp": StyleC, ".dart": StyleC,
+	".go": StyleC, ".groovy": StyleC, ".h": StyleC, ".hpp": StyleC,
+	".java": StyleC, ".js": StyleC, ".jsx": StyleC, ".kt": StyleC,
+	".mod": StyleC, ".proto": StyleC, ".rs": StyleC, ".scala": StyleC,
+	".swift": StyleC, ".ts": StyleC, ".tsx": StyleC, ".work": StyleC,
+	"Jenkinsfile": StyleC,
+
+	".css": StyleCSS, ".less": StyleCSS, ".scss": StyleCSS,
+
+	".htm": StyleHTML, ".html": StyleHTML, ".markdown": StyleHTML,
+	".md": StyleHTML, ".svg": StyleHTML, ".vue": StyleHTML, ".xml": StyleHTML,
+
+	".bash": StylePython, ".cfg": StylePython, ".cmake": StylePython,
+	".conf": StylePython, ".dockerignore": StylePython, ".editorconfig": StylePython,
+	".envrc": StylePython, ".gitattributes": StylePython, ".gitignore": StylePython,
+	".gitmodules": StylePython, ".mk": StylePython, ".nix": StylePython,
+	".pl": StylePython, ".properties": StylePython, ".ps1": StylePython,
+	".py": StylePython, ".r": StylePython, ".rb": StylePython, ".sh": StylePython,
+	".tf": StylePython, ".toml": StylePython, ".yaml": StylePython,
+	".yml": StylePython, ".zsh": StylePython,
+	"CODEOWNERS": StylePython, "Containerfile": StylePython, "Dockerfile": StylePython,
+	"Gemfile": StylePython, "Makefile": StylePython, "Rakefile": StylePython,
+	"Vagrantfile": StylePython,
+
+	".hs": StyleSQL, ".lua": StyleSQL, ".sql": StyleSQL,
+}
+
+// CommentStyleOf returns the comment style of the file at the given
+// path based on its name or extension. Files without a known style
+// must be annotated using ".license" sidecar files.
+func CommentStyleOf(path string) (CommentStyle, bool) {
+	return styleOf(path, nil)
+}
+
+// styleOf returns the comment style of the file at the given
+// path preferring those in styles over the default styles.
+func styleOf(path string, styles map[string]CommentStyle) (CommentStyle, bool) {
+	base := filepath.Base(path)
+	ext := strings.ToLower(filepath.Ext(base))
+
+	for _, m := range []map[string]CommentStyle{styles, defaultStyles} {
+		if style, ok := m[base]; ok {
+			return style, true
+		}
+
+		if style, ok := m[ext]; ok && ext != "" {
+			return style, true
+		}
+	}
+
+	return CommentStyle{}, false
+}
+
+// comment formats lines as a comment of the style. Lines of block
+// comments are written between lines holding the delimiters.
+func (s CommentStyle) comment(lines []string, eol string) string {
+	var sb strings.Builder
+
+	if s.Line == "" {
+		sb.WriteString(s.Start + eol)
+	}
+
+	for _, line := range lines {
+		switch {
+		case s.Line == "":
+			sb.WriteString(line)
+		case line == "":
+			sb.WriteString(s.Line)
+		default:
+			sb.WriteString(s.Line + " " + line)
+		}
+
+		sb.WriteString(eol)
+	}
+
+	if s.Line == "" {
+		sb.WriteString(s.End + eol)
+	}
+
+	return sb.String()
+}
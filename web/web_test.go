@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/mt-sre/go-ci/container/registrytest"
 	"github.com/mt-sre/go-ci/web"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -94,3 +95,24 @@ func TestFailedRequestError(t *testing.T) {
 	require.NotNil(t, err, "error should not be nil")
 	assert.Equal(t, "request failed with status 404", err.Error(), "error message should match")
 }
+
+// TestDownloadFileRegistryBlob downloads a blob from an
+// in-process registry as done when fetching image layers.
+func TestDownloadFileRegistryBlob(t *testing.T) {
+	srv := registrytest.NewServer()
+	defer srv.Close()
+
+	layer := []byte("layer contents")
+	digest := srv.Registry.PutBlob("org/app", layer)
+
+	out := filepath.Join(t.TempDir(), "layer")
+
+	require.NoError(t, web.DownloadFile(context.Background(), srv.URL+"/v2/org/app/blobs/"+digest, out))
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, layer, data)
+
+	err = web.DownloadFile(context.Background(), srv.URL+"/v2/org/other/blobs/"+digest, out)
+	assert.Equal(t, web.FailedRequestError(http.StatusNotFound), err)
+}